@@ -7,6 +7,11 @@ import Register from './pages/Register';
 import PostDetail from './pages/PostDetail';
 import CreatePost from './pages/CreatePost';
 import MyPosts from './pages/MyPosts';
+import Search from './pages/Search';
+import LikedPosts from './pages/LikedPosts';
+import ResetPassword from './pages/ResetPassword';
+import VerifyEmail from './pages/VerifyEmail';
+import OIDCCallback from './pages/OIDCCallback';
 import { AuthProvider } from './contexts/AuthContext';
 
 function App() {
@@ -19,9 +24,14 @@ function App() {
             <Route path="/" element={<Home />} />
             <Route path="/login" element={<Login />} />
             <Route path="/register" element={<Register />} />
+            <Route path="/reset-password" element={<ResetPassword />} />
+            <Route path="/verify-email" element={<VerifyEmail />} />
+            <Route path="/oidc/callback" element={<OIDCCallback />} />
             <Route path="/posts/:id" element={<PostDetail />} />
             <Route path="/create-post" element={<CreatePost />} />
             <Route path="/my-posts" element={<MyPosts />} />
+            <Route path="/search" element={<Search />} />
+            <Route path="/liked" element={<LikedPosts />} />
           </Routes>
         </div>
       </div>
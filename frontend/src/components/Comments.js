@@ -0,0 +1,147 @@
+import React, { useState, useEffect, useCallback } from 'react';
+import { Link } from 'react-router-dom';
+import { getComments, createComment } from '../services/api';
+import { useAuth } from '../contexts/AuthContext';
+
+function CommentForm({ onSubmit, placeholder, autoFocus }) {
+  const [content, setContent] = useState('');
+  const [submitting, setSubmitting] = useState(false);
+  const [error, setError] = useState('');
+
+  const handleSubmit = async (e) => {
+    e.preventDefault();
+    if (!content.trim()) {
+      return;
+    }
+    setSubmitting(true);
+    setError('');
+    try {
+      await onSubmit(content);
+      setContent('');
+    } catch (err) {
+      setError(err.response?.data?.error || 'Không thể gửi bình luận');
+    } finally {
+      setSubmitting(false);
+    }
+  };
+
+  return (
+    <form onSubmit={handleSubmit} className="mt-2">
+      <textarea
+        value={content}
+        onChange={(e) => setContent(e.target.value)}
+        maxLength={5000}
+        rows={3}
+        autoFocus={autoFocus}
+        placeholder={placeholder}
+        className="w-full px-3 py-2 border border-gray-300 rounded-md focus:outline-none focus:ring-2 focus:ring-blue-500"
+      />
+      {error && <p className="text-sm text-red-600 mt-1">{error}</p>}
+      <button
+        type="submit"
+        disabled={submitting || !content.trim()}
+        className="mt-2 px-4 py-2 bg-blue-600 text-white text-sm rounded-md hover:bg-blue-700 disabled:opacity-50"
+      >
+        Gửi
+      </button>
+    </form>
+  );
+}
+
+function Comment({ comment, canReply, onReply }) {
+  const [replying, setReplying] = useState(false);
+
+  const handleReply = async (content) => {
+    await onReply(content, comment.id);
+    setReplying(false);
+  };
+
+  return (
+    <div className={comment.depth > 0 ? 'ml-6 pl-4 border-l border-gray-200' : ''}>
+      <div className="py-3">
+        {comment.deleted_at ? (
+          <p className="text-gray-400 italic">Bình luận đã bị xóa</p>
+        ) : (
+          <>
+            <div className="text-sm text-gray-500">
+              <span className="font-medium text-gray-700">{comment.author_username}</span>
+              {' · '}
+              {new Date(comment.created_at).toLocaleString('vi-VN')}
+            </div>
+            <p className="text-gray-800 whitespace-pre-line mt-1">{comment.content}</p>
+            {canReply && (
+              <button
+                onClick={() => setReplying(!replying)}
+                className="text-sm text-blue-600 hover:text-blue-700 mt-1"
+              >
+                Trả lời
+              </button>
+            )}
+          </>
+        )}
+        {replying && (
+          <CommentForm onSubmit={handleReply} placeholder="Viết câu trả lời..." autoFocus />
+        )}
+      </div>
+      {comment.replies.map((reply) => (
+        <Comment key={reply.id} comment={reply} canReply={canReply} onReply={onReply} />
+      ))}
+    </div>
+  );
+}
+
+function Comments({ postId, locked }) {
+  const [comments, setComments] = useState([]);
+  const [loading, setLoading] = useState(true);
+  const { isLoggedIn } = useAuth();
+
+  const fetchComments = useCallback(async () => {
+    try {
+      const response = await getComments(postId, 'tree', 100);
+      setComments(response.data);
+    } catch (error) {
+      console.error('Error fetching comments:', error);
+    } finally {
+      setLoading(false);
+    }
+  }, [postId]);
+
+  useEffect(() => {
+    fetchComments();
+  }, [fetchComments]);
+
+  const handleCreate = async (content, parentId) => {
+    await createComment(postId, content, parentId);
+    await fetchComments();
+  };
+
+  const canReply = isLoggedIn && !locked;
+
+  return (
+    <div className="mt-8 border-t pt-6">
+      <h2 className="text-xl font-semibold text-gray-900 mb-4">Bình luận</h2>
+      {locked && (
+        <p className="text-sm text-gray-500 mb-4">Bình luận cho bài viết này đã bị khóa.</p>
+      )}
+      {canReply && <CommentForm onSubmit={(content) => handleCreate(content)} placeholder="Viết bình luận..." />}
+      {!isLoggedIn && !locked && (
+        <p className="text-sm text-gray-600 mb-4">
+          <Link to="/login" className="text-blue-600 hover:text-blue-700">Đăng nhập</Link> để bình luận.
+        </p>
+      )}
+      {loading ? (
+        <p className="text-gray-500">Đang tải bình luận...</p>
+      ) : comments.length === 0 ? (
+        <p className="text-gray-500 mt-4">Chưa có bình luận nào.</p>
+      ) : (
+        <div className="mt-4 divide-y divide-gray-100">
+          {comments.map((comment) => (
+            <Comment key={comment.id} comment={comment} canReply={canReply} onReply={handleCreate} />
+          ))}
+        </div>
+      )}
+    </div>
+  );
+}
+
+export default Comments;
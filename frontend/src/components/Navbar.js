@@ -30,6 +30,12 @@ function Navbar() {
               >
                 Trang chủ
               </Link>
+              <Link
+                to="/search"
+                className="text-white hover:bg-blue-500 hover:bg-opacity-75 px-3 py-2 rounded-md text-sm font-medium transition duration-150 ease-in-out"
+              >
+                Tìm kiếm
+              </Link>
               {isLoggedIn && (
                 <>
                   <Link
@@ -44,6 +50,12 @@ function Navbar() {
                   >
                     Bài viết của tôi
                   </Link>
+                  <Link
+                    to="/liked"
+                    className="text-white hover:bg-blue-500 hover:bg-opacity-75 px-3 py-2 rounded-md text-sm font-medium transition duration-150 ease-in-out"
+                  >
+                    Đã thích
+                  </Link>
                 </>
               )}
               {!isLoggedIn ? (
@@ -120,6 +132,12 @@ function Navbar() {
             >
               Trang chủ
             </Link>
+            <Link
+              to="/search"
+              className="text-white hover:bg-blue-500 block px-3 py-2 rounded-md text-base font-medium"
+            >
+              Tìm kiếm
+            </Link>
             {isLoggedIn && (
               <>
                 <Link
@@ -134,6 +152,12 @@ function Navbar() {
                 >
                   Bài viết của tôi
                 </Link>
+                <Link
+                  to="/liked"
+                  className="text-white hover:bg-blue-500 block px-3 py-2 rounded-md text-base font-medium"
+                >
+                  Đã thích
+                </Link>
               </>
             )}
             {!isLoggedIn ? (
@@ -0,0 +1,61 @@
+import React, { useState, useEffect } from 'react';
+import { getPostReactions, setPostReaction } from '../services/api';
+import { useAuth } from '../contexts/AuthContext';
+
+const kinds = [
+  { kind: 'like', emoji: '👍', label: 'Thích' },
+  { kind: 'love', emoji: '❤️', label: 'Yêu thích' },
+  { kind: 'insightful', emoji: '💡', label: 'Hữu ích' },
+  { kind: 'funny', emoji: '😄', label: 'Hài hước' },
+  { kind: 'celebrate', emoji: '🎉', label: 'Chúc mừng' },
+];
+
+function Reactions({ postId, initialCounts }) {
+  const [counts, setCounts] = useState(initialCounts || {});
+  const [mine, setMine] = useState([]);
+  const { isLoggedIn } = useAuth();
+
+  useEffect(() => {
+    if (!isLoggedIn) {
+      return;
+    }
+    getPostReactions(postId)
+      .then((response) => {
+        setCounts(response.data.reactions);
+        setMine(response.data.mine);
+      })
+      .catch((error) => console.error('Error fetching reactions:', error));
+  }, [postId, isLoggedIn]);
+
+  const toggle = async (kind) => {
+    try {
+      const response = await setPostReaction(postId, kind, !mine.includes(kind));
+      setCounts(response.data.reactions);
+      setMine(response.data.mine);
+    } catch (error) {
+      console.error('Error updating reaction:', error);
+    }
+  };
+
+  return (
+    <div className="mt-6 flex flex-wrap gap-2">
+      {kinds.map(({ kind, emoji, label }) => (
+        <button
+          key={kind}
+          title={label}
+          disabled={!isLoggedIn}
+          onClick={() => toggle(kind)}
+          className={`px-3 py-1 rounded-full border text-sm ${
+            mine.includes(kind)
+              ? 'bg-blue-50 border-blue-400 text-blue-700'
+              : 'border-gray-200 text-gray-600 hover:bg-gray-50'
+          } disabled:cursor-default`}
+        >
+          {emoji} {counts[kind] || 0}
+        </button>
+      ))}
+    </div>
+  );
+}
+
+export default Reactions;
@@ -1,4 +1,5 @@
 import React, { createContext, useState, useContext, useEffect } from 'react';
+import { getCsrfToken, logout as logoutApi } from '../services/api';
 
 const AuthContext = createContext(null);
 
@@ -7,16 +8,24 @@ export const AuthProvider = ({ children }) => {
 
   useEffect(() => {
     const token = localStorage.getItem('token');
-    setIsLoggedIn(!!token);
+    setIsLoggedIn(!!token || !!getCsrfToken());
   }, []);
 
-  const login = (token) => {
-    localStorage.setItem('token', token);
+  // With cookie authentication the login response carries no tokens; the
+  // browser keeps them in HttpOnly cookies.
+  const login = (token, refreshToken) => {
+    if (token) {
+      localStorage.setItem('token', token);
+      localStorage.setItem('refreshToken', refreshToken);
+    }
     setIsLoggedIn(true);
   };
 
   const logout = () => {
+    // Revoke the tokens server-side; the local state is cleared regardless.
+    logoutApi(localStorage.getItem('token'), localStorage.getItem('refreshToken')).catch(() => {});
     localStorage.removeItem('token');
+    localStorage.removeItem('refreshToken');
     setIsLoggedIn(false);
   };
 
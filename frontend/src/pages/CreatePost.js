@@ -5,6 +5,10 @@ import { createPost } from '../services/api';
 function CreatePost() {
   const [title, setTitle] = useState('');
   const [content, setContent] = useState('');
+  const [format, setFormat] = useState('markdown');
+  const [tags, setTags] = useState('');
+  const [status, setStatus] = useState('published');
+  const [publishedAt, setPublishedAt] = useState('');
   const [error, setError] = useState('');
   const [isSubmitting, setIsSubmitting] = useState(false);
   const navigate = useNavigate();
@@ -13,8 +17,10 @@ function CreatePost() {
     e.preventDefault();
     setIsSubmitting(true);
     try {
-      await createPost(title, content);
-      navigate('/');
+      const scheduledAt = status === 'scheduled' ? new Date(publishedAt).toISOString() : undefined;
+      const tagList = tags.split(',').map((tag) => tag.trim()).filter(Boolean);
+      await createPost(title, content, status, scheduledAt, format, tagList);
+      navigate(status === 'published' ? '/' : '/my-posts');
     } catch (error) {
       setError('Không thể tạo bài viết. Vui lòng thử lại sau.');
     } finally {
@@ -77,6 +83,78 @@ function CreatePost() {
                 required
               />
             </div>
+            <div>
+              <label
+                htmlFor="tags"
+                className="block text-sm font-medium text-gray-700 mb-2"
+              >
+                Thẻ
+              </label>
+              <input
+                type="text"
+                id="tags"
+                value={tags}
+                onChange={(e) => setTags(e.target.value)}
+                className="w-full px-4 py-2 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-blue-500 focus:border-transparent transition duration-150"
+                placeholder="Ví dụ: go, cơ sở dữ liệu"
+              />
+            </div>
+            <div className="flex flex-wrap gap-4">
+              <div>
+                <label
+                  htmlFor="format"
+                  className="block text-sm font-medium text-gray-700 mb-2"
+                >
+                  Định dạng
+                </label>
+                <select
+                  id="format"
+                  value={format}
+                  onChange={(e) => setFormat(e.target.value)}
+                  className="px-4 py-2 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-blue-500"
+                >
+                  <option value="markdown">Markdown</option>
+                  <option value="plain">Văn bản thuần</option>
+                  <option value="html">HTML</option>
+                </select>
+              </div>
+              <div>
+                <label
+                  htmlFor="status"
+                  className="block text-sm font-medium text-gray-700 mb-2"
+                >
+                  Trạng thái
+                </label>
+                <select
+                  id="status"
+                  value={status}
+                  onChange={(e) => setStatus(e.target.value)}
+                  className="px-4 py-2 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-blue-500"
+                >
+                  <option value="published">Xuất bản ngay</option>
+                  <option value="draft">Lưu bản nháp</option>
+                  <option value="scheduled">Lên lịch</option>
+                </select>
+              </div>
+              {status === 'scheduled' && (
+                <div>
+                  <label
+                    htmlFor="publishedAt"
+                    className="block text-sm font-medium text-gray-700 mb-2"
+                  >
+                    Thời gian xuất bản
+                  </label>
+                  <input
+                    type="datetime-local"
+                    id="publishedAt"
+                    value={publishedAt}
+                    onChange={(e) => setPublishedAt(e.target.value)}
+                    className="px-4 py-2 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-blue-500"
+                    required
+                  />
+                </div>
+              )}
+            </div>
             <div className="flex justify-end">
               <button
                 type="submit"
@@ -1,15 +1,17 @@
 import React, { useState, useEffect } from 'react';
-import { Link } from 'react-router-dom';
+import { Link, useSearchParams } from 'react-router-dom';
 import { getPosts } from '../services/api';
 
 function Home() {
   const [posts, setPosts] = useState([]);
   const [loading, setLoading] = useState(true);
+  const [searchParams] = useSearchParams();
+  const tag = searchParams.get('tag');
 
   useEffect(() => {
     const fetchPosts = async () => {
       try {
-        const response = await getPosts();
+        const response = await getPosts(10, 0, tag ? [tag] : []);
         setPosts(response.data);
       } catch (error) {
         console.error('Error fetching posts:', error);
@@ -19,7 +21,7 @@ function Home() {
     };
 
     fetchPosts();
-  }, []);
+  }, [tag]);
 
   if (loading) {
     return (
@@ -31,7 +33,16 @@ function Home() {
 
   return (
     <div className="max-w-7xl mx-auto px-4 sm:px-6 lg:px-8 py-12">
-      <h1 className="text-4xl font-bold text-gray-900 mb-8 text-center">Bài viết mới nhất</h1>
+      <h1 className="text-4xl font-bold text-gray-900 mb-8 text-center">
+        {tag ? `Bài viết với thẻ #${tag}` : 'Bài viết mới nhất'}
+      </h1>
+      {tag && (
+        <div className="text-center -mt-4 mb-8">
+          <Link to="/" className="text-blue-600 hover:text-blue-700">
+            Xem tất cả bài viết
+          </Link>
+        </div>
+      )}
       <div className="grid grid-cols-1 md:grid-cols-2 lg:grid-cols-3 gap-8">
         {posts.map((post) => (
           <Link
@@ -65,6 +76,7 @@ function Home() {
                       />
                     </svg>
                     {new Date(post.created_at).toLocaleDateString('vi-VN')}
+                    <span className="ml-3">{post.comment_count} bình luận</span>
                   </span>
                   <span className="inline-flex items-center text-blue-600 hover:text-blue-700">
                     Đọc thêm
@@ -0,0 +1,62 @@
+import React, { useState, useEffect } from 'react';
+import { Link } from 'react-router-dom';
+import { getReactedPosts } from '../services/api';
+
+function LikedPosts() {
+  const [posts, setPosts] = useState([]);
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState('');
+
+  useEffect(() => {
+    const fetchPosts = async () => {
+      try {
+        const response = await getReactedPosts('like', 50);
+        setPosts(response.data);
+      } catch (error) {
+        setError('Không thể tải bài viết. Vui lòng thử lại sau.');
+        console.error('Error fetching liked posts:', error);
+      } finally {
+        setLoading(false);
+      }
+    };
+
+    fetchPosts();
+  }, []);
+
+  if (loading) {
+    return (
+      <div className="flex justify-center items-center h-64">
+        <div className="animate-spin rounded-full h-12 w-12 border-t-2 border-b-2 border-blue-500"></div>
+      </div>
+    );
+  }
+
+  return (
+    <div className="max-w-3xl mx-auto px-4 sm:px-6 lg:px-8 py-12">
+      <h1 className="text-3xl font-bold text-gray-900 mb-8">Bài viết đã thích</h1>
+      {error ? (
+        <p className="text-red-600">{error}</p>
+      ) : posts.length === 0 ? (
+        <p className="text-gray-600">Bạn chưa thích bài viết nào.</p>
+      ) : (
+        <div className="space-y-4">
+          {posts.map((post) => (
+            <Link
+              key={post.id}
+              to={`/posts/${post.id}`}
+              className="block bg-white rounded-xl shadow p-6 hover:shadow-lg transition-shadow duration-200"
+            >
+              <h2 className="text-xl font-semibold text-gray-900">{post.title}</h2>
+              <div className="text-sm text-gray-500 mt-2">
+                {post.author_username} · Đã thích ngày{' '}
+                {new Date(post.reacted_at).toLocaleDateString('vi-VN')}
+              </div>
+            </Link>
+          ))}
+        </div>
+      )}
+    </div>
+  );
+}
+
+export default LikedPosts;
@@ -1,22 +1,41 @@
-import React, { useState } from 'react';
-import { Link, useNavigate } from 'react-router-dom';
-import { login as loginApi } from '../services/api';
+import React, { useState, useEffect } from 'react';
+import { Link, useLocation, useNavigate } from 'react-router-dom';
+import { login as loginApi, verifyLoginMfa, getOIDCProviders, oidcLoginUrl } from '../services/api';
 import { useAuth } from '../contexts/AuthContext';
 
 function Login() {
   const [username, setUsername] = useState('');
   const [password, setPassword] = useState('');
+  const location = useLocation();
+  // A login with an external provider may come back here for the second factor.
+  const [mfaToken, setMfaToken] = useState(location.state?.mfaToken || '');
+  const [providers, setProviders] = useState([]);
+  const [code, setCode] = useState('');
   const [error, setError] = useState('');
   const [isSubmitting, setIsSubmitting] = useState(false);
   const navigate = useNavigate();
   const { login } = useAuth();
 
+  useEffect(() => {
+    getOIDCProviders()
+      .then((response) => setProviders(response.data))
+      .catch(() => setProviders([]));
+  }, []);
+
   const handleSubmit = async (e) => {
     e.preventDefault();
     setIsSubmitting(true);
     try {
-      const response = await loginApi(username, password);
-      login(response.data.access_token);
+      const response = mfaToken
+        ? await verifyLoginMfa(mfaToken, code)
+        : await loginApi(username, password);
+      if (response.data.mfa_required) {
+        // Mật khẩu đúng, cần thêm mã xác thực hai lớp
+        setMfaToken(response.data.mfa_token);
+        setError('');
+        return;
+      }
+      login(response.data.access_token, response.data.refresh_token);
       navigate('/');
     } catch (error) {
       setError(error.response?.data?.error || 'Đăng nhập thất bại. Vui lòng thử lại.');
@@ -47,32 +66,51 @@ function Login() {
             </div>
           )}
           <form onSubmit={handleSubmit} className="space-y-6">
-            <div>
-              <label htmlFor="username" className="block text-sm font-medium text-gray-700 mb-2">
-                Tên đăng nhập
-              </label>
-              <input
-                id="username"
-                type="text"
-                value={username}
-                onChange={(e) => setUsername(e.target.value)}
-                className="w-full px-4 py-2 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-blue-500 focus:border-transparent"
-                required
-              />
-            </div>
-            <div>
-              <label htmlFor="password" className="block text-sm font-medium text-gray-700 mb-2">
-                Mật khẩu
-              </label>
-              <input
-                id="password"
-                type="password"
-                value={password}
-                onChange={(e) => setPassword(e.target.value)}
-                className="w-full px-4 py-2 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-blue-500 focus:border-transparent"
-                required
-              />
-            </div>
+            {mfaToken ? (
+              <div>
+                <label htmlFor="code" className="block text-sm font-medium text-gray-700 mb-2">
+                  Mã xác thực (ứng dụng Authenticator hoặc mã khôi phục)
+                </label>
+                <input
+                  id="code"
+                  type="text"
+                  autoComplete="one-time-code"
+                  value={code}
+                  onChange={(e) => setCode(e.target.value)}
+                  className="w-full px-4 py-2 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-blue-500 focus:border-transparent"
+                  required
+                />
+              </div>
+            ) : (
+              <>
+                <div>
+                  <label htmlFor="username" className="block text-sm font-medium text-gray-700 mb-2">
+                    Tên đăng nhập
+                  </label>
+                  <input
+                    id="username"
+                    type="text"
+                    value={username}
+                    onChange={(e) => setUsername(e.target.value)}
+                    className="w-full px-4 py-2 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-blue-500 focus:border-transparent"
+                    required
+                  />
+                </div>
+                <div>
+                  <label htmlFor="password" className="block text-sm font-medium text-gray-700 mb-2">
+                    Mật khẩu
+                  </label>
+                  <input
+                    id="password"
+                    type="password"
+                    value={password}
+                    onChange={(e) => setPassword(e.target.value)}
+                    className="w-full px-4 py-2 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-blue-500 focus:border-transparent"
+                    required
+                  />
+                </div>
+              </>
+            )}
             <button
               type="submit"
               disabled={isSubmitting}
@@ -83,7 +121,25 @@ function Login() {
               {isSubmitting ? 'Đang đăng nhập...' : 'Đăng nhập'}
             </button>
           </form>
+          {!mfaToken && providers.length > 0 && (
+            <div className="mt-6 space-y-2">
+              {providers.map((provider) => (
+                <a
+                  key={provider}
+                  href={oidcLoginUrl(provider)}
+                  className="block w-full py-2 px-4 border border-gray-300 rounded-md text-center text-sm font-medium text-gray-700 hover:bg-gray-50"
+                >
+                  Đăng nhập với {provider}
+                </a>
+              ))}
+            </div>
+          )}
           <p className="mt-4 text-center text-sm text-gray-600">
+            <Link to="/reset-password" className="text-blue-600 hover:text-blue-700 font-medium">
+              Quên mật khẩu?
+            </Link>
+          </p>
+          <p className="mt-2 text-center text-sm text-gray-600">
             Chưa có tài khoản?{' '}
             <Link to="/register" className="text-blue-600 hover:text-blue-700 font-medium">
               Đăng ký
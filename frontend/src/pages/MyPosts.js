@@ -2,6 +2,13 @@ import React, { useState, useEffect } from 'react';
 import { Link } from 'react-router-dom';
 import { getMyPosts } from '../services/api';
 
+const statusLabels = {
+  draft: 'Bản nháp',
+  published: 'Đã xuất bản',
+  scheduled: 'Đã lên lịch',
+  archived: 'Đã lưu trữ',
+};
+
 function MyPosts() {
   const [posts, setPosts] = useState([]);
   const [loading, setLoading] = useState(true);
@@ -86,6 +93,11 @@ function MyPosts() {
                     <h2 className="text-xl font-semibold text-white">
                       {post.title}
                     </h2>
+                    <span className="text-sm text-blue-100">
+                      {statusLabels[post.status]}
+                      {post.status === 'scheduled' &&
+                        ` · ${new Date(post.published_at).toLocaleString('vi-VN')}`}
+                    </span>
                   </div>
                   <p className="text-gray-600 mb-4 line-clamp-3">
                     {post.content}
@@ -0,0 +1,47 @@
+import React, { useEffect, useState } from 'react';
+import { Link, useNavigate } from 'react-router-dom';
+import { useAuth } from '../contexts/AuthContext';
+
+// OIDCCallback finishes a login with an external provider. The server
+// redirects here with the outcome in the URL fragment: tokens, an MFA token
+// when a second factor is needed, or an error. With cookie authentication a
+// successful login carries nothing, the cookies are already set.
+function OIDCCallback() {
+  const [error, setError] = useState('');
+  const navigate = useNavigate();
+  const { login } = useAuth();
+
+  useEffect(() => {
+    const params = new URLSearchParams(window.location.hash.slice(1));
+    // Keep the tokens out of the browser history.
+    window.history.replaceState(null, '', window.location.pathname);
+
+    if (params.get('error')) {
+      setError(params.get('error'));
+      return;
+    }
+    if (params.get('mfa_token')) {
+      navigate('/login', { replace: true, state: { mfaToken: params.get('mfa_token') } });
+      return;
+    }
+    login(params.get('access_token'), params.get('refresh_token'));
+    navigate('/', { replace: true });
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, []);
+
+  if (!error) {
+    return <div className="text-center text-gray-600 py-8">Đang đăng nhập...</div>;
+  }
+  return (
+    <div className="max-w-md mx-auto px-4 py-8">
+      <div className="bg-red-50 border-l-4 border-red-400 p-4 rounded-md mb-4">
+        <p className="text-sm text-red-700">{error}</p>
+      </div>
+      <Link to="/login" className="text-blue-600 hover:text-blue-700 font-medium">
+        Quay lại đăng nhập
+      </Link>
+    </div>
+  );
+}
+
+export default OIDCCallback;
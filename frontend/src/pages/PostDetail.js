@@ -1,12 +1,22 @@
 import React, { useState, useEffect } from 'react';
 import { useParams, Link } from 'react-router-dom';
-import { getPost } from '../services/api';
+import { getPost, getMyPost } from '../services/api';
+import { useAuth } from '../contexts/AuthContext';
+import Comments from '../components/Comments';
+import Reactions from '../components/Reactions';
+
+const statusLabels = {
+  draft: 'Bản nháp',
+  scheduled: 'Đã lên lịch',
+  archived: 'Đã lưu trữ',
+};
 
 function PostDetail() {
   const { id } = useParams();
   const [post, setPost] = useState(null);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState('');
+  const { isLoggedIn } = useAuth();
 
   useEffect(() => {
     const fetchPost = async () => {
@@ -14,6 +24,16 @@ function PostDetail() {
         const response = await getPost(id);
         setPost(response.data);
       } catch (error) {
+        // Drafts and scheduled posts are only visible to their author.
+        if (isLoggedIn && error.response?.status === 404) {
+          try {
+            const response = await getMyPost(id);
+            setPost(response.data);
+            return;
+          } catch (ownError) {
+            console.error('Error fetching own post:', ownError);
+          }
+        }
         setError('Không thể tải bài viết');
       } finally {
         setLoading(false);
@@ -21,7 +41,7 @@ function PostDetail() {
     };
 
     fetchPost();
-  }, [id]);
+  }, [id, isLoggedIn]);
 
   if (loading) {
     return (
@@ -83,15 +103,47 @@ function PostDetail() {
                 d="M8 7V3m8 4V3m-9 8h10M5 21h14a2 2 0 002-2V7a2 2 0 00-2-2H5a2 2 0 00-2 2v12a2 2 0 002 2z"
               />
             </svg>
-            <span>{new Date(post.created_at).toLocaleDateString('vi-VN')}</span>
+            <span>{new Date(post.published_at || post.created_at).toLocaleDateString('vi-VN')}</span>
+            {post.status !== 'published' && (
+              <span className="ml-4 px-2 py-0.5 rounded bg-white text-blue-700 text-sm">
+                {statusLabels[post.status]}
+              </span>
+            )}
           </div>
         </div>
         <div className="px-8 py-6">
-          <div className="prose max-w-none">
-            <p className="text-gray-700 leading-relaxed whitespace-pre-line">
-              {post.content}
-            </p>
-          </div>
+          {post.content_html ? (
+            // content_html is sanitized by the server.
+            <div
+              className="prose max-w-none text-gray-700"
+              dangerouslySetInnerHTML={{ __html: post.content_html }}
+            />
+          ) : (
+            <div className="prose max-w-none">
+              <p className="text-gray-700 leading-relaxed whitespace-pre-line">
+                {post.content}
+              </p>
+            </div>
+          )}
+          {post.tags?.length > 0 && (
+            <div className="mt-6 flex flex-wrap gap-2">
+              {post.tags.map((tag) => (
+                <Link
+                  key={tag}
+                  to={`/?tag=${encodeURIComponent(tag)}`}
+                  className="px-3 py-1 rounded-full bg-blue-50 text-blue-700 text-sm hover:bg-blue-100"
+                >
+                  #{tag}
+                </Link>
+              ))}
+            </div>
+          )}
+          {post.status === 'published' && (
+            <>
+              <Reactions postId={post.id} initialCounts={post.reactions} />
+              <Comments postId={post.id} locked={post.comments_locked} />
+            </>
+          )}
           <div className="mt-8 border-t pt-4">
             <Link
               to="/"
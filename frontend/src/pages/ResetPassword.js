@@ -0,0 +1,108 @@
+import React, { useState } from 'react';
+import { Link, useSearchParams } from 'react-router-dom';
+import { forgotPassword, resetPassword } from '../services/api';
+
+// ResetPassword asks for the account email, or, when opened from the link in
+// a reset email, for the new password.
+function ResetPassword() {
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get('token');
+  const [email, setEmail] = useState('');
+  const [password, setPassword] = useState('');
+  const [error, setError] = useState('');
+  const [message, setMessage] = useState('');
+  const [isSubmitting, setIsSubmitting] = useState(false);
+
+  const handleSubmit = async (e) => {
+    e.preventDefault();
+    setIsSubmitting(true);
+    setError('');
+    try {
+      if (token) {
+        await resetPassword(token, password);
+        setMessage('Đã đổi mật khẩu. Bạn có thể đăng nhập bằng mật khẩu mới.');
+      } else {
+        const response = await forgotPassword(email);
+        setMessage(response.data.message);
+      }
+    } catch (error) {
+      setError(error.response?.data?.error || 'Có lỗi xảy ra. Vui lòng thử lại.');
+    } finally {
+      setIsSubmitting(false);
+    }
+  };
+
+  return (
+    <div className="max-w-md mx-auto px-4 py-8">
+      <div className="bg-white rounded-xl shadow-lg overflow-hidden">
+        <div className="bg-gradient-to-r from-blue-600 to-indigo-700 py-6 px-8">
+          <h2 className="text-2xl font-bold text-white text-center">
+            {token ? 'Đặt mật khẩu mới' : 'Quên mật khẩu'}
+          </h2>
+        </div>
+        <div className="p-8">
+          {error && (
+            <div className="bg-red-50 border-l-4 border-red-400 p-4 rounded-md mb-6">
+              <p className="text-sm text-red-700">{error}</p>
+            </div>
+          )}
+          {message ? (
+            <div className="bg-green-50 border-l-4 border-green-400 p-4 rounded-md">
+              <p className="text-sm text-green-700">{message}</p>
+            </div>
+          ) : (
+            <form onSubmit={handleSubmit} className="space-y-6">
+              {token ? (
+                <div>
+                  <label htmlFor="password" className="block text-sm font-medium text-gray-700 mb-2">
+                    Mật khẩu mới
+                  </label>
+                  <input
+                    id="password"
+                    type="password"
+                    autoComplete="new-password"
+                    value={password}
+                    onChange={(e) => setPassword(e.target.value)}
+                    className="w-full px-4 py-2 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-blue-500 focus:border-transparent"
+                    required
+                    minLength={6}
+                  />
+                </div>
+              ) : (
+                <div>
+                  <label htmlFor="email" className="block text-sm font-medium text-gray-700 mb-2">
+                    Email
+                  </label>
+                  <input
+                    id="email"
+                    type="email"
+                    value={email}
+                    onChange={(e) => setEmail(e.target.value)}
+                    className="w-full px-4 py-2 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-blue-500 focus:border-transparent"
+                    required
+                  />
+                </div>
+              )}
+              <button
+                type="submit"
+                disabled={isSubmitting}
+                className={`w-full py-3 px-4 border border-transparent rounded-md shadow-sm text-sm font-medium text-white bg-blue-600 hover:bg-blue-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-blue-500 ${
+                  isSubmitting ? 'opacity-75 cursor-not-allowed' : ''
+                }`}
+              >
+                {isSubmitting ? 'Đang gửi...' : token ? 'Đổi mật khẩu' : 'Gửi liên kết đặt lại mật khẩu'}
+              </button>
+            </form>
+          )}
+          <p className="mt-4 text-center text-sm text-gray-600">
+            <Link to="/login" className="text-blue-600 hover:text-blue-700 font-medium">
+              Quay lại đăng nhập
+            </Link>
+          </p>
+        </div>
+      </div>
+    </div>
+  );
+}
+
+export default ResetPassword;
@@ -0,0 +1,119 @@
+import React, { useState, useEffect } from 'react';
+import { Link, useSearchParams } from 'react-router-dom';
+import { searchPosts } from '../services/api';
+
+const PAGE_SIZE = 10;
+
+function Search() {
+  const [searchParams, setSearchParams] = useSearchParams();
+  const q = searchParams.get('q') || '';
+  const page = Number(searchParams.get('page')) || 0;
+  const [input, setInput] = useState(q);
+  const [results, setResults] = useState([]);
+  const [loading, setLoading] = useState(false);
+
+  useEffect(() => {
+    setInput(q);
+    if (!q.trim()) {
+      setResults([]);
+      return;
+    }
+    const fetchResults = async () => {
+      setLoading(true);
+      try {
+        const response = await searchPosts(q, PAGE_SIZE, page * PAGE_SIZE);
+        setResults(response.data);
+      } catch (error) {
+        console.error('Error searching posts:', error);
+      } finally {
+        setLoading(false);
+      }
+    };
+
+    fetchResults();
+  }, [q, page]);
+
+  const handleSubmit = (e) => {
+    e.preventDefault();
+    setSearchParams(input.trim() ? { q: input.trim() } : {});
+  };
+
+  const goToPage = (p) => {
+    setSearchParams({ q, page: p });
+  };
+
+  return (
+    <div className="max-w-3xl mx-auto px-4 sm:px-6 lg:px-8 py-12">
+      <form onSubmit={handleSubmit} className="flex gap-2 mb-8">
+        <input
+          type="search"
+          value={input}
+          onChange={(e) => setInput(e.target.value)}
+          maxLength={200}
+          placeholder="Tìm bài viết..."
+          className="flex-1 px-4 py-2 border border-gray-300 rounded-md focus:outline-none focus:ring-2 focus:ring-blue-500"
+        />
+        <button
+          type="submit"
+          className="px-4 py-2 bg-blue-600 text-white rounded-md hover:bg-blue-700"
+        >
+          Tìm kiếm
+        </button>
+      </form>
+
+      {loading ? (
+        <div className="flex justify-center items-center h-32">
+          <div className="animate-spin rounded-full h-12 w-12 border-t-2 border-b-2 border-blue-500"></div>
+        </div>
+      ) : (
+        <>
+          {q && results.length === 0 && (
+            <p className="text-center text-gray-600">Không tìm thấy bài viết nào.</p>
+          )}
+          <div className="space-y-6">
+            {results.map((result) => (
+              <Link
+                key={result.id}
+                to={`/posts/${result.id}`}
+                className="block bg-white rounded-xl shadow p-6 hover:shadow-lg transition-shadow duration-200"
+              >
+                <h2
+                  className="text-xl font-semibold text-gray-900 mb-2"
+                  dangerouslySetInnerHTML={{ __html: result.title_highlight }}
+                />
+                <p
+                  className="text-gray-600 mb-2"
+                  dangerouslySetInnerHTML={{ __html: result.snippet }}
+                />
+                <div className="text-sm text-gray-500">
+                  {result.author_username} ·{' '}
+                  {new Date(result.published_at).toLocaleDateString('vi-VN')}
+                </div>
+              </Link>
+            ))}
+          </div>
+          {q && (page > 0 || results.length === PAGE_SIZE) && (
+            <div className="flex justify-between mt-8">
+              <button
+                onClick={() => goToPage(page - 1)}
+                disabled={page === 0}
+                className="px-4 py-2 text-blue-600 disabled:text-gray-400"
+              >
+                Trang trước
+              </button>
+              <button
+                onClick={() => goToPage(page + 1)}
+                disabled={results.length < PAGE_SIZE}
+                className="px-4 py-2 text-blue-600 disabled:text-gray-400"
+              >
+                Trang sau
+              </button>
+            </div>
+          )}
+        </>
+      )}
+    </div>
+  );
+}
+
+export default Search;
@@ -0,0 +1,69 @@
+import React, { useState } from 'react';
+import { Link, useSearchParams } from 'react-router-dom';
+import { verifyEmail } from '../services/api';
+
+// VerifyEmail is opened from the link in a verification email. The token is
+// only redeemed when the user confirms, so mail scanners that open the link
+// do not use it up.
+function VerifyEmail() {
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get('token');
+  const [error, setError] = useState(token ? '' : 'Liên kết xác nhận không hợp lệ.');
+  const [message, setMessage] = useState('');
+  const [isSubmitting, setIsSubmitting] = useState(false);
+
+  const handleSubmit = async (e) => {
+    e.preventDefault();
+    setIsSubmitting(true);
+    setError('');
+    try {
+      const response = await verifyEmail(token);
+      setMessage(`Đã xác nhận địa chỉ email ${response.data.email}.`);
+    } catch (error) {
+      setError(error.response?.data?.error || 'Có lỗi xảy ra. Vui lòng thử lại.');
+    } finally {
+      setIsSubmitting(false);
+    }
+  };
+
+  return (
+    <div className="max-w-md mx-auto px-4 py-8">
+      <div className="bg-white rounded-xl shadow-lg overflow-hidden">
+        <div className="bg-gradient-to-r from-blue-600 to-indigo-700 py-6 px-8">
+          <h2 className="text-2xl font-bold text-white text-center">Xác nhận email</h2>
+        </div>
+        <div className="p-8">
+          {error && (
+            <div className="bg-red-50 border-l-4 border-red-400 p-4 rounded-md mb-6">
+              <p className="text-sm text-red-700">{error}</p>
+            </div>
+          )}
+          {message ? (
+            <div className="bg-green-50 border-l-4 border-green-400 p-4 rounded-md">
+              <p className="text-sm text-green-700">{message}</p>
+            </div>
+          ) : token && (
+            <form onSubmit={handleSubmit}>
+              <button
+                type="submit"
+                disabled={isSubmitting}
+                className={`w-full py-3 px-4 border border-transparent rounded-md shadow-sm text-sm font-medium text-white bg-blue-600 hover:bg-blue-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-blue-500 ${
+                  isSubmitting ? 'opacity-75 cursor-not-allowed' : ''
+                }`}
+              >
+                {isSubmitting ? 'Đang xác nhận...' : 'Xác nhận địa chỉ email'}
+              </button>
+            </form>
+          )}
+          <p className="mt-4 text-center text-sm text-gray-600">
+            <Link to="/" className="text-blue-600 hover:text-blue-700 font-medium">
+              Về trang chủ
+            </Link>
+          </p>
+        </div>
+      </div>
+    </div>
+  );
+}
+
+export default VerifyEmail;
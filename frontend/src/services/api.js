@@ -11,10 +11,20 @@ const getBaseUrl = () => {
 
 const API_URL = getBaseUrl();
 
+// withCredentials sends the auth cookies when the server runs with cookie
+// authentication (COOKIE_AUTH=true).
 const api = axios.create({
   baseURL: API_URL,
+  withCredentials: true,
 });
 
+// getCsrfToken returns the double-submit token the server sets next to its
+// auth cookies, or null when tokens are kept in localStorage instead.
+export const getCsrfToken = () => {
+  const match = document.cookie.match(/(?:^|;\s*)plog_csrf_token=([^;]*)/);
+  return match ? decodeURIComponent(match[1]) : null;
+};
+
 // Add a request interceptor to add the auth token to requests
 api.interceptors.request.use(
   (config) => {
@@ -22,6 +32,10 @@ api.interceptors.request.use(
     if (token) {
       config.headers.Authorization = `Bearer ${token}`;
     }
+    const csrfToken = getCsrfToken();
+    if (csrfToken) {
+      config.headers['X-CSRF-Token'] = csrfToken;
+    }
     return config;
   },
   (error) => {
@@ -29,6 +43,49 @@ api.interceptors.request.use(
   }
 );
 
+// When a request fails with 401, exchange the refresh token for a new access
+// token and retry the request once. Concurrent failures share a single renewal,
+// because replaying an already rotated refresh token revokes the session.
+let renewRequest = null;
+
+api.interceptors.response.use(
+  (response) => response,
+  async (error) => {
+    const originalRequest = error.config;
+    const refreshToken = localStorage.getItem('refreshToken');
+    if (
+      error.response?.status !== 401 ||
+      (!refreshToken && !getCsrfToken()) ||
+      originalRequest._retry ||
+      originalRequest.url === '/tokens/renew'
+    ) {
+      return Promise.reject(error);
+    }
+    originalRequest._retry = true;
+
+    try {
+      if (!renewRequest) {
+        renewRequest = api
+          .post('/tokens/renew', refreshToken ? { refresh_token: refreshToken } : {})
+          .finally(() => {
+            renewRequest = null;
+          });
+      }
+      const { data } = await renewRequest;
+      // With cookie authentication the new tokens arrive as cookies.
+      if (data.access_token) {
+        localStorage.setItem('token', data.access_token);
+        localStorage.setItem('refreshToken', data.refresh_token);
+      }
+      return api(originalRequest);
+    } catch (renewError) {
+      localStorage.removeItem('token');
+      localStorage.removeItem('refreshToken');
+      return Promise.reject(error);
+    }
+  }
+);
+
 export const register = (username, password) => {
   return api.post('/register', { username, password });
 };
@@ -37,24 +94,130 @@ export const login = (username, password) => {
   return api.post('/login', { username, password });
 };
 
-export const getPosts = (limit = 10, offset = 0) => {
-  return api.get(`/posts?limit=${limit}&offset=${offset}`);
+export const verifyLoginMfa = (mfaToken, code) => {
+  return api.post('/login/mfa', { mfa_token: mfaToken, code });
+};
+
+export const getOIDCProviders = () => {
+  return api.get('/oidc/providers');
+};
+
+// oidcLoginUrl is a page to navigate to, not an API call: the server
+// redirects to the provider and back to /oidc/callback.
+export const oidcLoginUrl = (provider) => {
+  return `${API_URL}/oidc/${encodeURIComponent(provider)}/login`;
+};
+
+export const forgotPassword = (email) => {
+  return api.post('/password/forgot', { email });
+};
+
+export const resetPassword = (token, newPassword) => {
+  return api.post('/password/reset', { token, new_password: newPassword });
+};
+
+export const verifyEmail = (token) => {
+  return api.post('/verify-email', { token });
+};
+
+export const logout = (token, refreshToken) => {
+  if (!token) {
+    // Cookie authentication: the cookies identify the session.
+    return api.post('/logout');
+  }
+  return api.post(
+    '/logout',
+    { refresh_token: refreshToken },
+    { headers: { Authorization: `Bearer ${token}` } }
+  );
+};
+
+// tags only lists posts with any of them, or with tagMatch 'all' all of them.
+export const getPosts = (limit = 10, offset = 0, tags = [], tagMatch = 'any') => {
+  return api.get('/posts', {
+    params: { limit, offset, tags: tags.length ? tags.join(',') : undefined, tag_match: tagMatch },
+  });
+};
+
+export const getTags = () => {
+  return api.get('/tags');
+};
+
+export const searchPosts = (q, limit = 10, offset = 0) => {
+  return api.get('/search', { params: { q, limit, offset } });
 };
 
 export const getPost = (id) => {
   return api.get(`/posts/${id}`);
 };
 
-export const getMyPosts = () => {
-  return api.get('/my-posts');
+export const getMyPosts = (status) => {
+  return api.get('/my-posts', { params: status ? { status } : {} });
+};
+
+export const getMyPost = (id) => {
+  return api.get(`/my-posts/${id}`);
+};
+
+export const getPostRevisions = (id) => {
+  return api.get(`/posts/${id}/revisions`);
+};
+
+// mode is line or word; to defaults to the current revision.
+export const diffPostRevisions = (id, from, to, mode) => {
+  return api.get(`/posts/${id}/revisions/diff`, { params: { from, to, mode } });
+};
+
+export const restorePostRevision = (id, rev) => {
+  return api.post(`/posts/${id}/revisions/${rev}/restore`);
+};
+
+// view is tree, with nested replies, or flat.
+export const getComments = (postId, view = 'tree', limit = 20, offset = 0) => {
+  return api.get(`/posts/${postId}/comments`, { params: { view, limit, offset } });
+};
+
+// parentId is the comment being replied to, if any.
+export const createComment = (postId, content, parentId) => {
+  return api.post(`/posts/${postId}/comments`, { content, parent_id: parentId });
+};
+
+export const updateComment = (postId, commentId, content) => {
+  return api.put(`/posts/${postId}/comments/${commentId}`, { content });
+};
+
+export const deleteComment = (postId, commentId) => {
+  return api.delete(`/posts/${postId}/comments/${commentId}`);
+};
+
+export const setCommentsLocked = (postId, locked) => {
+  return locked
+    ? api.put(`/posts/${postId}/comments/lock`)
+    : api.delete(`/posts/${postId}/comments/lock`);
+};
+
+export const getPostReactions = (postId) => {
+  return api.get(`/posts/${postId}/reactions`);
+};
+
+// kind is like, love, insightful, funny or celebrate.
+export const setPostReaction = (postId, kind, on) => {
+  return on
+    ? api.put(`/posts/${postId}/reactions/${kind}`)
+    : api.delete(`/posts/${postId}/reactions/${kind}`);
+};
+
+export const getReactedPosts = (kind = 'like', limit = 10, offset = 0) => {
+  return api.get('/my-reactions', { params: { kind, limit, offset } });
 };
 
-export const createPost = (title, content) => {
-  return api.post('/posts', { title, content });
+// status is draft, published or scheduled; scheduled posts need publishedAt.
+export const createPost = (title, content, status, publishedAt, format, tags) => {
+  return api.post('/posts', { title, content, status, published_at: publishedAt, format, tags });
 };
 
-export const updatePost = (id, title, content) => {
-  return api.put(`/posts/${id}`, { title, content });
+export const updatePost = (id, title, content, status, publishedAt, format, tags) => {
+  return api.put(`/posts/${id}`, { title, content, status, published_at: publishedAt, format, tags });
 };
 
 export default api; 
\ No newline at end of file
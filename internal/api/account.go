@@ -0,0 +1,259 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/lshigami/Plog/internal/audit"
+	"github.com/lshigami/Plog/internal/config"
+	"github.com/lshigami/Plog/internal/db/sqlc"
+)
+
+// accountDeletionInterval is how often each instance looks for accounts whose
+// deletion grace period has ended.
+const accountDeletionInterval = 10 * time.Minute
+
+type AccountExport struct {
+	ExportedAt           time.Time                     `json:"exported_at"`
+	Profile              UserResponse                  `json:"profile"`
+	Posts                []sqlc.Post                   `json:"posts" swaggertype:"array,object"`
+	Comments             []sqlc.Comment                `json:"comments" swaggertype:"array,object"`
+	Reactions            []sqlc.PostReaction           `json:"reactions" swaggertype:"array,object"`
+	Sessions             []SessionResponse             `json:"sessions"`
+	PersonalAccessTokens []PersonalAccessTokenResponse `json:"personal_access_tokens"`
+}
+
+type DeleteAccountRequest struct {
+	// Password is required unless the account was created through OIDC
+	// and has no password.
+	Password string `json:"password"`
+	// Code is a TOTP or recovery code, required when two-factor
+	// authentication is on.
+	Code string `json:"code"`
+}
+
+type DeleteAccountResponse struct {
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
+
+// ExportAccount godoc
+// @Summary Export personal data
+// @Description Download everything Plog stores about the current user as a JSON document: the profile, all posts, comments (deleted ones without their text) and reactions, login sessions and personal access tokens (without secrets).
+// @Tags account
+// @Produce json
+// @Success 200 {object} AccountExport "Export"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Called with a personal access token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /me/export [get]
+func (server *Server) ExportAccount(c *gin.Context) {
+	user, ok := server.currentUser(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	posts, err := server.store.ListUserPosts(ctx, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list posts: " + err.Error()})
+		return
+	}
+	comments, err := server.store.ListUserComments(ctx, pgtype.Int4{Int32: user.ID, Valid: true})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list comments: " + err.Error()})
+		return
+	}
+	reactions, err := server.store.ListUserReactions(ctx, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list reactions: " + err.Error()})
+		return
+	}
+	sessions, err := server.store.ListUserSessions(ctx, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list sessions: " + err.Error()})
+		return
+	}
+	pats, err := server.store.ListPersonalAccessTokens(ctx, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list tokens: " + err.Error()})
+		return
+	}
+
+	export := AccountExport{
+		ExportedAt:           time.Now().UTC(),
+		Profile:              newUserResponse(user),
+		Posts:                posts,
+		Comments:             comments,
+		Reactions:            reactions,
+		Sessions:             make([]SessionResponse, 0, len(sessions)),
+		PersonalAccessTokens: make([]PersonalAccessTokenResponse, 0, len(pats)),
+	}
+	for _, session := range sessions {
+		export.Sessions = append(export.Sessions, newSessionResponse(session, uuid.Nil))
+	}
+	for _, pat := range pats {
+		export.PersonalAccessTokens = append(export.PersonalAccessTokens, newPersonalAccessTokenResponse(pat))
+	}
+	server.audit(c, audit.Event{Type: audit.EventAccountExport, Outcome: audit.OutcomeSuccess, UserID: user.ID, Username: user.Username})
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="plog-export-%s.json"`, user.Username))
+	c.JSON(http.StatusOK, export)
+}
+
+// DeleteAccount godoc
+// @Summary Delete the account
+// @Description Schedule the current user's account for deletion. The password, and with two-factor authentication a code, must be confirmed. Accounts created through OIDC without a password confirm with their code, or without two-factor authentication by having signed in within the last 10 minutes. Until the grace period ends the deletion can be cancelled; after that the account is deleted together with its posts, or anonymized with its posts kept, depending on the server configuration.
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param request body DeleteAccountRequest true "Password and, with two-factor authentication, a code"
+// @Success 202 {object} DeleteAccountResponse "Deletion scheduled"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Wrong password or code, sign-in too long ago, or called with a personal access token"
+// @Failure 409 {object} map[string]string "Deletion already scheduled"
+// @Failure 423 {object} map[string]string "Account temporarily locked after too many failed passwords, see Retry-After"
+// @Failure 429 {object} map[string]string "Too many failed passwords from this IP, see Retry-After"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /me [delete]
+func (server *Server) DeleteAccount(c *gin.Context) {
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	user, ok := server.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.DeletionScheduledAt.Valid {
+		c.JSON(http.StatusConflict, gin.H{"error": "Account deletion is already scheduled"})
+		return
+	}
+
+	if !server.confirmIdentity(c, user, req.Password, req.Code, audit.EventDeletionSchedule) {
+		return
+	}
+
+	user, err := server.store.ScheduleUserDeletion(c.Request.Context(), sqlc.ScheduleUserDeletionParams{
+		ID:                  user.ID,
+		DeletionScheduledAt: pgtype.Timestamptz{Time: time.Now().Add(server.config.AccountDeletionGracePeriod), Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule deletion: " + err.Error()})
+		return
+	}
+	server.audit(c, audit.Event{Type: audit.EventDeletionSchedule, Outcome: audit.OutcomeSuccess, UserID: user.ID, Username: user.Username,
+		Details: server.config.AccountDeletionMode})
+
+	c.JSON(http.StatusAccepted, DeleteAccountResponse{DeletionScheduledAt: user.DeletionScheduledAt.Time})
+}
+
+// CancelAccountDeletion godoc
+// @Summary Cancel account deletion
+// @Description Keep the current user's account after a deletion was requested, as long as the grace period has not ended.
+// @Tags account
+// @Produce json
+// @Success 204 "Deletion cancelled"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Called with a personal access token"
+// @Failure 404 {object} map[string]string "No deletion scheduled"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /me/deletion [delete]
+func (server *Server) CancelAccountDeletion(c *gin.Context) {
+	user, ok := server.currentUser(c)
+	if !ok {
+		return
+	}
+	cancelled, err := server.store.CancelUserDeletion(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel deletion: " + err.Error()})
+		return
+	}
+	if cancelled == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No account deletion is scheduled"})
+		return
+	}
+	server.audit(c, audit.Event{Type: audit.EventDeletionCancel, Outcome: audit.OutcomeSuccess, UserID: user.ID, Username: user.Username})
+
+	c.Status(http.StatusNoContent)
+}
+
+func (server *Server) runAccountDeletion(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		server.deleteDueAccounts(context.Background())
+		<-ticker.C
+	}
+}
+
+// deleteDueAccounts deletes or anonymizes the accounts whose grace period has
+// ended. Every instance runs it; each account is claimed by a single
+// statement, so none is processed twice.
+func (server *Server) deleteDueAccounts(ctx context.Context) {
+	type account struct {
+		id       int32
+		username string
+	}
+	var accounts []account
+	if server.config.AccountDeletionMode == config.AccountDeletionAnonymize {
+		rows, err := server.store.AnonymizeDueUsers(ctx)
+		if err != nil {
+			log.Printf("Warning: could not anonymize deleted accounts: %v", err)
+			return
+		}
+		for _, row := range rows {
+			accounts = append(accounts, account{id: row.ID, username: row.Username})
+		}
+	} else {
+		// The sessions are revoked first and the account deleted on a later
+		// run, once their access tokens have expired: the deletion removes
+		// the sessions, and with them the revocation other instances sync.
+		revoked, err := server.store.RevokeDueUserSessions(ctx)
+		if err != nil {
+			log.Printf("Warning: could not revoke sessions of deleted accounts: %v", err)
+			return
+		}
+		if revoked > 0 {
+			server.denylist.ForceSync()
+		}
+		rows, err := server.store.DeleteDueUsers(ctx, pgtype.Timestamptz{Time: time.Now().Add(-server.config.AccessTokenDuration), Valid: true})
+		if err != nil {
+			log.Printf("Warning: could not delete accounts: %v", err)
+			return
+		}
+		for _, row := range rows {
+			accounts = append(accounts, account{id: row.ID, username: row.Username})
+		}
+	}
+	if len(accounts) == 0 {
+		return
+	}
+
+	server.denylist.ForceSync()
+	for _, acc := range accounts {
+		event := audit.Event{Type: audit.EventAccountDelete, Outcome: audit.OutcomeSuccess, Username: acc.username,
+			Details: fmt.Sprintf("id=%d mode=%s", acc.id, server.config.AccountDeletionMode)}
+		if server.config.AccountDeletionMode == config.AccountDeletionAnonymize {
+			// The row still exists, so the event can point at it.
+			event.UserID = acc.id
+		}
+		server.auditor.Log(ctx, event)
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lshigami/Plog/internal/audit"
+	"github.com/lshigami/Plog/internal/auth"
+	"github.com/lshigami/Plog/internal/db/sqlc"
+)
+
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// UpdateUserRole godoc
+// @Summary Change a user's role
+// @Description Set the role of a user (admin, editor, author or reader). The user's sessions and personal access tokens are revoked, so the new role applies from their next login. Admin only.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param role body UpdateUserRoleRequest true "New role"
+// @Success 200 {object} UserResponse "Updated user"
+// @Failure 400 {object} map[string]string "Invalid input or unknown role"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not an admin"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /admin/users/{id}/role [put]
+func (server *Server) UpdateUserRole(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+	var req UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	if !auth.IsValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown role: " + req.Role})
+		return
+	}
+
+	user, err := server.store.UpdateUserRole(c.Request.Context(), sqlc.UpdateUserRoleParams{
+		ID:   int32(userID),
+		Role: req.Role,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role: " + err.Error()})
+		return
+	}
+	// Personal access tokens act with the owner's current role, so a token
+	// created for one role must not carry over to another.
+	if _, err := server.store.RevokeUserPersonalAccessTokens(c.Request.Context(), user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke personal access tokens: " + err.Error()})
+		return
+	}
+	// Access tokens carry the role they were issued with, so the user signs
+	// in again to get the new one.
+	if err := server.store.RevokeUserSessions(c.Request.Context(), user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions: " + err.Error()})
+		return
+	}
+	server.denylist.ForceSync()
+
+	server.audit(c, audit.Event{Type: audit.EventRoleChange, Outcome: audit.OutcomeSuccess, UserID: user.ID, Username: user.Username, Details: "role=" + user.Role})
+
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// UnlockUser godoc
+// @Summary Unlock a user's login
+// @Description Clear the failed logins of a user, lifting a lockout after too many wrong passwords. Lockouts of client IPs are not affected. Admin only.
+// @Tags admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 204 "Login unlocked"
+// @Failure 400 {object} map[string]string "Invalid user ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not an admin"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /admin/users/{id}/lockout [delete]
+func (server *Server) UnlockUser(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+
+	user, err := server.store.GetUserByID(c.Request.Context(), int32(userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user: " + err.Error()})
+		return
+	}
+
+	if err := server.throttle.Reset(c.Request.Context(), user.Username); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock user: " + err.Error()})
+		return
+	}
+	server.audit(c, audit.Event{Type: audit.EventUnlock, Outcome: audit.OutcomeSuccess, UserID: user.ID, Username: user.Username})
+
+	c.Status(http.StatusNoContent)
+}
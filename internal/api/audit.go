@@ -0,0 +1,123 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/lshigami/Plog/internal/audit"
+	"github.com/lshigami/Plog/internal/auth"
+	"github.com/lshigami/Plog/internal/db/sqlc"
+)
+
+type ListAuditEventsRequest struct {
+	UserID    int32      `form:"user_id" binding:"min=0"`
+	EventType string     `form:"event_type"`
+	Since     *time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until     *time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit     int32      `form:"limit,default=50" binding:"min=1,max=500"`
+	Offset    int32      `form:"offset,default=0" binding:"min=0"`
+}
+
+type AuditEventResponse struct {
+	ID        int64     `json:"id"`
+	EventType string    `json:"event_type"`
+	Outcome   string    `json:"outcome"`
+	UserID    *int32    `json:"user_id,omitempty"`
+	ActorID   *int32    `json:"actor_id,omitempty"`
+	Username  string    `json:"username,omitempty"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	Details   string    `json:"details,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newAuditEventResponse(event sqlc.AuditEvent) AuditEventResponse {
+	rsp := AuditEventResponse{
+		ID:        event.ID,
+		EventType: event.EventType,
+		Outcome:   event.Outcome,
+		Username:  event.Username,
+		IP:        event.Ip,
+		UserAgent: event.UserAgent,
+		Details:   event.Details,
+		CreatedAt: event.CreatedAt.Time,
+	}
+	if event.UserID.Valid {
+		rsp.UserID = &event.UserID.Int32
+	}
+	if event.ActorID.Valid {
+		rsp.ActorID = &event.ActorID.Int32
+	}
+	return rsp
+}
+
+// audit records event with the client details of the request. Unless set,
+// the actor is the authenticated user of the request, if any.
+func (server *Server) audit(c *gin.Context, event audit.Event) {
+	event.IP = c.ClientIP()
+	event.UserAgent = truncate(c.Request.UserAgent(), maxUserAgentLength)
+	if event.ActorID == 0 {
+		if payload, ok := c.Get(AuthorizationPayloadKey); ok {
+			event.ActorID = payload.(*auth.Payload).ID
+		}
+	}
+	server.auditor.Log(c.Request.Context(), event)
+}
+
+// ListAuditEvents godoc
+// @Summary List audit events
+// @Description List security events such as logins, failed logins, credential changes and revocations, newest first. user_id matches events about the user as well as events the user caused. Admin only.
+// @Tags admin
+// @Produce json
+// @Param user_id query int false "Only events about or by this user"
+// @Param event_type query string false "Only events of this type, e.g. login"
+// @Param since query string false "Only events at or after this time (RFC 3339)"
+// @Param until query string false "Only events before this time (RFC 3339)"
+// @Param limit query int false "Limit" minimum(1) maximum(500) default(50)
+// @Param offset query int false "Offset" minimum(0) default(0)
+// @Success 200 {array} AuditEventResponse "Audit events"
+// @Failure 400 {object} map[string]string "Invalid filter"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not an admin"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /admin/audit-events [get]
+func (server *Server) ListAuditEvents(c *gin.Context) {
+	var req ListAuditEventsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	if req.EventType != "" && !slices.Contains(audit.EventTypes, req.EventType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown event type: " + req.EventType})
+		return
+	}
+
+	arg := sqlc.ListAuditEventsParams{
+		UserID:    pgtype.Int4{Int32: req.UserID, Valid: req.UserID != 0},
+		EventType: pgtype.Text{String: req.EventType, Valid: req.EventType != ""},
+		Limit:     req.Limit,
+		Offset:    req.Offset,
+	}
+	if req.Since != nil {
+		arg.Since = pgtype.Timestamptz{Time: *req.Since, Valid: true}
+	}
+	if req.Until != nil {
+		arg.Until = pgtype.Timestamptz{Time: *req.Until, Valid: true}
+	}
+
+	events, err := server.store.ListAuditEvents(c.Request.Context(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list audit events: " + err.Error()})
+		return
+	}
+
+	rsp := make([]AuditEventResponse, 0, len(events))
+	for _, event := range events {
+		rsp = append(rsp, newAuditEventResponse(event))
+	}
+	c.JSON(http.StatusOK, rsp)
+}
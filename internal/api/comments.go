@@ -0,0 +1,401 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/lshigami/Plog/internal/auth"
+	"github.com/lshigami/Plog/internal/db/sqlc"
+)
+
+// Ways ListComments can lay out threads.
+const (
+	CommentViewTree = "tree"
+	CommentViewFlat = "flat"
+)
+
+type ListCommentsRequest struct {
+	View   string `form:"view,default=tree" binding:"oneof=tree flat"`
+	Limit  int32  `form:"limit,default=20" binding:"min=1,max=100"`
+	Offset int32  `form:"offset,default=0" binding:"min=0"`
+}
+
+type CreateCommentRequest struct {
+	Content string `json:"content" binding:"required,max=5000"`
+	// ParentID is the comment this one replies to, if any.
+	ParentID *int32 `json:"parent_id" binding:"omitempty,min=1"`
+}
+
+type UpdateCommentRequest struct {
+	Content string `json:"content" binding:"required,max=5000"`
+}
+
+// CommentThread is a comment with its replies, in the tree view of
+// ListComments.
+type CommentThread struct {
+	sqlc.ListCommentsRow
+	Replies []*CommentThread `json:"replies"`
+}
+
+// commentTree nests comments listed in thread order under their parents.
+func commentTree(rows []sqlc.ListCommentsRow) []*CommentThread {
+	roots := []*CommentThread{}
+	threads := make(map[int32]*CommentThread, len(rows))
+	for _, row := range rows {
+		thread := &CommentThread{ListCommentsRow: row, Replies: []*CommentThread{}}
+		threads[row.ID] = thread
+		if parent, ok := threads[row.ParentID.Int32]; row.ParentID.Valid && ok {
+			parent.Replies = append(parent.Replies, thread)
+		} else {
+			roots = append(roots, thread)
+		}
+	}
+	return roots
+}
+
+// publishedPost loads the published post named by the id path parameter, the
+// only posts that can be commented on and reacted to. It responds and returns
+// false when there is none.
+func (server *Server) publishedPost(c *gin.Context) (sqlc.GetPostByIDRow, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID format"})
+		return sqlc.GetPostByIDRow{}, false
+	}
+
+	post, err := server.store.GetPostByID(c.Request.Context(), int32(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return sqlc.GetPostByIDRow{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get post: " + err.Error()})
+		return sqlc.GetPostByIDRow{}, false
+	}
+	if post.Status != PostStatusPublished {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return sqlc.GetPostByIDRow{}, false
+	}
+	return post, true
+}
+
+// postComment loads the comment named by the comment_id path parameter,
+// which must belong to post. It responds and returns false when not.
+func (server *Server) postComment(c *gin.Context, post sqlc.GetPostByIDRow) (sqlc.Comment, bool) {
+	id, err := strconv.ParseInt(c.Param("comment_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID format"})
+		return sqlc.Comment{}, false
+	}
+
+	comment, err := server.store.GetComment(c.Request.Context(), int32(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+			return sqlc.Comment{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get comment: " + err.Error()})
+		return sqlc.Comment{}, false
+	}
+	if comment.PostID != post.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return sqlc.Comment{}, false
+	}
+	return comment, true
+}
+
+// ListComments godoc
+// @Summary List comments
+// @Description List the comments of a published post, oldest threads first. The limit and offset count top-level comments; each comes with all its replies. The tree view nests replies under the comment they answer, the flat view lists every comment in thread order with its depth. Deleted comments that have replies stay in place with empty content and no author.
+// @Tags comments
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param view query string false "Layout of the threads" Enums(tree, flat) default(tree)
+// @Param limit query int false "Number of top-level comments" minimum(1) maximum(100) default(20)
+// @Param offset query int false "Offset" minimum(0) default(0)
+// @Success 200 {array} SwaggerComment "Comments"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /posts/{id}/comments [get]
+func (server *Server) ListComments(c *gin.Context) {
+	var req ListCommentsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	post, ok := server.publishedPost(c)
+	if !ok {
+		return
+	}
+
+	rows, err := server.store.ListComments(c.Request.Context(), sqlc.ListCommentsParams{
+		PostID: post.ID,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list comments: " + err.Error()})
+		return
+	}
+
+	if req.View == CommentViewFlat {
+		c.JSON(http.StatusOK, rows)
+		return
+	}
+	c.JSON(http.StatusOK, commentTree(rows))
+}
+
+// CreateComment godoc
+// @Summary Comment on a post
+// @Description Comment on a published post, or reply to one of its comments with parent_id. Posts whose comments are locked take no new ones.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param request body CreateCommentRequest true "Comment"
+// @Success 201 {object} SwaggerComment "Comment created"
+// @Failure 400 {object} map[string]string "Invalid input, or replying to a deleted comment"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Comments are locked, or email not verified"
+// @Failure 404 {object} map[string]string "Post or parent comment not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /posts/{id}/comments [post]
+func (server *Server) CreateComment(c *gin.Context) {
+	var req CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment is empty"})
+		return
+	}
+	post, ok := server.publishedPost(c)
+	if !ok {
+		return
+	}
+	if post.CommentsLocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Comments on this post are locked"})
+		return
+	}
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+	if !server.requireVerifiedEmail(c, payload.ID, "commenting") {
+		return
+	}
+
+	arg := sqlc.CreateCommentParams{
+		PostID:  post.ID,
+		UserID:  pgtype.Int4{Int32: payload.ID, Valid: true},
+		Content: content,
+	}
+	if req.ParentID != nil {
+		parent, err := server.store.GetComment(c.Request.Context(), *req.ParentID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get parent comment: " + err.Error()})
+			return
+		}
+		if err != nil || parent.PostID != post.ID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Parent comment not found"})
+			return
+		}
+		if parent.DeletedAt.Valid {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot reply to a deleted comment"})
+			return
+		}
+		arg.ParentID = pgtype.Int4{Int32: parent.ID, Valid: true}
+	}
+
+	comment, err := server.store.CreateComment(c.Request.Context(), arg)
+	if err != nil {
+		// The parent was deleted in the meantime.
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Parent comment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// UpdateComment godoc
+// @Summary Edit a comment
+// @Description Change the content of one of your own comments. Comments on posts whose comments are locked cannot be edited.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param comment_id path int true "Comment ID"
+// @Param request body UpdateCommentRequest true "New content"
+// @Success 200 {object} SwaggerComment "Comment updated"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not your comment, or comments are locked"
+// @Failure 404 {object} map[string]string "Post or comment not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /posts/{id}/comments/{comment_id} [put]
+func (server *Server) UpdateComment(c *gin.Context) {
+	var req UpdateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment is empty"})
+		return
+	}
+	post, ok := server.publishedPost(c)
+	if !ok {
+		return
+	}
+	comment, ok := server.postComment(c, post)
+	if !ok {
+		return
+	}
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+	if !comment.UserID.Valid || comment.UserID.Int32 != payload.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only edit your own comments"})
+		return
+	}
+	if post.CommentsLocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Comments on this post are locked"})
+		return
+	}
+
+	updated, err := server.store.UpdateComment(c.Request.Context(), sqlc.UpdateCommentParams{
+		ID:      comment.ID,
+		Content: content,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteComment godoc
+// @Summary Delete a comment
+// @Description Delete a comment. Commenters can delete their own comments, post authors any comment on their posts, and editors and admins any comment. A comment with replies is emptied instead of removed, so the replies stay.
+// @Tags comments
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param comment_id path int true "Comment ID"
+// @Success 204 "Comment deleted"
+// @Failure 400 {object} map[string]string "Invalid post or comment ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "No permission to delete this comment"
+// @Failure 404 {object} map[string]string "Post or comment not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /posts/{id}/comments/{comment_id} [delete]
+func (server *Server) DeleteComment(c *gin.Context) {
+	post, ok := server.publishedPost(c)
+	if !ok {
+		return
+	}
+	comment, ok := server.postComment(c, post)
+	if !ok {
+		return
+	}
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+	ownComment := comment.UserID.Valid && comment.UserID.Int32 == payload.ID
+	if !ownComment && post.UserID != payload.ID && !auth.HasPermission(payload.Role, auth.PermissionModerateComments) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to delete this comment"})
+		return
+	}
+
+	if err := server.store.DeleteComment(c.Request.Context(), comment.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment: " + err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// LockComments godoc
+// @Summary Lock comments
+// @Description Stop a post from taking new comments and comment edits. Existing comments stay visible and can still be deleted. Authors can lock comments on their own posts; editors and admins on any.
+// @Tags comments
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 204 "Comments locked"
+// @Failure 400 {object} map[string]string "Invalid post ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "No permission to lock comments on this post"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /posts/{id}/comments/lock [put]
+func (server *Server) LockComments(c *gin.Context) {
+	server.setCommentsLocked(c, true)
+}
+
+// UnlockComments godoc
+// @Summary Unlock comments
+// @Description Let a post take comments again.
+// @Tags comments
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 204 "Comments unlocked"
+// @Failure 400 {object} map[string]string "Invalid post ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "No permission to lock comments on this post"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /posts/{id}/comments/lock [delete]
+func (server *Server) UnlockComments(c *gin.Context) {
+	server.setCommentsLocked(c, false)
+}
+
+func (server *Server) setCommentsLocked(c *gin.Context, locked bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID format"})
+		return
+	}
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+
+	post, err := server.store.GetPostByID(c.Request.Context(), int32(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get post: " + err.Error()})
+		return
+	}
+	if post.UserID != payload.ID && !auth.HasPermission(payload.Role, auth.PermissionModerateComments) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to lock comments on this post"})
+		return
+	}
+
+	err = server.store.SetPostCommentsLocked(c.Request.Context(), sqlc.SetPostCommentsLockedParams{
+		ID:             post.ID,
+		CommentsLocked: locked,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post: " + err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lshigami/Plog/internal/config"
+)
+
+const (
+	AccessTokenCookieName  = "plog_access_token"
+	RefreshTokenCookieName = "plog_refresh_token"
+	// CSRFCookieName is readable by scripts so the SPA can echo it in
+	// CSRFHeaderName; a page on another site can do neither.
+	CSRFCookieName = "plog_csrf_token"
+	CSRFHeaderName = "X-CSRF-Token"
+
+	accessTokenCookiePath  = "/api/v1"
+	refreshTokenCookiePath = "/api/v1/tokens"
+	csrfCookiePath         = "/"
+
+	csrfTokenBytes = 32
+)
+
+func newCSRFToken() (string, error) {
+	b := make([]byte, csrfTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (server *Server) setCookie(c *gin.Context, name, value, path string, maxAge int, httpOnly bool) {
+	sameSite := http.SameSiteStrictMode
+	switch server.config.CookieSameSite {
+	case config.CookieSameSiteLax:
+		sameSite = http.SameSiteLaxMode
+	case config.CookieSameSiteNone:
+		sameSite = http.SameSiteNoneMode
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   server.config.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   server.config.CookieSecure,
+		HttpOnly: httpOnly,
+		SameSite: sameSite,
+	})
+}
+
+// setAuthCookies hands the tokens to a browser as HttpOnly cookies, together
+// with the CSRF token it has to send back on state-changing requests. The
+// CSRF token of an existing login is kept, so requests already in flight
+// during a renewal stay valid.
+func (server *Server) setAuthCookies(c *gin.Context, accessToken, refreshToken string) error {
+	csrfToken, err := c.Cookie(CSRFCookieName)
+	if err != nil || csrfToken == "" {
+		csrfToken, err = newCSRFToken()
+		if err != nil {
+			return err
+		}
+	}
+
+	refreshMaxAge := int(server.config.RefreshTokenDuration.Seconds())
+	server.setCookie(c, AccessTokenCookieName, accessToken, accessTokenCookiePath, int(server.config.AccessTokenDuration.Seconds()), true)
+	server.setCookie(c, RefreshTokenCookieName, refreshToken, refreshTokenCookiePath, refreshMaxAge, true)
+	server.setCookie(c, CSRFCookieName, csrfToken, csrfCookiePath, refreshMaxAge, false)
+	return nil
+}
+
+func (server *Server) clearAuthCookies(c *gin.Context) {
+	server.setCookie(c, AccessTokenCookieName, "", accessTokenCookiePath, -1, true)
+	server.setCookie(c, RefreshTokenCookieName, "", refreshTokenCookiePath, -1, true)
+	server.setCookie(c, CSRFCookieName, "", csrfCookiePath, -1, false)
+}
+
+// hasAuthCookie reports whether the request carries a token the browser
+// attached on its own.
+func hasAuthCookie(c *gin.Context) bool {
+	for _, name := range []string{AccessTokenCookieName, RefreshTokenCookieName} {
+		if value, err := c.Cookie(name); err == nil && value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// CSRFMiddleware enforces the double-submit check on state-changing requests
+// that authenticate with a cookie: the CSRFHeaderName header must match the
+// CSRFCookieName cookie. Requests with an Authorization header are left
+// alone, because browsers never add that header on their own.
+func CSRFMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		switch ctx.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			ctx.Next()
+			return
+		}
+		if ctx.GetHeader(AuthorizationHeaderKey) != "" || !hasAuthCookie(ctx) {
+			ctx.Next()
+			return
+		}
+
+		cookie, err := ctx.Cookie(CSRFCookieName)
+		header := ctx.GetHeader(CSRFHeaderName)
+		if err != nil || cookie == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) != 1 {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Missing or invalid CSRF token"})
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/lshigami/Plog/internal/auth"
+	"github.com/lshigami/Plog/internal/db/sqlc"
+)
+
+const (
+	denylistSyncInterval  = 10 * time.Second
+	denylistPurgeInterval = time.Hour
+	// denylistSyncOverlap re-reads recent rows on every sync so revocations
+	// committed by other instances slightly out of order are not missed.
+	denylistSyncOverlap = time.Minute
+)
+
+// TokenDenylist tracks access tokens that were revoked before they expired,
+// either one by one or because their session was revoked. Revocations are
+// stored in Postgres so every instance sees them, and each instance keeps the
+// still-active entries in memory, pulling new rows from the database at most
+// once per sync interval.
+type TokenDenylist struct {
+	store        sqlc.Querier
+	syncInterval time.Duration
+	// tokenTTL is the access token lifetime. Tokens of a session revoked
+	// longer ago than that have expired anyway.
+	tokenTTL time.Duration
+
+	mu            sync.RWMutex
+	entries       map[uuid.UUID]time.Time
+	sessions      map[uuid.UUID]time.Time
+	cursor        time.Time
+	sessionCursor time.Time
+	syncedAt      time.Time
+	purgedAt      time.Time
+}
+
+func NewTokenDenylist(store sqlc.Querier, syncInterval, tokenTTL time.Duration) *TokenDenylist {
+	return &TokenDenylist{
+		store:        store,
+		syncInterval: syncInterval,
+		tokenTTL:     tokenTTL,
+		entries:      make(map[uuid.UUID]time.Time),
+		sessions:     make(map[uuid.UUID]time.Time),
+	}
+}
+
+// Revoke denylists the token described by payload until it expires.
+func (d *TokenDenylist) Revoke(ctx context.Context, payload *auth.Payload) error {
+	arg := sqlc.RevokeTokenParams{
+		Jti:       payload.TokenID,
+		ExpiresAt: pgtype.Timestamptz{Time: payload.ExpiresAt, Valid: true},
+	}
+	if err := d.store.RevokeToken(ctx, arg); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.entries[payload.TokenID] = payload.ExpiresAt
+	d.mu.Unlock()
+	return nil
+}
+
+// ForceSync makes the next check reload revocations from the database, so
+// that sessions just revoked by this instance are rejected right away.
+func (d *TokenDenylist) ForceSync() {
+	d.mu.Lock()
+	d.syncedAt = time.Time{}
+	d.mu.Unlock()
+}
+
+// IsRevoked reports whether the token described by payload, or its session,
+// has been revoked.
+func (d *TokenDenylist) IsRevoked(ctx context.Context, payload *auth.Payload) (bool, error) {
+	if err := d.sync(ctx); err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if expiresAt, ok := d.entries[payload.TokenID]; ok && now.Before(expiresAt) {
+		return true, nil
+	}
+	if payload.SessionID == uuid.Nil {
+		return false, nil
+	}
+	until, ok := d.sessions[payload.SessionID]
+	return ok && now.Before(until), nil
+}
+
+func (d *TokenDenylist) sync(ctx context.Context) error {
+	d.mu.RLock()
+	fresh := time.Since(d.syncedAt) < d.syncInterval
+	d.mu.RUnlock()
+	if fresh {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if time.Since(d.syncedAt) < d.syncInterval {
+		return nil
+	}
+
+	since := d.cursor.Add(-denylistSyncOverlap)
+	revoked, err := d.store.ListRevokedTokens(ctx, pgtype.Timestamptz{Time: since, Valid: true})
+	if err != nil {
+		return err
+	}
+	for _, token := range revoked {
+		d.entries[token.Jti] = token.ExpiresAt.Time
+		if token.RevokedAt.Time.After(d.cursor) {
+			d.cursor = token.RevokedAt.Time
+		}
+	}
+
+	now := time.Now()
+	since = d.sessionCursor.Add(-denylistSyncOverlap)
+	if oldest := now.Add(-d.tokenTTL); since.Before(oldest) {
+		since = oldest
+	}
+	revokedSessions, err := d.store.ListRevokedSessions(ctx, pgtype.Timestamptz{Time: since, Valid: true})
+	if err != nil {
+		return err
+	}
+	for _, session := range revokedSessions {
+		d.sessions[session.ID] = session.RevokedAt.Time.Add(d.tokenTTL)
+		if session.RevokedAt.Time.After(d.sessionCursor) {
+			d.sessionCursor = session.RevokedAt.Time
+		}
+	}
+
+	for tokenID, expiresAt := range d.entries {
+		if now.After(expiresAt) {
+			delete(d.entries, tokenID)
+		}
+	}
+	for sessionID, until := range d.sessions {
+		if now.After(until) {
+			delete(d.sessions, sessionID)
+		}
+	}
+	d.syncedAt = now
+
+	if now.Sub(d.purgedAt) >= denylistPurgeInterval {
+		d.purgedAt = now
+		if _, err := d.store.DeleteExpiredRevokedTokens(ctx); err != nil {
+			log.Printf("Warning: could not purge expired revoked tokens: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/lshigami/Plog/internal/audit"
+	"github.com/lshigami/Plog/internal/auth"
+	"github.com/lshigami/Plog/internal/db/sqlc"
+	"github.com/lshigami/Plog/internal/mail"
+)
+
+const emailVerificationTokenBytes = 32
+
+type UpdateEmailRequest struct {
+	Email string `json:"email" binding:"required,email,max=255"`
+	// Password is required unless the account was created through OIDC
+	// and has no password.
+	Password string `json:"password"`
+	// Code is a TOTP or recovery code, required when two-factor
+	// authentication is on.
+	Code string `json:"code"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// sendVerificationEmail emails user a link that verifies their current address.
+func (server *Server) sendVerificationEmail(ctx context.Context, user sqlc.User) error {
+	token, err := auth.RandomToken(emailVerificationTokenBytes)
+	if err != nil {
+		return err
+	}
+	_, err = server.store.CreateEmailVerificationToken(ctx, sqlc.CreateEmailVerificationTokenParams{
+		UserID:    user.ID,
+		Email:     user.Email.String,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(server.config.EmailVerificationTTL), Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	// The link opens a page of the frontend, which posts the token to
+	// VerifyEmail, so that mail scanners following it cannot redeem it.
+	link := server.config.AppBaseURL + "/verify-email?token=" + url.QueryEscape(token)
+	server.sendMail(mail.Message{
+		To:      user.Email.String,
+		Subject: "Verify your Plog email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm that this is your email address by opening the link below. It expires in %s.\n\n%s\n\nIf you did not create a Plog account, you can ignore this email.\n",
+			user.Username, server.config.EmailVerificationTTL, link),
+	})
+	return nil
+}
+
+// VerifyEmail godoc
+// @Summary Verify an email address
+// @Description Confirm an email address with the token from a verification email. Tokens can only be used once and stop working if the account's address has changed since.
+// @Tags authentication
+// @Accept json
+// @Produce json
+// @Param request body VerifyEmailRequest true "Verification token"
+// @Success 200 {object} UserResponse "Email verified"
+// @Failure 400 {object} map[string]string "Invalid input or invalid/expired token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /verify-email [post]
+func (server *Server) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	verification, err := server.store.ConsumeEmailVerificationToken(c.Request.Context(), auth.HashToken(req.Token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to redeem verification token: " + err.Error()})
+		return
+	}
+
+	user, err := server.store.MarkUserEmailVerified(c.Request.Context(), sqlc.MarkUserEmailVerifiedParams{
+		ID:    verification.UserID,
+		Email: pgtype.Text{String: verification.Email, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The address was changed after this token was sent.
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// UpdateEmail godoc
+// @Summary Change the email address
+// @Description Set a new email address for the current user and send a verification email to it. The password, and with two-factor authentication a code, must be confirmed. Accounts created through OIDC without a password confirm with their code, or without two-factor authentication by having signed in within the last 10 minutes. The address is unverified until the link is opened.
+// @Tags authentication
+// @Accept json
+// @Produce json
+// @Param request body UpdateEmailRequest true "New email address, password and, with two-factor authentication, a code"
+// @Success 200 {object} UserResponse "Email updated"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Wrong password or code, or sign-in too long ago"
+// @Failure 409 {object} map[string]string "Email already in use"
+// @Failure 423 {object} map[string]string "Account temporarily locked after too many failed passwords, see Retry-After"
+// @Failure 429 {object} map[string]string "Too many failed passwords from this IP, see Retry-After"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /me/email [put]
+func (server *Server) UpdateEmail(c *gin.Context) {
+	var req UpdateEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	user, ok := server.currentUser(c)
+	if !ok {
+		return
+	}
+	// Otherwise a stolen access token would be enough to take over the
+	// account with a password reset sent to the new address.
+	if !server.confirmIdentity(c, user, req.Password, req.Code, audit.EventEmailChange) {
+		return
+	}
+
+	user, err := server.store.UpdateUserEmail(c.Request.Context(), sqlc.UpdateUserEmailParams{
+		ID:    user.ID,
+		Email: pgtype.Text{String: normalizeEmail(req.Email), Valid: true},
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			c.JSON(http.StatusConflict, gin.H{"error": "Email already in use"})
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update email: " + err.Error()})
+		return
+	}
+
+	server.audit(c, audit.Event{Type: audit.EventEmailChange, Outcome: audit.OutcomeSuccess, UserID: user.ID, Username: user.Username})
+
+	if err := server.sendVerificationEmail(c.Request.Context(), user); err != nil {
+		log.Printf("Warning: could not send verification email to user %d: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// ResendVerificationEmail godoc
+// @Summary Resend the verification email
+// @Description Send a new verification link to the current user's email address.
+// @Tags authentication
+// @Produce json
+// @Success 202 {object} map[string]string "Verification email sent"
+// @Failure 400 {object} map[string]string "No email address set"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 409 {object} map[string]string "Email already verified"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /me/email/verification [post]
+func (server *Server) ResendVerificationEmail(c *gin.Context) {
+	user, ok := server.currentUser(c)
+	if !ok {
+		return
+	}
+	if !user.Email.Valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No email address set"})
+		return
+	}
+	if user.EmailVerifiedAt.Valid {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email already verified"})
+		return
+	}
+
+	if err := server.sendVerificationEmail(c.Request.Context(), user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent"})
+}
@@ -1,72 +1,129 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"log"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/lshigami/Plog/internal/audit"
 	"github.com/lshigami/Plog/internal/auth"
 	"github.com/lshigami/Plog/internal/db/sqlc"
+	"github.com/lshigami/Plog/internal/render"
 )
 
+// pgUniqueViolation is the Postgres error code for a unique constraint violation.
+const pgUniqueViolation = "23505"
+
+// pgForeignKeyViolation is the Postgres error code for a foreign key violation.
+const pgForeignKeyViolation = "23503"
+
 type RegisterUserRequest struct {
 	Username string `json:"username" binding:"required,alphanum,min=3,max=50"`
 	Password string `json:"password" binding:"required,min=6"`
+	Email    string `json:"email" binding:"omitempty,email,max=255"`
 }
 
 type UserResponse struct {
-	ID        int32     `json:"id"`
-	Username  string    `json:"username"`
-	CreatedAt time.Time `json:"created_at"`
+	ID               int32     `json:"id"`
+	Username         string    `json:"username"`
+	Email            string    `json:"email,omitempty"`
+	EmailVerified    bool      `json:"email_verified"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
+	Role             string    `json:"role"`
+	CreatedAt        time.Time `json:"created_at"`
+	// DeletionScheduledAt is set while a requested account deletion can
+	// still be cancelled.
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
 }
 
 func newUserResponse(user sqlc.User) UserResponse {
-	return UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		CreatedAt: user.CreatedAt.Time,
+	rsp := UserResponse{
+		ID:               user.ID,
+		Username:         user.Username,
+		Email:            user.Email.String,
+		EmailVerified:    user.EmailVerifiedAt.Valid,
+		TwoFactorEnabled: user.TotpEnabledAt.Valid,
+		Role:             user.Role,
+		CreatedAt:        user.CreatedAt.Time,
+	}
+	if user.DeletionScheduledAt.Valid {
+		rsp.DeletionScheduledAt = &user.DeletionScheduledAt.Time
 	}
+	return rsp
+}
+
+// normalizeEmail lowercases an address so that lookups and the uniqueness
+// constraint are case-insensitive.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
 
 type LoginUserRequest struct {
-	Username string `json:"username" binding:"required"`
+	Username string `json:"username" binding:"required,max=50"`
 	Password string `json:"password" binding:"required"`
 }
 
+// LoginUserResponse leaves out the tokens when they are set as cookies.
 type LoginUserResponse struct {
-	AccessToken string       `json:"access_token"`
-	User        UserResponse `json:"user"`
+	AccessToken  string       `json:"access_token,omitempty"`
+	RefreshToken string       `json:"refresh_token,omitempty"`
+	User         UserResponse `json:"user"`
 }
 
 type CreatePostRequest struct {
 	Title   string `json:"title" binding:"required,min=3,max=255"`
 	Content string `json:"content" binding:"required"`
+	// Status defaults to published. A scheduled post needs PublishedAt, the
+	// time it becomes public.
+	Status      string     `json:"status" binding:"omitempty,oneof=draft published scheduled"`
+	PublishedAt *time.Time `json:"published_at"`
+	// Format is the markup Content is written in and defaults to markdown.
+	Format string   `json:"format" binding:"omitempty,oneof=markdown plain html"`
+	Tags   []string `json:"tags" binding:"max=10,dive,min=1,max=50"`
 }
 
 type ListPostsRequest struct {
 	Limit  int32 `form:"limit,default=10" binding:"min=1,max=100"`
 	Offset int32 `form:"offset,default=0" binding:"min=0"`
+	// Tags only lists posts with any, or with TagMatch all, of these tags.
+	Tags     []string `form:"tags"`
+	TagMatch string   `form:"tag_match,default=any" binding:"oneof=any all"`
 }
 
 type UpdatePostRequest struct {
 	Title   string `json:"title" binding:"required,min=3,max=255"`
 	Content string `json:"content" binding:"required"`
+	// Status, PublishedAt, Format and Tags keep their current values when
+	// left out. An empty list of tags removes all of them.
+	Status      string     `json:"status" binding:"omitempty,oneof=draft published scheduled archived"`
+	PublishedAt *time.Time `json:"published_at"`
+	Format      string     `json:"format" binding:"omitempty,oneof=markdown plain html"`
+	Tags        []string   `json:"tags" binding:"omitempty,max=10,dive,min=1,max=50"`
+}
+
+type DeletePostResponse struct {
+	RowsAffected int64 `json:"rows_affected"`
 }
 
 // RegisterUser godoc
 // @Summary Register a new user
-// @Description Register a new user with username and password
+// @Description Register a new user with username, password and an email address, which is optional unless EMAIL_REQUIRED is set. A verification link is emailed to the address.
 // @Tags authentication
 // @Accept json
 // @Produce json
 // @Param request body RegisterUserRequest true "User registration details"
 // @Success 201 {object} UserResponse "User created successfully"
 // @Failure 400 {object} map[string]string "Invalid input"
-// @Failure 409 {object} map[string]string "Username already exists"
+// @Failure 409 {object} map[string]string "Username or email already exists"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /register [post]
 func (server *Server) RegisterUser(c *gin.Context) {
@@ -75,8 +132,12 @@ func (server *Server) RegisterUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
 		return
 	}
+	if req.Email == "" && server.config.EmailRequired {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: email is required"})
+		return
+	}
 
-	hashedPassword, err := auth.HashPassword(req.Password)
+	hashedPassword, err := server.hasher.Hash(req.Password)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
 		return
@@ -86,27 +147,45 @@ func (server *Server) RegisterUser(c *gin.Context) {
 		Username:     req.Username,
 		PasswordHash: hashedPassword,
 	}
+	if req.Email != "" {
+		arg.Email = pgtype.Text{String: normalizeEmail(req.Email), Valid: true}
+	}
 
 	user, err := server.store.CreateUser(c, arg)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			c.JSON(http.StatusConflict, gin.H{"error": "Username or email already exists"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user: " + err.Error()})
 		return
 	}
 
+	if user.Email.Valid {
+		if err := server.sendVerificationEmail(c.Request.Context(), user); err != nil {
+			log.Printf("Warning: could not send verification email to user %d: %v", user.ID, err)
+		}
+	}
+
+	server.audit(c, audit.Event{Type: audit.EventRegister, Outcome: audit.OutcomeSuccess, UserID: user.ID, Username: user.Username})
+
 	rsp := newUserResponse(user)
 	c.JSON(http.StatusCreated, rsp)
 }
 
 // LoginUser godoc
 // @Summary Login a user
-// @Description Authenticate a user and return an access token
+// @Description Authenticate a user and return an access token and a refresh token. For accounts with two-factor authentication the response is an MFAChallengeResponse instead, to be completed at /login/mfa. With cookie authentication the tokens are set as HttpOnly cookies together with a plog_csrf_token cookie instead of being returned.
 // @Tags authentication
 // @Accept json
 // @Produce json
 // @Param request body LoginUserRequest true "User login credentials"
-// @Success 200 {object} LoginUserResponse "Login successful"
+// @Success 200 {object} LoginUserResponse "Login successful (or MFAChallengeResponse when a second factor is required)"
 // @Failure 400 {object} map[string]string "Invalid input"
 // @Failure 401 {object} map[string]string "Invalid username or password"
+// @Failure 423 {object} map[string]string "Account temporarily locked after too many failed logins, see Retry-After"
+// @Failure 429 {object} map[string]string "Too many failed logins from this IP, see Retry-After"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /login [post]
 func (server *Server) LoginUser(c *gin.Context) {
@@ -116,9 +195,16 @@ func (server *Server) LoginUser(c *gin.Context) {
 		return
 	}
 
+	// Refuse locked out usernames and IPs before spending a password check on them.
+	ip := c.ClientIP()
+	if !server.checkLoginLockout(c, req.Username, ip, audit.EventLogin) {
+		return
+	}
+
 	user, err := server.store.GetUserByUsername(c.Request.Context(), req.Username)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
+			server.recordLoginFailure(c, audit.EventLogin, 0, req.Username, ip, "unknown_user")
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
 			return
 		}
@@ -126,28 +212,219 @@ func (server *Server) LoginUser(c *gin.Context) {
 		return
 	}
 
-	if !auth.CheckPasswordHash(req.Password, user.PasswordHash) {
+	match, needsRehash, err := server.hasher.Verify(req.Password, user.PasswordHash)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check password: " + err.Error()})
+		return
+	}
+	if !match {
+		server.recordLoginFailure(c, audit.EventLogin, user.ID, req.Username, ip, "wrong_password")
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
 		return
 	}
+	if needsRehash {
+		server.rehashPassword(c.Request.Context(), user, req.Password)
+	}
+
+	if user.TotpEnabledAt.Valid {
+		server.audit(c, audit.Event{Type: audit.EventLogin, Outcome: audit.OutcomeSuccess, UserID: user.ID, Username: user.Username, Details: "mfa_required"})
+		server.startMFAChallenge(c, user)
+		return
+	}
 
-	accessToken, err := server.tokenMaker.CreateToken(user.ID, user.Username, server.config.AccessTokenDuration)
+	server.completeLogin(c, user, audit.EventLogin)
+}
+
+// rehashPassword replaces the password hash of user with one made by the
+// current hasher, unless the password was changed in the meantime.
+func (server *Server) rehashPassword(ctx context.Context, user sqlc.User, password string) {
+	hash, err := server.hasher.Hash(password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create access token"})
+		log.Printf("Warning: could not rehash password of %s: %v", user.Username, err)
 		return
 	}
+	err = server.store.RehashUserPassword(ctx, sqlc.RehashUserPasswordParams{
+		NewPasswordHash: hash,
+		ID:              user.ID,
+		PasswordHash:    user.PasswordHash,
+	})
+	if err != nil {
+		log.Printf("Warning: could not rehash password of %s: %v", user.Username, err)
+	}
+}
 
-	rsp := LoginUserResponse{
-		AccessToken: accessToken,
-		User:        newUserResponse(user),
+// checkLoginLockout responds with 423 or 429 and returns false if password
+// checks for username or from ip are locked out. Refusals are audited as
+// failed events of eventType.
+func (server *Server) checkLoginLockout(c *gin.Context, username, ip, eventType string) bool {
+	scope, lockedUntil, err := server.throttle.Check(c.Request.Context(), username, ip)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check login attempts: " + err.Error()})
+		return false
+	}
+	if scope == "" {
+		return true
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(lockedUntil).Seconds()))))
+	server.audit(c, audit.Event{Type: eventType, Outcome: audit.OutcomeFailure, Username: username, Details: "locked_out_" + scope})
+	if scope == loginScopeUsername {
+		c.JSON(http.StatusLocked, gin.H{"error": "Account is temporarily locked after too many failed logins"})
+		return false
+	}
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed logins, try again later"})
+	return false
+}
+
+// recordLoginFailure audits a failed password or code check of eventType and
+// counts it towards the lockouts of username and ip.
+func (server *Server) recordLoginFailure(c *gin.Context, eventType string, userID int32, username, ip, reason string) {
+	server.audit(c, audit.Event{Type: eventType, Outcome: audit.OutcomeFailure, UserID: userID, Username: username, Details: reason})
+	if err := server.throttle.RecordFailure(c.Request.Context(), username, ip); err != nil {
+		log.Printf("Warning: could not record failed login of %s from %s: %v", username, ip, err)
+	}
+}
+
+// confirmIdentity checks, before the current user does something sensitive
+// recorded as eventType, that they know their password and, with two-factor
+// authentication on, a code. Wrong answers count as failed logins, so a
+// stolen access token cannot be used to guess them. Accounts created through
+// OIDC have no password: they confirm with their code, or without two-factor
+// authentication by having signed in within reauthenticationWindow. It
+// responds and returns false when the check fails.
+func (server *Server) confirmIdentity(c *gin.Context, user sqlc.User, password, code, eventType string) bool {
+	ip := c.ClientIP()
+	if !server.checkLoginLockout(c, user.Username, ip, eventType) {
+		return false
+	}
+
+	if user.PasswordHash == oidcPasswordHash {
+		if !user.TotpEnabledAt.Valid {
+			return server.checkRecentLogin(c, user, eventType)
+		}
+	} else {
+		if password == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Password is required"})
+			return false
+		}
+		match, _, err := server.hasher.Verify(password, user.PasswordHash)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check password: " + err.Error()})
+			return false
+		}
+		if !match {
+			server.recordLoginFailure(c, eventType, user.ID, user.Username, ip, "wrong_password")
+			c.JSON(http.StatusForbidden, gin.H{"error": "Incorrect password"})
+			return false
+		}
+	}
+
+	if user.TotpEnabledAt.Valid {
+		ok, err := server.checkSecondFactor(c.Request.Context(), user, code)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check code: " + err.Error()})
+			return false
+		}
+		if !ok {
+			server.recordLoginFailure(c, eventType, user.ID, user.Username, ip, "invalid_code")
+			c.JSON(http.StatusForbidden, gin.H{"error": errInvalidMFACode})
+			return false
+		}
+	}
+	return true
+}
+
+// checkRecentLogin confirms the identity of a user without a password or a
+// second factor by the session of the request: the provider asked for their
+// credentials when it was created, which must be at most
+// reauthenticationWindow ago. Nothing can be guessed here, so a refusal is
+// not a failed login. It responds and returns false when the check fails.
+func (server *Server) checkRecentLogin(c *gin.Context, user sqlc.User, eventType string) bool {
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+	session, err := server.store.GetSession(c.Request.Context(), payload.SessionID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get session: " + err.Error()})
+		return false
+	}
+	if err != nil || session.UserID != user.ID || session.IsRevoked ||
+		time.Since(session.CreatedAt.Time) > reauthenticationWindow {
+		server.audit(c, audit.Event{Type: eventType, Outcome: audit.OutcomeFailure, UserID: user.ID, Username: user.Username, Details: "login_too_old"})
+		c.JSON(http.StatusForbidden, gin.H{"error": errReauthenticationRequired})
+		return false
+	}
+	return true
+}
+
+// completeLogin responds with a new access token and session for user, once
+// every authentication factor has been checked, and records a successful
+// login of eventType.
+func (server *Server) completeLogin(c *gin.Context, user sqlc.User, eventType string) {
+	rsp, ok := server.newLogin(c, user, eventType)
+	if !ok {
+		return
 	}
 	c.JSON(http.StatusOK, rsp)
+}
+
+// newLogin starts a session for user, sets the auth cookies with cookie
+// authentication and records a successful login of eventType. It responds
+// and returns false when that fails.
+func (server *Server) newLogin(c *gin.Context, user sqlc.User, eventType string) (LoginUserResponse, bool) {
+	// Failed logins are only forgotten once the second factor passed too,
+	// or knowing the password would allow unlimited guesses at the code.
+	if err := server.throttle.Reset(c.Request.Context(), user.Username); err != nil {
+		log.Printf("Warning: could not reset failed logins of %s: %v", user.Username, err)
+	}
+
+	sessionID, refreshToken, err := server.createSession(c, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session: " + err.Error()})
+		return LoginUserResponse{}, false
+	}
+
+	accessToken, err := server.tokenMaker.CreateToken(user.ID, user.Username, user.Role, sessionID, server.config.AccessTokenDuration)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create access token"})
+		return LoginUserResponse{}, false
+	}
 
+	server.audit(c, audit.Event{Type: eventType, Outcome: audit.OutcomeSuccess, UserID: user.ID, Username: user.Username})
+
+	rsp := LoginUserResponse{User: newUserResponse(user)}
+	if server.config.CookieAuth {
+		if err := server.setAuthCookies(c, accessToken, refreshToken); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create CSRF token"})
+			return LoginUserResponse{}, false
+		}
+	} else {
+		rsp.AccessToken = accessToken
+		rsp.RefreshToken = refreshToken
+	}
+	return rsp, true
+}
+
+// requireVerifiedEmail checks, when REQUIRE_VERIFIED_EMAIL is set, that the
+// user has verified their email address before doing what action describes.
+// It responds and returns false when not.
+func (server *Server) requireVerifiedEmail(c *gin.Context, userID int32, action string) bool {
+	if !server.config.RequireVerifiedEmail {
+		return true
+	}
+	user, err := server.store.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user: " + err.Error()})
+		return false
+	}
+	if !user.EmailVerifiedAt.Valid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Verify your email address before " + action})
+		return false
+	}
+	return true
 }
 
 // CreatePost godoc
 // @Summary Create a new post
-// @Description Create a new blog post
+// @Description Create a new blog post. It is published right away unless it is saved as a draft or scheduled for a later time.
 // @Tags posts
 // @Accept json
 // @Produce json
@@ -155,6 +432,7 @@ func (server *Server) LoginUser(c *gin.Context) {
 // @Success 201 {object} SwaggerPost "Post created successfully"
 // @Failure 400 {object} map[string]string "Invalid input"
 // @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Role is not allowed to create posts, or email not verified"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Security BearerAuth
 // @Router /posts [post]
@@ -169,14 +447,56 @@ func (server *Server) CreatePost(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
 		return
 	}
+	if !server.requireVerifiedEmail(c, userID.(int32), "creating posts") {
+		return
+	}
+	if req.Status == "" {
+		req.Status = PostStatusPublished
+	}
+	status, publishedAt, err := resolvePublication(req.Status, req.PublishedAt, "", pgtype.Timestamptz{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	if req.Format == "" {
+		req.Format = render.FormatMarkdown
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	contentHTML, err := server.renderer.Render(req.Format, req.Content)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render content: " + err.Error()})
+		return
+	}
+	postSlug, err := server.uniqueSlug(c.Request.Context(), req.Title, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create slug: " + err.Error()})
+		return
+	}
 	arg := sqlc.CreatePostParams{
-		UserID:  userID.(int32),
-		Title:   req.Title,
-		Content: req.Content,
+		UserID:       userID.(int32),
+		Title:        req.Title,
+		Content:      req.Content,
+		Status:       status,
+		PublishedAt:  publishedAt,
+		Slug:         postSlug,
+		Format:       req.Format,
+		ContentHtml:  pgtype.Text{String: contentHTML, Valid: true},
+		RenderedWith: pgtype.Text{String: server.renderer.Fingerprint(), Valid: true},
+		TagSlugs:     tags.slugs,
+		TagNames:     tags.names,
 	}
 
 	post, err := server.store.CreatePost(c.Request.Context(), arg)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			c.JSON(http.StatusConflict, gin.H{"error": "Another post just took this slug, please try again"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create post: " + err.Error()})
 		return
 	}
@@ -194,7 +514,7 @@ func (server *Server) CreatePost(c *gin.Context) {
 
 // GetPost godoc
 // @Summary Get a post by ID
-// @Description Get details of a specific post by its ID
+// @Description Get details of a specific published post by its ID
 // @Tags posts
 // @Accept json
 // @Produce json
@@ -221,18 +541,24 @@ func (server *Server) GetPost(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get post: " + err.Error()})
 		return
 	}
+	if post.Status != PostStatusPublished {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
 
 	c.JSON(http.StatusOK, post)
 }
 
 // ListPosts godoc
 // @Summary List posts
-// @Description Get a list of posts with pagination
+// @Description Get a list of published posts with pagination, newest first, optionally only those with some tags
 // @Tags posts
 // @Accept json
 // @Produce json
 // @Param limit query int true "Limit" minimum(1) maximum(100)
 // @Param offset query int true "Offset" minimum(0)
+// @Param tags query []string false "Only posts with these tags, as slugs or names; repeated or comma separated" collectionFormat(multi)
+// @Param tag_match query string false "Whether posts need any or all of the tags" Enums(any, all) default(any)
 // @Success 200 {array} SwaggerPost "List of posts"
 // @Failure 400 {object} map[string]string "Invalid input"
 // @Failure 500 {object} map[string]string "Internal server error"
@@ -244,9 +570,17 @@ func (server *Server) ListPosts(c *gin.Context) {
 		return
 	}
 
+	tags, err := tagFilter(req.Tags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
 	arg := sqlc.ListPostsParams{
-		Limit:  req.Limit,
-		Offset: req.Offset,
+		Limit:    req.Limit,
+		Offset:   req.Offset,
+		Tags:     tags,
+		MatchAll: req.TagMatch == TagMatchAll,
 	}
 
 	posts, err := server.store.ListPosts(c.Request.Context(), arg)
@@ -260,7 +594,7 @@ func (server *Server) ListPosts(c *gin.Context) {
 
 // UpdatePost godoc
 // @Summary Update a post
-// @Description Update a post's title and content
+// @Description Update a post's title and content, and optionally its status. Authors can only update their own posts; editors and admins can update any post.
 // @Tags posts
 // @Accept json
 // @Produce json
@@ -269,7 +603,8 @@ func (server *Server) ListPosts(c *gin.Context) {
 // @Success 200 {object} SwaggerPost "Updated post"
 // @Failure 400 {object} map[string]string "Invalid input"
 // @Failure 401 {object} map[string]string "Unauthorized"
-// @Failure 404 {object} map[string]string "Post not found or no permission"
+// @Failure 403 {object} map[string]string "No permission to update this post"
+// @Failure 404 {object} map[string]string "Post not found"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Security BearerAuth
 // @Router /posts/{id} [put]
@@ -285,32 +620,101 @@ func (server *Server) UpdatePost(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
 		return
 	}
-	userID := c.MustGet(UserIDKey).(int)
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+
+	existing, err := server.store.GetPostByID(c.Request.Context(), int32(postID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get post: " + err.Error()})
+		return
+	}
+	if existing.UserID != payload.ID && !auth.HasPermission(payload.Role, auth.PermissionUpdateAnyPost) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to update this post"})
+		return
+	}
+	status, publishedAt, err := resolvePublication(req.Status, req.PublishedAt, existing.Status, existing.PublishedAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	if req.Format == "" {
+		req.Format = existing.Format
+	}
+	var tags *postTags
+	if req.Tags != nil {
+		tags, err = normalizeTags(req.Tags)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+	}
+
+	server.savePost(c, existing, sqlc.UpdatePostParams{
+		ID:          existing.ID,
+		Title:       req.Title,
+		Content:     req.Content,
+		Status:      status,
+		PublishedAt: publishedAt,
+		Format:      req.Format,
+		EditorID:    payload.ID,
+	}, tags)
+}
 
-	arg := sqlc.UpdatePostParams{
-		ID:      int32(postID),
-		Title:   req.Title,
-		Content: req.Content,
-		UserID:  int32(userID),
+// DeletePost godoc
+// @Summary Delete a post
+// @Description Delete a post. Authors can only delete their own posts; editors and admins can delete any post.
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} DeletePostResponse "Post deleted"
+// @Failure 400 {object} map[string]string "Invalid post ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "No permission to delete this post"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /posts/{id} [delete]
+func (server *Server) DeletePost(c *gin.Context) {
+	idStr := c.Param("id")
+	postID, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID format"})
+		return
 	}
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
 
-	post, err := server.store.UpdatePost(c.Request.Context(), arg)
+	existing, err := server.store.GetPostByID(c.Request.Context(), int32(postID))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			// Could be post not found OR user doesn't own it
-			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found or you don't have permission to update it"})
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post: " + err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get post: " + err.Error()})
+		return
+	}
+	anyOwner := auth.HasPermission(payload.Role, auth.PermissionDeleteAnyPost)
+	if existing.UserID != payload.ID && !anyOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to delete this post"})
 		return
 	}
 
-	fullPost, err := server.store.GetPostByID(c.Request.Context(), post.ID)
+	rows, err := server.store.DeletePost(c.Request.Context(), sqlc.DeletePostParams{
+		ID:       int32(postID),
+		UserID:   payload.ID,
+		AnyOwner: anyOwner,
+	})
 	if err != nil {
-		log.Printf("Warning: could not fetch full post details after update: %v", err)
-		c.JSON(http.StatusOK, post)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete post: " + err.Error()})
+		return
+	}
+	if rows == 0 {
+		// Deleted by a concurrent request after the lookup.
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, fullPost)
+	c.JSON(http.StatusOK, DeletePostResponse{RowsAffected: rows})
 }
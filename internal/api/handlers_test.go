@@ -1,20 +1,36 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/lshigami/Plog/internal/audit"
+	"github.com/lshigami/Plog/internal/auth"
 	"github.com/lshigami/Plog/internal/config"
 	mock_sqlc "github.com/lshigami/Plog/internal/db/mock"
 	"github.com/lshigami/Plog/internal/db/sqlc"
+	"github.com/lshigami/Plog/internal/diff"
+	"github.com/lshigami/Plog/internal/mail"
+	"github.com/lshigami/Plog/internal/oidc/oidctest"
+	"github.com/lshigami/Plog/internal/render"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Định nghĩa struct response tương ứng với handler
@@ -35,15 +51,30 @@ type HTTPError struct {
 
 func setupTestServer(t *testing.T, store sqlc.Querier) *Server {
 	fakeConfig := config.Config{
-		DatabaseURL:         "postgres",
-		ServerPort:          "8080",
-		AccessTokenDuration: time.Minute,
-		JWTSecret:           "a_very_secret_key_should_be_longer_and_random",
+		DatabaseURL:          "postgres",
+		ServerPort:           "8080",
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+		JWTSecret:            "a_very_secret_key_should_be_longer_and_random",
+		Argon2Memory:         64,
+		Argon2Iterations:     1,
+		Argon2Parallelism:    1,
 	}
 	server := NewServer(fakeConfig, store)
+	server.auditor = &auditRecorder{}
 	return server
 }
 
+// auditRecorder keeps audit events in memory instead of writing them to the
+// store.
+type auditRecorder struct {
+	events []audit.Event
+}
+
+func (r *auditRecorder) Log(ctx context.Context, event audit.Event) {
+	r.events = append(r.events, event)
+}
+
 func setupGinTest() (*gin.Context, *httptest.ResponseRecorder) {
 	gin.SetMode(gin.TestMode)
 	w := httptest.NewRecorder()
@@ -155,4 +186,3581 @@ func TestListPostsAPI(t *testing.T) {
 		require.NoError(t, err)
 		require.Contains(t, errorResponse.Error, "Invalid input")
 	})
+
+	t.Run("FilterByTags", func(t *testing.T) {
+		testCases := []struct {
+			query    string
+			tags     []string
+			matchAll bool
+		}{
+			{query: "tags=go", tags: []string{"go"}},
+			{query: "tags=Go,Web%20Dev&tags=go&tag_match=all", tags: []string{"go", "web-dev"}, matchAll: true},
+			{query: "tags=go&tags=sql&tag_match=any", tags: []string{"go", "sql"}},
+		}
+		for _, tc := range testCases {
+			ctrl := gomock.NewController(t)
+			mockStore := mock_sqlc.NewMockQuerier(ctrl)
+			server := setupTestServer(t, mockStore)
+			c, recorder := setupGinTest()
+
+			mockStore.EXPECT().
+				ListPosts(gomock.Any(), sqlc.ListPostsParams{Limit: 10, Offset: 0, Tags: tc.tags, MatchAll: tc.matchAll}).
+				Times(1).
+				Return(mockPosts, nil)
+
+			c.Request, _ = http.NewRequest(http.MethodGet, "/posts?"+tc.query, nil)
+			server.ListPosts(c)
+
+			require.Equal(t, http.StatusOK, recorder.Code, tc.query)
+			ctrl.Finish()
+		}
+	})
+
+	t.Run("InvalidTagMatch", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := setupGinTest()
+
+		c.Request, _ = http.NewRequest(http.MethodGet, "/posts?tags=go&tag_match=some", nil)
+		server.ListPosts(c)
+
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
+
+func TestRenewAccessTokenAPI(t *testing.T) {
+	user := sqlc.User{ID: 10, Username: "testuser"}
+	sessionID := uuid.New()
+	refreshToken, refreshTokenHash, err := auth.NewRefreshToken(sessionID)
+	require.NoError(t, err)
+
+	newRenewRequest := func(token string) *http.Request {
+		body, _ := json.Marshal(RenewAccessTokenRequest{RefreshToken: token})
+		return httptest.NewRequest(http.MethodPost, "/tokens/renew", bytes.NewReader(body))
+	}
+	activeSession := sqlc.Session{
+		ID:               sessionID,
+		UserID:           user.ID,
+		RefreshTokenHash: refreshTokenHash,
+		ExpiresAt:        pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := setupGinTest()
+
+		mockStore.EXPECT().GetSession(gomock.Any(), sessionID).Times(1).Return(activeSession, nil)
+		mockStore.EXPECT().
+			RotateSessionToken(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg sqlc.RotateSessionTokenParams) (sqlc.Session, error) {
+				require.Equal(t, refreshTokenHash, arg.RefreshTokenHash)
+				require.NotEqual(t, refreshTokenHash, arg.NewRefreshTokenHash)
+				return activeSession, nil
+			})
+		mockStore.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+
+		c.Request = newRenewRequest(refreshToken)
+		server.RenewAccessToken(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var rsp RenewAccessTokenResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		require.NotEmpty(t, rsp.AccessToken)
+		require.NotEqual(t, refreshToken, rsp.RefreshToken)
+	})
+
+	t.Run("ReusedTokenRevokesSession", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := setupGinTest()
+
+		rotatedSession := activeSession
+		rotatedSession.RefreshTokenHash = auth.HashToken("a-newer-secret")
+
+		mockStore.EXPECT().GetSession(gomock.Any(), sessionID).Times(1).Return(rotatedSession, nil)
+		mockStore.EXPECT().RevokeSession(gomock.Any(), sessionID).Times(1).Return(nil)
+		mockStore.EXPECT().RotateSessionToken(gomock.Any(), gomock.Any()).Times(0)
+
+		c.Request = newRenewRequest(refreshToken)
+		server.RenewAccessToken(c)
+
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	})
+
+	t.Run("RevokedSession", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := setupGinTest()
+
+		revokedSession := activeSession
+		revokedSession.IsRevoked = true
+
+		mockStore.EXPECT().GetSession(gomock.Any(), sessionID).Times(1).Return(revokedSession, nil)
+		mockStore.EXPECT().RotateSessionToken(gomock.Any(), gomock.Any()).Times(0)
+
+		c.Request = newRenewRequest(refreshToken)
+		server.RenewAccessToken(c)
+
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	})
+
+	t.Run("MalformedToken", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := setupGinTest()
+
+		c.Request = newRenewRequest("not-a-refresh-token")
+		server.RenewAccessToken(c)
+
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	})
+}
+
+func TestUpdatePostAPI(t *testing.T) {
+	publishedAt := pgtype.Timestamptz{Time: time.Now().Add(-time.Hour), Valid: true}
+	post := sqlc.GetPostByIDRow{ID: 7, UserID: 10, Title: "Old title", Content: "Old content", Status: PostStatusPublished, PublishedAt: publishedAt, Slug: "old-title", Format: render.FormatPlain}
+
+	newUpdateContext := func(userID int32, role string) (*gin.Context, *httptest.ResponseRecorder) {
+		c, recorder := setupGinTest()
+		body, _ := json.Marshal(UpdatePostRequest{Title: "New title", Content: "New content"})
+		c.Request = httptest.NewRequest(http.MethodPut, "/posts/7", bytes.NewReader(body))
+		c.Params = gin.Params{{Key: "id", Value: "7"}}
+		c.Set(AuthorizationPayloadKey, &auth.Payload{ID: userID, Username: "testuser", Role: role})
+		return c, recorder
+	}
+
+	testCases := []struct {
+		name         string
+		userID       int32
+		role         string
+		expectUpdate bool
+		expectedCode int
+	}{
+		{name: "OwnerCanUpdate", userID: 10, role: auth.RoleAuthor, expectUpdate: true, expectedCode: http.StatusOK},
+		{name: "AuthorCannotUpdateOthers", userID: 11, role: auth.RoleAuthor, expectedCode: http.StatusForbidden},
+		{name: "EditorCanUpdateAny", userID: 11, role: auth.RoleEditor, expectUpdate: true, expectedCode: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mock_sqlc.NewMockQuerier(ctrl)
+			server := setupTestServer(t, mockStore)
+			c, recorder := newUpdateContext(tc.userID, tc.role)
+
+			getCalls := 1
+			updateCalls := 0
+			if tc.expectUpdate {
+				getCalls, updateCalls = 2, 1
+			}
+			mockStore.EXPECT().GetPostByID(gomock.Any(), post.ID).Times(getCalls).Return(post, nil)
+			mockStore.EXPECT().
+				ListTakenPostSlugs(gomock.Any(), sqlc.ListTakenPostSlugsParams{Slug: "new-title", PostID: post.ID}).
+				Times(updateCalls).
+				Return([]string{"new-title"}, nil)
+			mockStore.EXPECT().
+				UpdatePost(gomock.Any(), sqlc.UpdatePostParams{ID: post.ID, Title: "New title", Content: "New content", Status: PostStatusPublished, PublishedAt: publishedAt, Slug: "new-title-2",
+					Format:       render.FormatPlain,
+					ContentHtml:  pgtype.Text{String: "<p>New content</p>\n", Valid: true},
+					RenderedWith: pgtype.Text{String: server.renderer.Fingerprint(), Valid: true},
+					EditorID:     tc.userID,
+				}).
+				Times(updateCalls).
+				Return(sqlc.Post{ID: post.ID, UserID: post.UserID}, nil)
+
+			server.UpdatePost(c)
+
+			require.Equal(t, tc.expectedCode, recorder.Code)
+		})
+	}
+
+	t.Run("NotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newUpdateContext(10, auth.RoleAdmin)
+
+		mockStore.EXPECT().GetPostByID(gomock.Any(), post.ID).Times(1).Return(sqlc.GetPostByIDRow{}, sql.ErrNoRows)
+		mockStore.EXPECT().UpdatePost(gomock.Any(), gomock.Any()).Times(0)
+
+		server.UpdatePost(c)
+
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+}
+
+func TestResolvePublication(t *testing.T) {
+	past := pgtype.Timestamptz{Time: time.Now().Add(-time.Hour), Valid: true}
+	future := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		name               string
+		status             string
+		publishedAt        *time.Time
+		currentStatus      string
+		currentPublishedAt pgtype.Timestamptz
+		expectedStatus     string
+		expectPublishedAt  bool
+		expectErr          bool
+	}{
+		{name: "PublishDraft", status: PostStatusPublished, currentStatus: PostStatusDraft, expectedStatus: PostStatusPublished, expectPublishedAt: true},
+		{name: "KeepStatus", currentStatus: PostStatusDraft, expectedStatus: PostStatusDraft},
+		{name: "Unpublish", status: PostStatusDraft, currentStatus: PostStatusPublished, currentPublishedAt: past, expectedStatus: PostStatusDraft},
+		{name: "Schedule", status: PostStatusScheduled, publishedAt: &future, currentStatus: PostStatusDraft, expectedStatus: PostStatusScheduled, expectPublishedAt: true},
+		{name: "ScheduleWithoutTime", status: PostStatusScheduled, currentStatus: PostStatusDraft, expectErr: true},
+		{name: "ScheduleInThePast", status: PostStatusScheduled, publishedAt: &past.Time, currentStatus: PostStatusDraft, expectErr: true},
+		{name: "PublishedAtWithoutSchedule", status: PostStatusPublished, publishedAt: &future, currentStatus: PostStatusDraft, expectErr: true},
+		{name: "Archive", status: PostStatusArchived, currentStatus: PostStatusPublished, currentPublishedAt: past, expectedStatus: PostStatusArchived, expectPublishedAt: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, publishedAt, err := resolvePublication(tc.status, tc.publishedAt, tc.currentStatus, tc.currentPublishedAt)
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStatus, status)
+			require.Equal(t, tc.expectPublishedAt, publishedAt.Valid)
+		})
+	}
+
+	t.Run("RepublishKeepsDate", func(t *testing.T) {
+		_, publishedAt, err := resolvePublication(PostStatusPublished, nil, PostStatusArchived, past)
+		require.NoError(t, err)
+		require.Equal(t, past, publishedAt)
+	})
+}
+
+func TestGetPostHidesUnpublished(t *testing.T) {
+	for _, status := range []string{PostStatusDraft, PostStatusScheduled, PostStatusArchived} {
+		t.Run(status, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mock_sqlc.NewMockQuerier(ctrl)
+			server := setupTestServer(t, mockStore)
+			c, recorder := setupGinTest()
+			c.Params = gin.Params{{Key: "id", Value: "7"}}
+
+			mockStore.EXPECT().GetPostByID(gomock.Any(), int32(7)).Times(1).Return(sqlc.GetPostByIDRow{ID: 7, UserID: 10, Status: status}, nil)
+
+			server.GetPost(c)
+
+			require.Equal(t, http.StatusNotFound, recorder.Code)
+		})
+	}
+}
+
+func TestListMyPostsAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock_sqlc.NewMockQuerier(ctrl)
+	server := setupTestServer(t, mockStore)
+	c, recorder := setupGinTest()
+	c.Request = httptest.NewRequest(http.MethodGet, "/my-posts?status=draft", nil)
+	c.Set(AuthorizationPayloadKey, &auth.Payload{ID: 10, Username: "testuser", Role: auth.RoleAuthor})
+
+	mockStore.EXPECT().
+		ListPostsByAuthor(gomock.Any(), sqlc.ListPostsByAuthorParams{
+			UserID: 10,
+			Status: pgtype.Text{String: PostStatusDraft, Valid: true},
+			Limit:  10,
+			Offset: 0,
+		}).
+		Times(1).
+		Return([]sqlc.ListPostsByAuthorRow{{ID: 7, UserID: 10, Title: "Draft", Status: PostStatusDraft}}, nil)
+
+	server.ListMyPosts(c)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var posts []sqlc.ListPostsByAuthorRow
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &posts))
+	require.Len(t, posts, 1)
+	require.Equal(t, PostStatusDraft, posts[0].Status)
+}
+
+func TestCreateScheduledPost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock_sqlc.NewMockQuerier(ctrl)
+	server := setupTestServer(t, mockStore)
+	c, recorder := setupGinTest()
+	c.Set(UserIDKey, int32(10))
+
+	publishAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	mockStore.EXPECT().ListTakenPostSlugs(gomock.Any(), sqlc.ListTakenPostSlugsParams{Slug: "hello"}).Times(1).Return(nil, nil)
+	mockStore.EXPECT().
+		CreatePost(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg sqlc.CreatePostParams) (sqlc.Post, error) {
+			require.Equal(t, "hello", arg.Slug)
+			require.Equal(t, PostStatusScheduled, arg.Status)
+			require.True(t, publishAt.Equal(arg.PublishedAt.Time))
+			require.Equal(t, render.FormatMarkdown, arg.Format)
+			require.Equal(t, "<p><em>World</em></p>\n", arg.ContentHtml.String)
+			require.Equal(t, server.renderer.Fingerprint(), arg.RenderedWith.String)
+			return sqlc.Post{ID: 7, UserID: arg.UserID, Status: arg.Status, PublishedAt: arg.PublishedAt}, nil
+		})
+	mockStore.EXPECT().GetPostByID(gomock.Any(), int32(7)).Times(1).Return(sqlc.GetPostByIDRow{ID: 7, UserID: 10, Status: PostStatusScheduled}, nil)
+
+	body, _ := json.Marshal(CreatePostRequest{Title: "Hello", Content: "*World*", Status: PostStatusScheduled, PublishedAt: &publishAt})
+	c.Request = httptest.NewRequest(http.MethodPost, "/posts", bytes.NewReader(body))
+	server.CreatePost(c)
+
+	require.Equal(t, http.StatusCreated, recorder.Code)
+}
+
+func TestRenderStalePosts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock_sqlc.NewMockQuerier(ctrl)
+	server := setupTestServer(t, mockStore)
+	fingerprint := pgtype.Text{String: server.renderer.Fingerprint(), Valid: true}
+	updatedAt := pgtype.Timestamptz{Time: time.Now(), Valid: true}
+
+	batch := make([]sqlc.ListPostsToRenderRow, renderBatchSize)
+	for i := range batch {
+		batch[i] = sqlc.ListPostsToRenderRow{ID: int32(i + 1), Format: render.FormatHTML, Content: "<b>hi</b><script>x()</script>", UpdatedAt: updatedAt}
+	}
+	gomock.InOrder(
+		mockStore.EXPECT().
+			ListPostsToRender(gomock.Any(), sqlc.ListPostsToRenderParams{RenderedWith: fingerprint, AfterID: 0, Limit: renderBatchSize}).
+			Return(batch, nil),
+		mockStore.EXPECT().
+			ListPostsToRender(gomock.Any(), sqlc.ListPostsToRenderParams{RenderedWith: fingerprint, AfterID: renderBatchSize, Limit: renderBatchSize}).
+			Return([]sqlc.ListPostsToRenderRow{{ID: 200, Format: render.FormatPlain, Content: "a < b", UpdatedAt: updatedAt}}, nil),
+	)
+	mockStore.EXPECT().
+		UpdatePostContentHTML(gomock.Any(), sqlc.UpdatePostContentHTMLParams{ID: 1, ContentHtml: pgtype.Text{String: "<b>hi</b>", Valid: true}, RenderedWith: fingerprint, UpdatedAt: updatedAt}).
+		Times(1)
+	mockStore.EXPECT().
+		UpdatePostContentHTML(gomock.Any(), sqlc.UpdatePostContentHTMLParams{ID: 200, ContentHtml: pgtype.Text{String: "<p>a &lt; b</p>\n", Valid: true}, RenderedWith: fingerprint, UpdatedAt: updatedAt}).
+		Times(1)
+	mockStore.EXPECT().UpdatePostContentHTML(gomock.Any(), gomock.Any()).Times(renderBatchSize - 1)
+
+	server.renderStalePosts(context.Background())
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := normalizeTags([]string{"  Web   Dev ", "Go", "web-dev", "Tiếng Việt"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"web-dev", "go", "tieng-viet"}, tags.slugs)
+	require.Equal(t, []string{"Web Dev", "Go", "Tiếng Việt"}, tags.names)
+
+	tags, err = normalizeTags(nil)
+	require.NoError(t, err)
+	require.Empty(t, tags.slugs)
+
+	_, err = normalizeTags([]string{"go", "!!!"})
+	require.Error(t, err)
+}
+
+func TestPostTagsAPI(t *testing.T) {
+	post := sqlc.GetPostByIDRow{ID: 7, UserID: 10, Title: "Title", Content: "Content", Status: PostStatusDraft, Slug: "title", Format: render.FormatPlain}
+
+	newUpdateContext := func(body string) (*gin.Context, *httptest.ResponseRecorder) {
+		c, recorder := setupGinTest()
+		c.Request = httptest.NewRequest(http.MethodPut, "/posts/7", strings.NewReader(body))
+		c.Params = gin.Params{{Key: "id", Value: "7"}}
+		c.Set(AuthorizationPayloadKey, &auth.Payload{ID: 10, Username: "testuser", Role: auth.RoleAuthor})
+		return c, recorder
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := setupGinTest()
+		c.Set(UserIDKey, int32(10))
+
+		mockStore.EXPECT().ListTakenPostSlugs(gomock.Any(), gomock.Any()).Times(1).Return(nil, nil)
+		mockStore.EXPECT().
+			CreatePost(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg sqlc.CreatePostParams) (sqlc.Post, error) {
+				require.Equal(t, []string{"go", "sql"}, arg.TagSlugs)
+				require.Equal(t, []string{"Go", "SQL"}, arg.TagNames)
+				return sqlc.Post{ID: 7, UserID: 10}, nil
+			})
+		mockStore.EXPECT().GetPostByID(gomock.Any(), int32(7)).Times(1).Return(sqlc.GetPostByIDRow{ID: 7, Tags: []string{"go", "sql"}}, nil)
+
+		c.Request = httptest.NewRequest(http.MethodPost, "/posts", strings.NewReader(`{"title":"Hello","content":"World","tags":["Go","SQL","go"]}`))
+		server.CreatePost(c)
+
+		require.Equal(t, http.StatusCreated, recorder.Code)
+		var rsp sqlc.GetPostByIDRow
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		require.Equal(t, []string{"go", "sql"}, rsp.Tags)
+	})
+
+	t.Run("UpdateKeepsTags", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newUpdateContext(`{"title":"Title","content":"New content"}`)
+
+		mockStore.EXPECT().GetPostByID(gomock.Any(), post.ID).Times(2).Return(post, nil)
+		mockStore.EXPECT().
+			UpdatePost(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg sqlc.UpdatePostParams) (sqlc.Post, error) {
+				require.False(t, arg.SetTags)
+				return sqlc.Post{ID: post.ID}, nil
+			})
+
+		server.UpdatePost(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("UpdateClearsTags", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newUpdateContext(`{"title":"Title","content":"New content","tags":[]}`)
+
+		mockStore.EXPECT().GetPostByID(gomock.Any(), post.ID).Times(2).Return(post, nil)
+		mockStore.EXPECT().
+			UpdatePost(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg sqlc.UpdatePostParams) (sqlc.Post, error) {
+				require.True(t, arg.SetTags)
+				require.Empty(t, arg.TagSlugs)
+				return sqlc.Post{ID: post.ID}, nil
+			})
+
+		server.UpdatePost(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("InvalidTag", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newUpdateContext(`{"title":"Title","content":"New content","tags":["---"]}`)
+
+		mockStore.EXPECT().GetPostByID(gomock.Any(), post.ID).Times(1).Return(post, nil)
+		mockStore.EXPECT().UpdatePost(gomock.Any(), gomock.Any()).Times(0)
+
+		server.UpdatePost(c)
+
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("ListTags", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := setupGinTest()
+
+		mockStore.EXPECT().
+			ListTags(gomock.Any(), sqlc.ListTagsParams{Limit: 50, Offset: 0}).
+			Times(1).
+			Return([]sqlc.ListTagsRow{{Slug: "go", Name: "Go", PostCount: 3}}, nil)
+
+		c.Request = httptest.NewRequest(http.MethodGet, "/tags", nil)
+		server.ListTags(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.JSONEq(t, `[{"slug":"go","name":"Go","post_count":3}]`, recorder.Body.String())
+	})
+}
+
+func TestHighlight(t *testing.T) {
+	require.Equal(t, "<mark>Go</mark> &amp; <mark>SQL</mark> &lt;b&gt;", highlight("Go & SQL <b>"))
+	require.Equal(t, "no matches", highlight("no matches"))
+}
+
+func TestSearchPostsAPI(t *testing.T) {
+	publishedAt := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mock_sqlc.MockQuerier)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "q=go+sql&limit=5&offset=10",
+			buildStubs: func(store *mock_sqlc.MockQuerier) {
+				store.EXPECT().
+					SearchPosts(gomock.Any(), sqlc.SearchPostsParams{Config: "english", Query: "go sql", Limit: 5, Offset: 10}).
+					Times(1).
+					Return([]sqlc.SearchPostsRow{{
+						ID:             3,
+						Title:          "Go <3 SQL",
+						Slug:           "go-3-sql",
+						PublishedAt:    pgtype.Timestamptz{Time: publishedAt, Valid: true},
+						AuthorUsername: "author",
+						Rank:           0.5,
+						TitleHeadline:  "Go <3 SQL",
+						Snippet:        "Using SQL from Go",
+					}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var rsp []SearchResult
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, []SearchResult{{
+					ID:             3,
+					Title:          "Go <3 SQL",
+					Slug:           "go-3-sql",
+					AuthorUsername: "author",
+					PublishedAt:    publishedAt,
+					Rank:           0.5,
+					TitleHighlight: "<mark>Go</mark> &lt;3 <mark>SQL</mark>",
+					Snippet:        "Using <mark>SQL</mark> from <mark>Go</mark>",
+				}}, rsp)
+			},
+		},
+		{
+			name:  "NoResults",
+			query: "q=nothing",
+			buildStubs: func(store *mock_sqlc.MockQuerier) {
+				store.EXPECT().
+					SearchPosts(gomock.Any(), sqlc.SearchPostsParams{Config: "english", Query: "nothing", Limit: 10, Offset: 0}).
+					Times(1).
+					Return([]sqlc.SearchPostsRow{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, "[]", recorder.Body.String())
+			},
+		},
+		{
+			name:  "MissingQuery",
+			query: "limit=5",
+			buildStubs: func(store *mock_sqlc.MockQuerier) {
+				store.EXPECT().SearchPosts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "BlankQuery",
+			query: "q=++",
+			buildStubs: func(store *mock_sqlc.MockQuerier) {
+				store.EXPECT().SearchPosts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: "q=go",
+			buildStubs: func(store *mock_sqlc.MockQuerier) {
+				store.EXPECT().SearchPosts(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mock_sqlc.NewMockQuerier(ctrl)
+			tc.buildStubs(mockStore)
+			server := setupTestServer(t, mockStore)
+			server.config.SearchConfig = "english"
+			c, recorder := setupGinTest()
+
+			c.Request = httptest.NewRequest(http.MethodGet, "/search?"+tc.query, nil)
+			server.SearchPosts(c)
+
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestUpdateSearchConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock_sqlc.NewMockQuerier(ctrl)
+	server := setupTestServer(t, mockStore)
+	server.config.SearchConfig = "english"
+	mockStore.EXPECT().UpdateSearchConfig(gomock.Any(), "english").Times(1).Return(int64(3), nil)
+
+	server.updateSearchConfig(context.Background())
+}
+
+func TestPostRevisionsAPI(t *testing.T) {
+	publishedAt := pgtype.Timestamptz{Time: time.Now().Add(-time.Hour), Valid: true}
+	post := sqlc.GetPostByIDRow{ID: 7, UserID: 10, Title: "Current title", Content: "one\ntwo\nthree\n", Status: PostStatusPublished,
+		PublishedAt: publishedAt, Slug: "current-title", Format: render.FormatMarkdown, Revision: 3}
+	first := sqlc.GetPostRevisionRow{PostID: 7, Revision: 1, Title: "First title", Content: "one\n2\nthree\n", Format: render.FormatPlain}
+	current := sqlc.GetPostRevisionRow{PostID: 7, Revision: 3, Title: post.Title, Content: post.Content, Format: post.Format}
+
+	newRevisionContext := func(method, target string, userID int32, role string, params gin.Params) (*gin.Context, *httptest.ResponseRecorder) {
+		c, recorder := setupGinTest()
+		c.Request = httptest.NewRequest(method, target, nil)
+		c.Params = append(gin.Params{{Key: "id", Value: "7"}}, params...)
+		c.Set(AuthorizationPayloadKey, &auth.Payload{ID: userID, Username: "testuser", Role: role})
+		return c, recorder
+	}
+
+	t.Run("List", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newRevisionContext(http.MethodGet, "/posts/7/revisions", 10, auth.RoleAuthor, nil)
+
+		mockStore.EXPECT().GetPostByID(gomock.Any(), post.ID).Times(1).Return(post, nil)
+		mockStore.EXPECT().
+			ListPostRevisions(gomock.Any(), sqlc.ListPostRevisionsParams{PostID: post.ID, Limit: 20, Offset: 0}).
+			Times(1).
+			Return([]sqlc.ListPostRevisionsRow{{Revision: 3}, {Revision: 2}, {Revision: 1}}, nil)
+
+		server.ListPostRevisions(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var revisions []sqlc.ListPostRevisionsRow
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &revisions))
+		require.Len(t, revisions, 3)
+	})
+
+	t.Run("OtherAuthorForbidden", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newRevisionContext(http.MethodGet, "/posts/7/revisions", 11, auth.RoleAuthor, nil)
+
+		mockStore.EXPECT().GetPostByID(gomock.Any(), post.ID).Times(1).Return(post, nil)
+		mockStore.EXPECT().ListPostRevisions(gomock.Any(), gomock.Any()).Times(0)
+
+		server.ListPostRevisions(c)
+
+		require.Equal(t, http.StatusForbidden, recorder.Code)
+	})
+
+	t.Run("DiffAgainstCurrent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newRevisionContext(http.MethodGet, "/posts/7/revisions/diff?from=1", 10, auth.RoleAuthor, nil)
+
+		mockStore.EXPECT().GetPostByID(gomock.Any(), post.ID).Times(1).Return(post, nil)
+		mockStore.EXPECT().GetPostRevision(gomock.Any(), sqlc.GetPostRevisionParams{PostID: 7, Revision: 1}).Times(1).Return(first, nil)
+		mockStore.EXPECT().GetPostRevision(gomock.Any(), sqlc.GetPostRevisionParams{PostID: 7, Revision: 3}).Times(1).Return(current, nil)
+
+		server.DiffPostRevisions(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var rsp PostRevisionDiff
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		require.Equal(t, int32(1), rsp.From)
+		require.Equal(t, int32(3), rsp.To)
+		require.Equal(t, DiffModeLine, rsp.Mode)
+		require.Equal(t, []diff.Chunk{
+			{Op: diff.OpEqual, Text: "one\n"},
+			{Op: diff.OpDelete, Text: "2\n"},
+			{Op: diff.OpInsert, Text: "two\n"},
+			{Op: diff.OpEqual, Text: "three\n"},
+		}, rsp.Content)
+		require.Equal(t, []diff.Chunk{
+			{Op: diff.OpDelete, Text: "First"},
+			{Op: diff.OpInsert, Text: "Current"},
+			{Op: diff.OpEqual, Text: " title"},
+		}, rsp.Title)
+	})
+
+	t.Run("DiffUnknownRevision", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newRevisionContext(http.MethodGet, "/posts/7/revisions/diff?from=9&to=3&mode=word", 10, auth.RoleAuthor, nil)
+
+		mockStore.EXPECT().GetPostByID(gomock.Any(), post.ID).Times(1).Return(post, nil)
+		mockStore.EXPECT().GetPostRevision(gomock.Any(), sqlc.GetPostRevisionParams{PostID: 7, Revision: 9}).Times(1).Return(sqlc.GetPostRevisionRow{}, sql.ErrNoRows)
+
+		server.DiffPostRevisions(c)
+
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newRevisionContext(http.MethodPost, "/posts/7/revisions/1/restore", 12, auth.RoleEditor, gin.Params{{Key: "rev", Value: "1"}})
+
+		mockStore.EXPECT().GetPostByID(gomock.Any(), post.ID).Times(2).Return(post, nil)
+		mockStore.EXPECT().GetPostRevision(gomock.Any(), sqlc.GetPostRevisionParams{PostID: 7, Revision: 1}).Times(1).Return(first, nil)
+		mockStore.EXPECT().
+			ListTakenPostSlugs(gomock.Any(), sqlc.ListTakenPostSlugsParams{Slug: "first-title", PostID: post.ID}).
+			Times(1).
+			Return(nil, nil)
+		mockStore.EXPECT().
+			UpdatePost(gomock.Any(), sqlc.UpdatePostParams{ID: post.ID, Title: first.Title, Content: first.Content, Status: PostStatusPublished, PublishedAt: publishedAt,
+				Slug:         "first-title",
+				Format:       render.FormatPlain,
+				ContentHtml:  pgtype.Text{String: "<p>one<br>\n2<br>\nthree</p>\n", Valid: true},
+				RenderedWith: pgtype.Text{String: server.renderer.Fingerprint(), Valid: true},
+				EditorID:     12,
+			}).
+			Times(1).
+			Return(sqlc.Post{ID: post.ID, UserID: post.UserID, Revision: 4}, nil)
+
+		server.RestorePostRevision(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("InvalidRevision", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newRevisionContext(http.MethodPost, "/posts/7/revisions/0/restore", 10, auth.RoleAuthor, gin.Params{{Key: "rev", Value: "0"}})
+
+		mockStore.EXPECT().GetPostByID(gomock.Any(), gomock.Any()).Times(0)
+
+		server.RestorePostRevision(c)
+
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
+
+func TestCommentTree(t *testing.T) {
+	parent := func(id int32) pgtype.Int4 { return pgtype.Int4{Int32: id, Valid: true} }
+	rows := []sqlc.ListCommentsRow{
+		{ID: 1},
+		{ID: 3, ParentID: parent(1), Depth: 1},
+		{ID: 5, ParentID: parent(3), Depth: 2},
+		{ID: 4, ParentID: parent(1), Depth: 1},
+		{ID: 2},
+	}
+
+	roots := commentTree(rows)
+	require.Len(t, roots, 2)
+	require.Equal(t, int32(1), roots[0].ID)
+	require.Equal(t, int32(2), roots[1].ID)
+	require.Empty(t, roots[1].Replies)
+	require.Len(t, roots[0].Replies, 2)
+	require.Equal(t, int32(3), roots[0].Replies[0].ID)
+	require.Equal(t, int32(4), roots[0].Replies[1].ID)
+	require.Equal(t, int32(5), roots[0].Replies[0].Replies[0].ID)
+}
+
+func TestCommentsAPI(t *testing.T) {
+	post := sqlc.GetPostByIDRow{ID: 7, UserID: 10, Title: "Title", Status: PostStatusPublished, Slug: "title"}
+	lockedPost := post
+	lockedPost.CommentsLocked = true
+	draft := post
+	draft.Status = PostStatusDraft
+	comment := sqlc.Comment{ID: 21, PostID: 7, UserID: pgtype.Int4{Int32: 12, Valid: true}, Content: "Nice post"}
+	deleted := sqlc.Comment{ID: 22, PostID: 7, DeletedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true}}
+	otherPostComment := sqlc.Comment{ID: 23, PostID: 8, UserID: pgtype.Int4{Int32: 12, Valid: true}, Content: "Elsewhere"}
+
+	newCommentContext := func(method, target, body string, userID int32, role string, params gin.Params) (*gin.Context, *httptest.ResponseRecorder) {
+		c, recorder := setupGinTest()
+		c.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+		c.Params = append(gin.Params{{Key: "id", Value: "7"}}, params...)
+		if userID != 0 {
+			c.Set(AuthorizationPayloadKey, &auth.Payload{ID: userID, Username: "testuser", Role: role})
+		}
+		return c, recorder
+	}
+	commentParam := func(id int32) gin.Params {
+		return gin.Params{{Key: "comment_id", Value: strconv.Itoa(int(id))}}
+	}
+
+	t.Run("ListTree", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newCommentContext(http.MethodGet, "/posts/7/comments", "", 0, "", nil)
+
+		mockStore.EXPECT().GetPostByID(gomock.Any(), post.ID).Times(1).Return(post, nil)
+		mockStore.EXPECT().
+			ListComments(gomock.Any(), sqlc.ListCommentsParams{PostID: post.ID, Limit: 20, Offset: 0}).
+			Times(1).
+			Return([]sqlc.ListCommentsRow{{ID: 1}, {ID: 2, ParentID: pgtype.Int4{Int32: 1, Valid: true}, Depth: 1}}, nil)
+
+		server.ListComments(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var rsp []struct {
+			ID      int32 `json:"id"`
+			Replies []struct {
+				ID    int32 `json:"id"`
+				Depth int32 `json:"depth"`
+			} `json:"replies"`
+		}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		require.Len(t, rsp, 1)
+		require.Len(t, rsp[0].Replies, 1)
+		require.Equal(t, int32(2), rsp[0].Replies[0].ID)
+		require.Equal(t, int32(1), rsp[0].Replies[0].Depth)
+	})
+
+	t.Run("ListFlat", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newCommentContext(http.MethodGet, "/posts/7/comments?view=flat&limit=5", "", 0, "", nil)
+
+		mockStore.EXPECT().GetPostByID(gomock.Any(), post.ID).Times(1).Return(post, nil)
+		mockStore.EXPECT().
+			ListComments(gomock.Any(), sqlc.ListCommentsParams{PostID: post.ID, Limit: 5, Offset: 0}).
+			Times(1).
+			Return([]sqlc.ListCommentsRow{{ID: 1}, {ID: 2, ParentID: pgtype.Int4{Int32: 1, Valid: true}, Depth: 1}}, nil)
+
+		server.ListComments(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var rsp []sqlc.ListCommentsRow
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		require.Len(t, rsp, 2)
+		require.NotContains(t, recorder.Body.String(), "replies")
+	})
+
+	t.Run("ListDraftNotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newCommentContext(http.MethodGet, "/posts/7/comments", "", 0, "", nil)
+
+		mockStore.EXPECT().GetPostByID(gomock.Any(), post.ID).Times(1).Return(draft, nil)
+		mockStore.EXPECT().ListComments(gomock.Any(), gomock.Any()).Times(0)
+
+		server.ListComments(c)
+
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	createCases := []struct {
+		name       string
+		body       string
+		post       sqlc.GetPostByIDRow
+		buildStubs func(store *mock_sqlc.MockQuerier)
+		wantStatus int
+	}{
+		{
+			name: "OK",
+			body: `{"content":"  Great read  "}`,
+			post: post,
+			buildStubs: func(store *mock_sqlc.MockQuerier) {
+				store.EXPECT().
+					CreateComment(gomock.Any(), sqlc.CreateCommentParams{PostID: 7, UserID: pgtype.Int4{Int32: 12, Valid: true}, Content: "Great read"}).
+					Times(1).
+					Return(sqlc.Comment{ID: 30, PostID: 7}, nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "Reply",
+			body: `{"content":"Agreed","parent_id":21}`,
+			post: post,
+			buildStubs: func(store *mock_sqlc.MockQuerier) {
+				store.EXPECT().GetComment(gomock.Any(), comment.ID).Times(1).Return(comment, nil)
+				store.EXPECT().
+					CreateComment(gomock.Any(), sqlc.CreateCommentParams{PostID: 7, ParentID: pgtype.Int4{Int32: 21, Valid: true}, UserID: pgtype.Int4{Int32: 12, Valid: true}, Content: "Agreed"}).
+					Times(1).
+					Return(sqlc.Comment{ID: 31, PostID: 7}, nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "ParentOnOtherPost",
+			body: `{"content":"Agreed","parent_id":23}`,
+			post: post,
+			buildStubs: func(store *mock_sqlc.MockQuerier) {
+				store.EXPECT().GetComment(gomock.Any(), otherPostComment.ID).Times(1).Return(otherPostComment, nil)
+				store.EXPECT().CreateComment(gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "ParentDeleted",
+			body: `{"content":"Agreed","parent_id":22}`,
+			post: post,
+			buildStubs: func(store *mock_sqlc.MockQuerier) {
+				store.EXPECT().GetComment(gomock.Any(), deleted.ID).Times(1).Return(deleted, nil)
+				store.EXPECT().CreateComment(gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "ParentDeletedMeanwhile",
+			body: `{"content":"Agreed","parent_id":21}`,
+			post: post,
+			buildStubs: func(store *mock_sqlc.MockQuerier) {
+				store.EXPECT().GetComment(gomock.Any(), comment.ID).Times(1).Return(comment, nil)
+				store.EXPECT().CreateComment(gomock.Any(), gomock.Any()).Times(1).Return(sqlc.Comment{}, &pgconn.PgError{Code: pgForeignKeyViolation})
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "Locked",
+			body: `{"content":"Hello"}`,
+			post: lockedPost,
+			buildStubs: func(store *mock_sqlc.MockQuerier) {
+				store.EXPECT().CreateComment(gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Blank",
+			body: `{"content":"   "}`,
+			post: post,
+			buildStubs: func(store *mock_sqlc.MockQuerier) {
+				store.EXPECT().CreateComment(gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range createCases {
+		t.Run("Create"+tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mock_sqlc.NewMockQuerier(ctrl)
+			server := setupTestServer(t, mockStore)
+			c, recorder := newCommentContext(http.MethodPost, "/posts/7/comments", tc.body, 12, auth.RoleReader, nil)
+
+			mockStore.EXPECT().GetPostByID(gomock.Any(), post.ID).AnyTimes().Return(tc.post, nil)
+			tc.buildStubs(mockStore)
+
+			server.CreateComment(c)
+
+			require.Equal(t, tc.wantStatus, recorder.Code, recorder.Body.String())
+		})
+	}
+
+	t.Run("UpdateOwn", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newCommentContext(http.MethodPut, "/posts/7/comments/21", `{"content":"Edited"}`, 12, auth.RoleReader, commentParam(comment.ID))
+
+		mockStore.EXPECT().GetPostByID(gomock.Any(), post.ID).Times(1).Return(post, nil)
+		mockStore.EXPECT().GetComment(gomock.Any(), comment.ID).Times(1).Return(comment, nil)
+		mockStore.EXPECT().
+			UpdateComment(gomock.Any(), sqlc.UpdateCommentParams{ID: comment.ID, Content: "Edited"}).
+			Times(1).
+			Return(sqlc.Comment{ID: comment.ID, Content: "Edited"}, nil)
+
+		server.UpdateComment(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("UpdateOthersForbidden", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		// Not even the post's author or an admin can edit someone else's words.
+		c, recorder := newCommentContext(http.MethodPut, "/posts/7/comments/21", `{"content":"Edited"}`, 10, auth.RoleAdmin, commentParam(comment.ID))
+
+		mockStore.EXPECT().GetPostByID(gomock.Any(), post.ID).Times(1).Return(post, nil)
+		mockStore.EXPECT().GetComment(gomock.Any(), comment.ID).Times(1).Return(comment, nil)
+		mockStore.EXPECT().UpdateComment(gomock.Any(), gomock.Any()).Times(0)
+
+		server.UpdateComment(c)
+
+		require.Equal(t, http.StatusForbidden, recorder.Code)
+	})
+
+	deleteCases := []struct {
+		name       string
+		userID     int32
+		role       string
+		comment    sqlc.Comment
+		wantStatus int
+	}{
+		{name: "Own", userID: 12, role: auth.RoleReader, comment: comment, wantStatus: http.StatusNoContent},
+		{name: "PostAuthor", userID: 10, role: auth.RoleAuthor, comment: comment, wantStatus: http.StatusNoContent},
+		{name: "Editor", userID: 13, role: auth.RoleEditor, comment: comment, wantStatus: http.StatusNoContent},
+		{name: "Stranger", userID: 14, role: auth.RoleAuthor, comment: comment, wantStatus: http.StatusForbidden},
+		{name: "OtherPost", userID: 12, role: auth.RoleReader, comment: otherPostComment, wantStatus: http.StatusNotFound},
+	}
+	for _, tc := range deleteCases {
+		t.Run("Delete"+tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mock_sqlc.NewMockQuerier(ctrl)
+			server := setupTestServer(t, mockStore)
+			c, recorder := newCommentContext(http.MethodDelete, "/posts/7/comments", "", tc.userID, tc.role, commentParam(tc.comment.ID))
+
+			mockStore.EXPECT().GetPostByID(gomock.Any(), post.ID).Times(1).Return(post, nil)
+			mockStore.EXPECT().GetComment(gomock.Any(), tc.comment.ID).Times(1).Return(tc.comment, nil)
+			deletes := 0
+			if tc.wantStatus == http.StatusNoContent {
+				deletes = 1
+			}
+			mockStore.EXPECT().DeleteComment(gomock.Any(), tc.comment.ID).Times(deletes).Return(nil)
+
+			server.DeleteComment(c)
+
+			// c.Status leaves writing the header to gin's engine.
+			require.Equal(t, tc.wantStatus, c.Writer.Status(), recorder.Body.String())
+		})
+	}
+
+	lockCases := []struct {
+		name       string
+		userID     int32
+		role       string
+		lock       bool
+		wantStatus int
+	}{
+		{name: "LockOwnPost", userID: 10, role: auth.RoleAuthor, lock: true, wantStatus: http.StatusNoContent},
+		{name: "UnlockAsEditor", userID: 13, role: auth.RoleEditor, lock: false, wantStatus: http.StatusNoContent},
+		{name: "LockOthersPost", userID: 11, role: auth.RoleAuthor, lock: true, wantStatus: http.StatusForbidden},
+	}
+	for _, tc := range lockCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mock_sqlc.NewMockQuerier(ctrl)
+			server := setupTestServer(t, mockStore)
+			c, recorder := newCommentContext(http.MethodPut, "/posts/7/comments/lock", "", tc.userID, tc.role, nil)
+
+			mockStore.EXPECT().GetPostByID(gomock.Any(), post.ID).Times(1).Return(post, nil)
+			updates := 0
+			if tc.wantStatus == http.StatusNoContent {
+				updates = 1
+			}
+			mockStore.EXPECT().
+				SetPostCommentsLocked(gomock.Any(), sqlc.SetPostCommentsLockedParams{ID: post.ID, CommentsLocked: tc.lock}).
+				Times(updates).
+				Return(nil)
+
+			if tc.lock {
+				server.LockComments(c)
+			} else {
+				server.UnlockComments(c)
+			}
+
+			require.Equal(t, tc.wantStatus, c.Writer.Status(), recorder.Body.String())
+		})
+	}
+}
+
+func TestPostReactionsAPI(t *testing.T) {
+	post := sqlc.GetPostByIDRow{ID: 7, UserID: 10, Title: "Title", Status: PostStatusPublished, Slug: "title"}
+	draft := post
+	draft.Status = PostStatusDraft
+	reactions := sqlc.GetPostReactionsRow{Reactions: json.RawMessage(`{"like":2,"love":1}`), Mine: []string{ReactionLike}}
+
+	newReactionContext := func(method, target string, params gin.Params) (*gin.Context, *httptest.ResponseRecorder) {
+		c, recorder := setupGinTest()
+		c.Request = httptest.NewRequest(method, target, nil)
+		c.Params = append(gin.Params{{Key: "id", Value: "7"}}, params...)
+		c.Set(AuthorizationPayloadKey, &auth.Payload{ID: 12, Username: "reader", Role: auth.RoleReader})
+		return c, recorder
+	}
+	kindParam := func(kind string) gin.Params {
+		return gin.Params{{Key: "kind", Value: kind}}
+	}
+
+	testCases := []struct {
+		name       string
+		method     string
+		kind       string
+		post       sqlc.GetPostByIDRow
+		buildStubs func(store *mock_sqlc.MockQuerier)
+		wantStatus int
+	}{
+		{
+			name:   "Add",
+			method: http.MethodPut,
+			kind:   ReactionLike,
+			post:   post,
+			buildStubs: func(store *mock_sqlc.MockQuerier) {
+				store.EXPECT().
+					AddPostReaction(gomock.Any(), sqlc.AddPostReactionParams{PostID: 7, UserID: 12, Kind: ReactionLike}).
+					Times(1).
+					Return(nil)
+				store.EXPECT().
+					GetPostReactions(gomock.Any(), sqlc.GetPostReactionsParams{PostID: 7, UserID: 12}).
+					Times(1).
+					Return(reactions, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "Remove",
+			method: http.MethodDelete,
+			kind:   ReactionLove,
+			post:   post,
+			buildStubs: func(store *mock_sqlc.MockQuerier) {
+				store.EXPECT().
+					RemovePostReaction(gomock.Any(), sqlc.RemovePostReactionParams{PostID: 7, UserID: 12, Kind: ReactionLove}).
+					Times(1).
+					Return(nil)
+				store.EXPECT().GetPostReactions(gomock.Any(), gomock.Any()).Times(1).Return(reactions, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "UnknownKind",
+			method: http.MethodPut,
+			kind:   "angry",
+			post:   post,
+			buildStubs: func(store *mock_sqlc.MockQuerier) {
+				store.EXPECT().AddPostReaction(gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "DraftNotFound",
+			method: http.MethodPut,
+			kind:   ReactionLike,
+			post:   draft,
+			buildStubs: func(store *mock_sqlc.MockQuerier) {
+				store.EXPECT().AddPostReaction(gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mock_sqlc.NewMockQuerier(ctrl)
+			server := setupTestServer(t, mockStore)
+			c, recorder := newReactionContext(tc.method, "/posts/7/reactions/"+tc.kind, kindParam(tc.kind))
+
+			mockStore.EXPECT().GetPostByID(gomock.Any(), post.ID).AnyTimes().Return(tc.post, nil)
+			tc.buildStubs(mockStore)
+
+			if tc.method == http.MethodPut {
+				server.AddPostReaction(c)
+			} else {
+				server.RemovePostReaction(c)
+			}
+
+			require.Equal(t, tc.wantStatus, recorder.Code)
+			if tc.wantStatus == http.StatusOK {
+				require.JSONEq(t, `{"reactions":{"like":2,"love":1},"mine":["like"]}`, recorder.Body.String())
+			}
+		})
+	}
+
+	t.Run("CountsInPost", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := setupGinTest()
+		c.Request = httptest.NewRequest(http.MethodGet, "/posts/7", nil)
+		c.Params = gin.Params{{Key: "id", Value: "7"}}
+
+		withReactions := post
+		withReactions.Reactions = json.RawMessage(`{"insightful":4}`)
+		mockStore.EXPECT().GetPostByID(gomock.Any(), post.ID).Times(1).Return(withReactions, nil)
+
+		server.GetPost(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var rsp SwaggerPost
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		require.Equal(t, map[string]int64{ReactionInsightful: 4}, rsp.Reactions)
+	})
+
+	t.Run("ListLiked", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newReactionContext(http.MethodGet, "/my-reactions", nil)
+
+		mockStore.EXPECT().
+			ListReactedPosts(gomock.Any(), sqlc.ListReactedPostsParams{UserID: 12, Kind: ReactionLike, Limit: 10, Offset: 0}).
+			Times(1).
+			Return([]sqlc.ListReactedPostsRow{{ID: 7}}, nil)
+
+		server.ListReactedPosts(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("ListInvalidKind", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newReactionContext(http.MethodGet, "/my-reactions?kind=angry", nil)
+
+		mockStore.EXPECT().ListReactedPosts(gomock.Any(), gomock.Any()).Times(0)
+
+		server.ListReactedPosts(c)
+
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
+
+func TestGetPostBySlugAPI(t *testing.T) {
+	newSlugContext := func(postSlug string) (*gin.Context, *httptest.ResponseRecorder) {
+		c, recorder := setupGinTest()
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/posts/by-slug/"+postSlug, nil)
+		c.Params = gin.Params{{Key: "slug", Value: postSlug}}
+		return c, recorder
+	}
+
+	t.Run("Current", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newSlugContext("hello-world")
+
+		mockStore.EXPECT().GetPostBySlug(gomock.Any(), "hello-world").Times(1).
+			Return(sqlc.GetPostBySlugRow{ID: 7, Slug: "hello-world", Status: PostStatusPublished}, nil)
+		mockStore.EXPECT().GetRenamedPostSlug(gomock.Any(), gomock.Any()).Times(0)
+
+		server.GetPostBySlug(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var post sqlc.GetPostBySlugRow
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &post))
+		require.Equal(t, "hello-world", post.Slug)
+	})
+
+	t.Run("RenamedRedirects", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newSlugContext("old-title")
+
+		mockStore.EXPECT().GetPostBySlug(gomock.Any(), "old-title").Times(1).Return(sqlc.GetPostBySlugRow{}, sql.ErrNoRows)
+		mockStore.EXPECT().GetRenamedPostSlug(gomock.Any(), "old-title").Times(1).Return("new-title", nil)
+
+		server.GetPostBySlug(c)
+
+		require.Equal(t, http.StatusMovedPermanently, recorder.Code)
+		require.Equal(t, "/api/v1/posts/by-slug/new-title", recorder.Header().Get("Location"))
+	})
+
+	t.Run("Unpublished", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newSlugContext("draft")
+
+		mockStore.EXPECT().GetPostBySlug(gomock.Any(), "draft").Times(1).
+			Return(sqlc.GetPostBySlugRow{ID: 7, Slug: "draft", Status: PostStatusDraft}, nil)
+		mockStore.EXPECT().GetRenamedPostSlug(gomock.Any(), gomock.Any()).Times(0)
+
+		server.GetPostBySlug(c)
+
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newSlugContext("nope")
+
+		mockStore.EXPECT().GetPostBySlug(gomock.Any(), "nope").Times(1).Return(sqlc.GetPostBySlugRow{}, sql.ErrNoRows)
+		mockStore.EXPECT().GetRenamedPostSlug(gomock.Any(), "nope").Times(1).Return("", sql.ErrNoRows)
+
+		server.GetPostBySlug(c)
+
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+}
+
+func TestPublishDuePosts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock_sqlc.NewMockQuerier(ctrl)
+	server := setupTestServer(t, mockStore)
+
+	mockStore.EXPECT().PublishDuePosts(gomock.Any()).Times(1).Return([]sqlc.PublishDuePostsRow{{ID: 7, UserID: 10}}, nil)
+
+	server.publishDuePosts(context.Background())
+}
+
+func TestDeletePostAPI(t *testing.T) {
+	post := sqlc.GetPostByIDRow{ID: 7, UserID: 10, Title: "Title", Content: "Content"}
+
+	newDeleteContext := func(id string, userID int32, role string) (*gin.Context, *httptest.ResponseRecorder) {
+		c, recorder := setupGinTest()
+		c.Request = httptest.NewRequest(http.MethodDelete, "/posts/"+id, nil)
+		c.Params = gin.Params{{Key: "id", Value: id}}
+		c.Set(AuthorizationPayloadKey, &auth.Payload{ID: userID, Username: "testuser", Role: role})
+		return c, recorder
+	}
+
+	testCases := []struct {
+		name         string
+		userID       int32
+		role         string
+		getErr       error
+		deleteCalls  int
+		rowsAffected int64
+		expectedCode int
+	}{
+		{name: "OwnerCanDelete", userID: 10, role: auth.RoleAuthor, deleteCalls: 1, rowsAffected: 1, expectedCode: http.StatusOK},
+		{name: "AuthorCannotDeleteOthers", userID: 11, role: auth.RoleAuthor, expectedCode: http.StatusForbidden},
+		{name: "EditorCanDeleteAny", userID: 11, role: auth.RoleEditor, deleteCalls: 1, rowsAffected: 1, expectedCode: http.StatusOK},
+		{name: "NotFound", userID: 10, role: auth.RoleAdmin, getErr: sql.ErrNoRows, expectedCode: http.StatusNotFound},
+		{name: "DeletedConcurrently", userID: 10, role: auth.RoleAuthor, deleteCalls: 1, rowsAffected: 0, expectedCode: http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mock_sqlc.NewMockQuerier(ctrl)
+			server := setupTestServer(t, mockStore)
+			c, recorder := newDeleteContext("7", tc.userID, tc.role)
+
+			mockStore.EXPECT().GetPostByID(gomock.Any(), post.ID).Times(1).Return(post, tc.getErr)
+			mockStore.EXPECT().
+				DeletePost(gomock.Any(), sqlc.DeletePostParams{
+					ID:       post.ID,
+					UserID:   tc.userID,
+					AnyOwner: auth.HasPermission(tc.role, auth.PermissionDeleteAnyPost),
+				}).
+				Times(tc.deleteCalls).
+				Return(tc.rowsAffected, nil)
+
+			server.DeletePost(c)
+
+			require.Equal(t, tc.expectedCode, recorder.Code)
+			if tc.expectedCode == http.StatusOK {
+				var rsp DeletePostResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, tc.rowsAffected, rsp.RowsAffected)
+			}
+		})
+	}
+
+	t.Run("InvalidID", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newDeleteContext("abc", 10, auth.RoleAuthor)
+
+		mockStore.EXPECT().GetPostByID(gomock.Any(), gomock.Any()).Times(0)
+		mockStore.EXPECT().DeletePost(gomock.Any(), gomock.Any()).Times(0)
+
+		server.DeletePost(c)
+
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
+
+// chanSender hands sent emails to the test through a channel, because the
+// server sends them in the background.
+type chanSender chan mail.Message
+
+func (sender chanSender) Send(ctx context.Context, msg mail.Message) error {
+	sender <- msg
+	return nil
+}
+
+func TestPasswordResetAPI(t *testing.T) {
+	user := sqlc.User{
+		ID:              10,
+		Username:        "testuser",
+		Email:           pgtype.Text{String: "user@example.com", Valid: true},
+		EmailVerifiedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+
+	newJSONRequest := func(path string, body any) *http.Request {
+		data, _ := json.Marshal(body)
+		return httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+	}
+
+	t.Run("ForgotSendsResetLink", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		sent := make(chanSender, 1)
+		server.mailer = sent
+		c, recorder := setupGinTest()
+
+		var tokenHash string
+		mockStore.EXPECT().
+			GetUserByEmail(gomock.Any(), pgtype.Text{String: "user@example.com", Valid: true}).
+			Times(1).
+			Return(user, nil)
+		mockStore.EXPECT().
+			CreatePasswordResetToken(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg sqlc.CreatePasswordResetTokenParams) (sqlc.PasswordResetToken, error) {
+				require.Equal(t, user.ID, arg.UserID)
+				tokenHash = arg.TokenHash
+				return sqlc.PasswordResetToken{UserID: arg.UserID, TokenHash: arg.TokenHash}, nil
+			})
+
+		c.Request = newJSONRequest("/password/forgot", ForgotPasswordRequest{Email: "User@Example.com"})
+		server.ForgotPassword(c)
+
+		require.Equal(t, http.StatusAccepted, recorder.Code)
+
+		select {
+		case msg := <-sent:
+			require.Equal(t, "user@example.com", msg.To)
+			_, token, found := strings.Cut(msg.Body, "token=")
+			require.True(t, found)
+			token, _, _ = strings.Cut(token, "\n")
+			require.Equal(t, tokenHash, auth.HashToken(token))
+		case <-time.After(time.Second):
+			t.Fatal("reset email was not sent")
+		}
+	})
+
+	t.Run("ForgotUnknownEmail", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := setupGinTest()
+
+		mockStore.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(1).Return(sqlc.User{}, sql.ErrNoRows)
+		mockStore.EXPECT().CreatePasswordResetToken(gomock.Any(), gomock.Any()).Times(0)
+
+		c.Request = newJSONRequest("/password/forgot", ForgotPasswordRequest{Email: "nobody@example.com"})
+		server.ForgotPassword(c)
+
+		require.Equal(t, http.StatusAccepted, recorder.Code)
+	})
+
+	t.Run("ForgotUnverifiedEmail", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		sent := make(chanSender, 1)
+		server.mailer = sent
+		c, recorder := setupGinTest()
+
+		unverified := user
+		unverified.EmailVerifiedAt = pgtype.Timestamptz{}
+		mockStore.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(1).Return(unverified, nil)
+		mockStore.EXPECT().CreatePasswordResetToken(gomock.Any(), gomock.Any()).Times(0)
+
+		c.Request = newJSONRequest("/password/forgot", ForgotPasswordRequest{Email: "user@example.com"})
+		server.ForgotPassword(c)
+
+		require.Equal(t, http.StatusAccepted, recorder.Code)
+		select {
+		case <-sent:
+			t.Fatal("reset email was sent to an unverified address")
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("ResetSuccess", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, _ := setupGinTest()
+
+		mockStore.EXPECT().
+			ConsumePasswordResetToken(gomock.Any(), auth.HashToken("reset-token")).
+			Times(1).
+			Return(sqlc.PasswordResetToken{UserID: user.ID}, nil)
+		mockStore.EXPECT().
+			UpdateUserPassword(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg sqlc.UpdateUserPasswordParams) error {
+				require.Equal(t, user.ID, arg.ID)
+				match, _, err := server.hasher.Verify("new-secret", arg.PasswordHash)
+				require.NoError(t, err)
+				require.True(t, match)
+				return nil
+			})
+		mockStore.EXPECT().InvalidatePasswordResetTokens(gomock.Any(), user.ID).Times(1).Return(nil)
+		mockStore.EXPECT().RevokeUserSessions(gomock.Any(), user.ID).Times(1).Return(nil)
+		mockStore.EXPECT().RevokeUserPersonalAccessTokens(gomock.Any(), user.ID).Times(1).Return(int64(2), nil)
+
+		c.Request = newJSONRequest("/password/reset", ResetPasswordRequest{Token: "reset-token", NewPassword: "new-secret"})
+		server.ResetPassword(c)
+
+		require.Equal(t, http.StatusNoContent, c.Writer.Status())
+	})
+
+	t.Run("ResetUsedOrExpiredToken", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := setupGinTest()
+
+		mockStore.EXPECT().
+			ConsumePasswordResetToken(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(sqlc.PasswordResetToken{}, sql.ErrNoRows)
+		mockStore.EXPECT().UpdateUserPassword(gomock.Any(), gomock.Any()).Times(0)
+
+		c.Request = newJSONRequest("/password/reset", ResetPasswordRequest{Token: "reset-token", NewPassword: "new-secret"})
+		server.ResetPassword(c)
+
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
+
+func TestVerifyEmailAPI(t *testing.T) {
+	verification := sqlc.EmailVerificationToken{UserID: 10, Email: "user@example.com"}
+
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := setupGinTest()
+
+		mockStore.EXPECT().
+			ConsumeEmailVerificationToken(gomock.Any(), auth.HashToken("verify-token")).
+			Times(1).
+			Return(verification, nil)
+		mockStore.EXPECT().
+			MarkUserEmailVerified(gomock.Any(), sqlc.MarkUserEmailVerifiedParams{
+				ID:    verification.UserID,
+				Email: pgtype.Text{String: verification.Email, Valid: true},
+			}).
+			Times(1).
+			Return(sqlc.User{
+				ID:              verification.UserID,
+				Email:           pgtype.Text{String: verification.Email, Valid: true},
+				EmailVerifiedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+			}, nil)
+
+		c.Request = httptest.NewRequest(http.MethodPost, "/verify-email", strings.NewReader(`{"token":"verify-token"}`))
+		server.VerifyEmail(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var rsp UserResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		require.True(t, rsp.EmailVerified)
+	})
+
+	t.Run("EmailChangedSinceSent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := setupGinTest()
+
+		mockStore.EXPECT().ConsumeEmailVerificationToken(gomock.Any(), gomock.Any()).Times(1).Return(verification, nil)
+		mockStore.EXPECT().MarkUserEmailVerified(gomock.Any(), gomock.Any()).Times(1).Return(sqlc.User{}, sql.ErrNoRows)
+
+		c.Request = httptest.NewRequest(http.MethodPost, "/verify-email", strings.NewReader(`{"token":"verify-token"}`))
+		server.VerifyEmail(c)
+
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("MissingToken", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := setupGinTest()
+		mockStore.EXPECT().ConsumeEmailVerificationToken(gomock.Any(), gomock.Any()).Times(0)
+
+		c.Request = httptest.NewRequest(http.MethodPost, "/verify-email", strings.NewReader(`{}`))
+		server.VerifyEmail(c)
+
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
+
+func TestUpdateEmailAPI(t *testing.T) {
+	newEmailContext := func(req UpdateEmailRequest) (*gin.Context, *httptest.ResponseRecorder) {
+		c, recorder := setupGinTest()
+		data, _ := json.Marshal(req)
+		c.Request = httptest.NewRequest(http.MethodPut, "/me/email", bytes.NewReader(data))
+		c.Set(AuthorizationPayloadKey, &auth.Payload{ID: 10, Username: "testuser", Role: auth.RoleAuthor})
+		return c, recorder
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		sent := make(chanSender, 1)
+		server.mailer = sent
+		server.config.AppBaseURL = "https://plog.example"
+		hash, err := server.hasher.Hash("secret123")
+		require.NoError(t, err)
+		user := sqlc.User{ID: 10, Username: "testuser", PasswordHash: hash}
+		updated := user
+		updated.Email = pgtype.Text{String: "new@example.com", Valid: true}
+
+		mockStore.EXPECT().GetUserByID(gomock.Any(), int32(10)).Times(1).Return(user, nil)
+		mockStore.EXPECT().
+			UpdateUserEmail(gomock.Any(), sqlc.UpdateUserEmailParams{ID: 10, Email: updated.Email}).
+			Times(1).
+			Return(updated, nil)
+		mockStore.EXPECT().CreateEmailVerificationToken(gomock.Any(), gomock.Any()).Times(1).Return(sqlc.EmailVerificationToken{}, nil)
+
+		c, recorder := newEmailContext(UpdateEmailRequest{Email: "New@Example.com", Password: "secret123"})
+		server.UpdateEmail(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		select {
+		case msg := <-sent:
+			// The link opens the frontend page, which posts the token.
+			require.Contains(t, msg.Body, "https://plog.example/verify-email?token=")
+		case <-time.After(time.Second):
+			t.Fatal("verification email was not sent")
+		}
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		hash, err := server.hasher.Hash("secret123")
+		require.NoError(t, err)
+
+		mockStore.EXPECT().GetUserByID(gomock.Any(), int32(10)).Times(1).Return(sqlc.User{ID: 10, Username: "testuser", PasswordHash: hash}, nil)
+		mockStore.EXPECT().DeleteStaleLoginFailures(gomock.Any(), gomock.Any()).AnyTimes().Return(int64(0), nil)
+		mockStore.EXPECT().UpdateUserEmail(gomock.Any(), gomock.Any()).Times(0)
+
+		c, recorder := newEmailContext(UpdateEmailRequest{Email: "attacker@example.com", Password: "wrong"})
+		server.UpdateEmail(c)
+
+		require.Equal(t, http.StatusForbidden, recorder.Code)
+	})
+
+	t.Run("MissingCode", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		hash, err := server.hasher.Hash("secret123")
+		require.NoError(t, err)
+		user := sqlc.User{
+			ID:            10,
+			Username:      "testuser",
+			PasswordHash:  hash,
+			TotpSecret:    pgtype.Text{String: "JBSWY3DPEHPK3PXP", Valid: true},
+			TotpEnabledAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		}
+
+		mockStore.EXPECT().GetUserByID(gomock.Any(), int32(10)).Times(1).Return(user, nil)
+		mockStore.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+		mockStore.EXPECT().DeleteStaleLoginFailures(gomock.Any(), gomock.Any()).AnyTimes().Return(int64(0), nil)
+		mockStore.EXPECT().UpdateUserEmail(gomock.Any(), gomock.Any()).Times(0)
+
+		c, recorder := newEmailContext(UpdateEmailRequest{Email: "attacker@example.com", Password: "secret123"})
+		server.UpdateEmail(c)
+
+		require.Equal(t, http.StatusForbidden, recorder.Code)
+	})
+}
+
+func TestCreatePostRequiresVerifiedEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock_sqlc.NewMockQuerier(ctrl)
+	server := setupTestServer(t, mockStore)
+	server.config.RequireVerifiedEmail = true
+	c, recorder := setupGinTest()
+
+	mockStore.EXPECT().
+		GetUserByID(gomock.Any(), int32(10)).
+		Times(1).
+		Return(sqlc.User{ID: 10, Email: pgtype.Text{String: "user@example.com", Valid: true}}, nil)
+	mockStore.EXPECT().CreatePost(gomock.Any(), gomock.Any()).Times(0)
+
+	body, _ := json.Marshal(CreatePostRequest{Title: "Hello", Content: "World"})
+	c.Request = httptest.NewRequest(http.MethodPost, "/posts", bytes.NewReader(body))
+	c.Set(UserIDKey, int32(10))
+	server.CreatePost(c)
+
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestVerifyLoginMFAAPI(t *testing.T) {
+	secret, err := auth.NewTOTPSecret()
+	require.NoError(t, err)
+	user := sqlc.User{
+		ID:            10,
+		Username:      "testuser",
+		Role:          auth.RoleAuthor,
+		TotpSecret:    pgtype.Text{String: secret, Valid: true},
+		TotpEnabledAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+	challenge := sqlc.MfaChallenge{ID: 3, UserID: user.ID, Attempts: 1}
+
+	newVerifyRequest := func(code string) *http.Request {
+		body, _ := json.Marshal(VerifyLoginMFARequest{MFAToken: "mfa-token", Code: code})
+		return httptest.NewRequest(http.MethodPost, "/login/mfa", bytes.NewReader(body))
+	}
+	currentCode := func(t *testing.T) string {
+		code, err := auth.TOTPCode(secret, auth.TOTPStep(time.Now()))
+		require.NoError(t, err)
+		return code
+	}
+
+	t.Run("TOTPCode", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := setupGinTest()
+
+		mockStore.EXPECT().
+			AttemptMFAChallenge(gomock.Any(), sqlc.AttemptMFAChallengeParams{TokenHash: auth.HashToken("mfa-token"), MaxAttempts: mfaMaxAttempts}).
+			Times(1).
+			Return(challenge, nil)
+		mockStore.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+		mockStore.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+		mockStore.EXPECT().DeleteMFAChallenge(gomock.Any(), challenge.ID).Times(1).Return(int64(1), nil)
+		mockStore.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1).Return(sqlc.Session{}, nil)
+
+		c.Request = newVerifyRequest(currentCode(t))
+		server.VerifyLoginMFA(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var rsp LoginUserResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		require.NotEmpty(t, rsp.AccessToken)
+		require.NotEmpty(t, rsp.RefreshToken)
+	})
+
+	t.Run("ReplayedTOTPCode", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := setupGinTest()
+
+		mockStore.EXPECT().AttemptMFAChallenge(gomock.Any(), gomock.Any()).Times(1).Return(challenge, nil)
+		mockStore.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+		mockStore.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+		mockStore.EXPECT().DeleteStaleLoginFailures(gomock.Any(), gomock.Any()).AnyTimes().Return(int64(0), nil)
+		mockStore.EXPECT().DeleteMFAChallenge(gomock.Any(), gomock.Any()).Times(0)
+
+		c.Request = newVerifyRequest(currentCode(t))
+		server.VerifyLoginMFA(c)
+
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	})
+
+	t.Run("RecoveryCode", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := setupGinTest()
+
+		mockStore.EXPECT().AttemptMFAChallenge(gomock.Any(), gomock.Any()).Times(1).Return(challenge, nil)
+		mockStore.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+		mockStore.EXPECT().
+			UseRecoveryCode(gomock.Any(), sqlc.UseRecoveryCodeParams{UserID: user.ID, CodeHash: auth.HashRecoveryCode("abcde-fghij")}).
+			Times(1).
+			Return(int64(1), nil)
+		mockStore.EXPECT().DeleteMFAChallenge(gomock.Any(), challenge.ID).Times(1).Return(int64(1), nil)
+		mockStore.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1).Return(sqlc.Session{}, nil)
+
+		c.Request = newVerifyRequest("ABCDE-FGHIJ")
+		server.VerifyLoginMFA(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("WrongCodeCountsAsFailedLogin", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		server.throttle = NewLoginThrottle(mockStore, 5, 20, time.Minute, time.Hour)
+		c, recorder := setupGinTest()
+
+		mockStore.EXPECT().AttemptMFAChallenge(gomock.Any(), gomock.Any()).Times(1).Return(challenge, nil)
+		mockStore.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+		mockStore.EXPECT().GetLoginLockouts(gomock.Any(), gomock.Any()).Times(1).Return([]sqlc.GetLoginLockoutsRow{}, nil)
+		mockStore.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+		mockStore.EXPECT().
+			RecordLoginFailure(gomock.Any(), gomock.Any()).
+			Times(2).
+			DoAndReturn(func(_ context.Context, arg sqlc.RecordLoginFailureParams) (int32, error) {
+				if arg.Scope == loginScopeUsername {
+					require.Equal(t, user.Username, arg.Key)
+				}
+				return 1, nil
+			})
+		mockStore.EXPECT().DeleteStaleLoginFailures(gomock.Any(), gomock.Any()).AnyTimes().Return(int64(0), nil)
+		mockStore.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Any()).Times(0)
+
+		c.Request = newVerifyRequest("000000-wrong")
+		server.VerifyLoginMFA(c)
+
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+		events := server.auditor.(*auditRecorder).events
+		require.Len(t, events, 1)
+		require.Equal(t, audit.EventLoginMFA, events[0].Type)
+		require.Equal(t, "invalid_code", events[0].Details)
+	})
+
+	t.Run("LockedOut", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		server.throttle = NewLoginThrottle(mockStore, 5, 20, time.Minute, time.Hour)
+		c, recorder := setupGinTest()
+
+		mockStore.EXPECT().AttemptMFAChallenge(gomock.Any(), gomock.Any()).Times(1).Return(challenge, nil)
+		mockStore.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+		mockStore.EXPECT().
+			GetLoginLockouts(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return([]sqlc.GetLoginLockoutsRow{{Scope: loginScopeUsername, LockedUntil: pgtype.Timestamptz{Time: time.Now().Add(time.Minute), Valid: true}}}, nil)
+		mockStore.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(0)
+
+		c.Request = newVerifyRequest(currentCode(t))
+		server.VerifyLoginMFA(c)
+
+		require.Equal(t, http.StatusLocked, recorder.Code)
+	})
+
+	t.Run("ExhaustedOrExpiredToken", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := setupGinTest()
+
+		mockStore.EXPECT().AttemptMFAChallenge(gomock.Any(), gomock.Any()).Times(1).Return(sqlc.MfaChallenge{}, sql.ErrNoRows)
+		mockStore.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).Times(0)
+
+		c.Request = newVerifyRequest(currentCode(t))
+		server.VerifyLoginMFA(c)
+
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	})
+}
+
+func TestTOTPChangeAPI(t *testing.T) {
+	secret, err := auth.NewTOTPSecret()
+	require.NoError(t, err)
+
+	handlers := []struct {
+		name    string
+		method  string
+		path    string
+		handler func(server *Server) gin.HandlerFunc
+	}{
+		{name: "Disable", method: http.MethodDelete, path: "/me/2fa/totp", handler: func(server *Server) gin.HandlerFunc { return server.DisableTOTP }},
+		{name: "RegenerateRecoveryCodes", method: http.MethodPost, path: "/me/2fa/recovery-codes", handler: func(server *Server) gin.HandlerFunc { return server.RegenerateRecoveryCodes }},
+	}
+
+	for _, h := range handlers {
+		t.Run(h.name, func(t *testing.T) {
+			setup := func(t *testing.T, req TOTPChangeRequest) (*Server, *mock_sqlc.MockQuerier, *gin.Context, *httptest.ResponseRecorder) {
+				ctrl := gomock.NewController(t)
+				t.Cleanup(ctrl.Finish)
+
+				mockStore := mock_sqlc.NewMockQuerier(ctrl)
+				server := setupTestServer(t, mockStore)
+				server.throttle = NewLoginThrottle(mockStore, 5, 20, time.Minute, time.Hour)
+				hash, err := server.hasher.Hash("secret123")
+				require.NoError(t, err)
+				mockStore.EXPECT().GetUserByID(gomock.Any(), int32(10)).Times(1).Return(sqlc.User{
+					ID:            10,
+					Username:      "testuser",
+					PasswordHash:  hash,
+					TotpSecret:    pgtype.Text{String: secret, Valid: true},
+					TotpEnabledAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+				}, nil)
+				// Nothing may change unless every check passes.
+				mockStore.EXPECT().DisableUserTOTP(gomock.Any(), gomock.Any()).Times(0)
+				mockStore.EXPECT().CreateRecoveryCodes(gomock.Any(), gomock.Any()).Times(0)
+
+				c, recorder := setupGinTest()
+				body, _ := json.Marshal(req)
+				c.Request = httptest.NewRequest(h.method, h.path, bytes.NewReader(body))
+				c.Set(AuthorizationPayloadKey, &auth.Payload{ID: 10, Username: "testuser", Role: auth.RoleAuthor})
+				return server, mockStore, c, recorder
+			}
+
+			t.Run("WrongCodeCountsAsFailedLogin", func(t *testing.T) {
+				server, mockStore, c, recorder := setup(t, TOTPChangeRequest{Password: "secret123", Code: "000000-wrong"})
+				mockStore.EXPECT().GetLoginLockouts(gomock.Any(), gomock.Any()).Times(1).Return([]sqlc.GetLoginLockoutsRow{}, nil)
+				mockStore.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				mockStore.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(2).Return(int32(1), nil)
+				mockStore.EXPECT().DeleteStaleLoginFailures(gomock.Any(), gomock.Any()).AnyTimes().Return(int64(0), nil)
+
+				h.handler(server)(c)
+
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				events := server.auditor.(*auditRecorder).events
+				require.Len(t, events, 1)
+				require.Equal(t, "invalid_code", events[0].Details)
+			})
+
+			t.Run("LockedOut", func(t *testing.T) {
+				server, mockStore, c, recorder := setup(t, TOTPChangeRequest{Password: "secret123", Code: "123456"})
+				mockStore.EXPECT().
+					GetLoginLockouts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]sqlc.GetLoginLockoutsRow{{Scope: loginScopeUsername, LockedUntil: pgtype.Timestamptz{Time: time.Now().Add(time.Minute), Valid: true}}}, nil)
+				mockStore.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(0)
+				mockStore.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Any()).Times(0)
+
+				h.handler(server)(c)
+
+				require.Equal(t, http.StatusLocked, recorder.Code)
+			})
+
+			t.Run("MissingPassword", func(t *testing.T) {
+				server, mockStore, c, recorder := setup(t, TOTPChangeRequest{Code: "123456"})
+				mockStore.EXPECT().GetLoginLockouts(gomock.Any(), gomock.Any()).Times(1).Return([]sqlc.GetLoginLockoutsRow{}, nil)
+				mockStore.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(0)
+				mockStore.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Any()).Times(0)
+
+				h.handler(server)(c)
+
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			})
+		})
+	}
+}
+
+func TestOIDCLoginAPI(t *testing.T) {
+	issuer := oidctest.NewIssuer(t, oidctest.User{
+		Subject:           "subject-1",
+		Email:             "Jane@Example.com",
+		EmailVerified:     true,
+		PreferredUsername: "jane.doe",
+	})
+	email := pgtype.Text{String: "jane@example.com", Valid: true}
+
+	newOIDCServer := func(t *testing.T, store sqlc.Querier) *Server {
+		server := setupTestServer(t, store)
+		server.config.AppBaseURL = "http://plog.test"
+		server.oidcProviders = newOIDCProviders(config.Config{
+			AppBaseURL: "http://plog.test",
+			OIDCProviders: []config.OIDCProvider{{
+				Name:         "corp",
+				IssuerURL:    issuer.URL,
+				ClientID:     oidctest.ClientID,
+				ClientSecret: oidctest.ClientSecret,
+			}},
+		})
+		return server
+	}
+
+	// startLogin runs OIDCLogin and follows the issuer's redirect, returning
+	// the callback request and the auth request that was stored.
+	startLogin := func(t *testing.T, server *Server, mockStore *mock_sqlc.MockQuerier) (*http.Request, sqlc.OidcAuthRequest) {
+		var stored sqlc.OidcAuthRequest
+		mockStore.EXPECT().DeleteExpiredOIDCAuthRequests(gomock.Any()).Times(1).Return(int64(0), nil)
+		mockStore.EXPECT().
+			CreateOIDCAuthRequest(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg sqlc.CreateOIDCAuthRequestParams) error {
+				stored = sqlc.OidcAuthRequest{
+					StateHash:    arg.StateHash,
+					Provider:     arg.Provider,
+					Nonce:        arg.Nonce,
+					CodeVerifier: arg.CodeVerifier,
+				}
+				return nil
+			})
+
+		c, recorder := setupGinTest()
+		c.Request = httptest.NewRequest(http.MethodGet, "/oidc/corp/login", nil)
+		c.Params = gin.Params{{Key: "provider", Value: "corp"}}
+		server.OIDCLogin(c)
+		require.Equal(t, http.StatusFound, recorder.Code)
+
+		client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}}
+		rsp, err := client.Get(recorder.Header().Get("Location"))
+		require.NoError(t, err)
+		rsp.Body.Close()
+		require.Equal(t, http.StatusFound, rsp.StatusCode)
+
+		callback := httptest.NewRequest(http.MethodGet, rsp.Header.Get("Location"), nil)
+		require.Equal(t, "/api/v1/oidc/corp/callback", callback.URL.Path)
+		require.Equal(t, stored.StateHash, auth.HashToken(callback.URL.Query().Get("state")))
+
+		// The browser sends back the state cookie set by OIDCLogin.
+		cookies := recorder.Result().Cookies()
+		require.Len(t, cookies, 1)
+		require.Equal(t, OIDCStateCookieName, cookies[0].Name)
+		require.True(t, cookies[0].HttpOnly)
+		require.Equal(t, callback.URL.Query().Get("state"), cookies[0].Value)
+		callback.AddCookie(cookies[0])
+		return callback, stored
+	}
+
+	// finishLogin runs OIDCCallback and returns the fragment of the frontend
+	// URL it redirected to.
+	finishLogin := func(t *testing.T, server *Server, callback *http.Request) url.Values {
+		c, recorder := setupGinTest()
+		c.Request = callback
+		c.Params = gin.Params{{Key: "provider", Value: "corp"}}
+		server.OIDCCallback(c)
+
+		require.Equal(t, http.StatusFound, recorder.Code)
+		location, err := url.Parse(recorder.Header().Get("Location"))
+		require.NoError(t, err)
+		require.Equal(t, "http://plog.test/oidc/callback", location.Scheme+"://"+location.Host+location.Path)
+		fragment, err := url.ParseQuery(location.Fragment)
+		require.NoError(t, err)
+		return fragment
+	}
+
+	t.Run("FirstLoginCreatesUser", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := newOIDCServer(t, mockStore)
+		callback, stored := startLogin(t, server, mockStore)
+
+		newUser := sqlc.User{ID: 20, Username: "janedoe", Role: auth.RoleAuthor, Email: email}
+		mockStore.EXPECT().ConsumeOIDCAuthRequest(gomock.Any(), stored.StateHash).Times(1).Return(stored, nil)
+		mockStore.EXPECT().
+			GetIdentity(gomock.Any(), sqlc.GetIdentityParams{Provider: "corp", Subject: "subject-1"}).
+			Times(1).
+			Return(sqlc.Identity{}, sql.ErrNoRows)
+		mockStore.EXPECT().GetUserByEmail(gomock.Any(), email).Times(1).Return(sqlc.User{}, sql.ErrNoRows)
+		mockStore.EXPECT().
+			CreateUser(gomock.Any(), sqlc.CreateUserParams{Username: "janedoe", PasswordHash: oidcPasswordHash, Email: email}).
+			Times(1).
+			Return(newUser, nil)
+		mockStore.EXPECT().
+			MarkUserEmailVerified(gomock.Any(), sqlc.MarkUserEmailVerifiedParams{ID: newUser.ID, Email: email}).
+			Times(1).
+			Return(newUser, nil)
+		mockStore.EXPECT().
+			CreateIdentity(gomock.Any(), sqlc.CreateIdentityParams{UserID: newUser.ID, Provider: "corp", Subject: "subject-1", Email: email}).
+			Times(1).
+			Return(sqlc.Identity{}, nil)
+		mockStore.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1).Return(sqlc.Session{}, nil)
+
+		fragment := finishLogin(t, server, callback)
+
+		require.Empty(t, fragment.Get("error"))
+		payload, err := server.tokenMaker.VerifyToken(fragment.Get("access_token"))
+		require.NoError(t, err)
+		require.Equal(t, "janedoe", payload.Username)
+		require.NotEmpty(t, fragment.Get("refresh_token"))
+	})
+
+	t.Run("LinksVerifiedEmail", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := newOIDCServer(t, mockStore)
+		callback, stored := startLogin(t, server, mockStore)
+
+		existing := sqlc.User{ID: 10, Username: "jane", Email: email, EmailVerifiedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true}}
+		mockStore.EXPECT().ConsumeOIDCAuthRequest(gomock.Any(), gomock.Any()).Times(1).Return(stored, nil)
+		mockStore.EXPECT().GetIdentity(gomock.Any(), gomock.Any()).Times(1).Return(sqlc.Identity{}, sql.ErrNoRows)
+		mockStore.EXPECT().GetUserByEmail(gomock.Any(), email).Times(1).Return(existing, nil)
+		mockStore.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+		mockStore.EXPECT().
+			CreateIdentity(gomock.Any(), sqlc.CreateIdentityParams{UserID: existing.ID, Provider: "corp", Subject: "subject-1", Email: email}).
+			Times(1).
+			Return(sqlc.Identity{}, nil)
+		mockStore.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1).Return(sqlc.Session{}, nil)
+
+		fragment := finishLogin(t, server, callback)
+
+		require.NotEmpty(t, fragment.Get("access_token"))
+	})
+
+	t.Run("RefusesUnverifiedLocalEmail", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := newOIDCServer(t, mockStore)
+		callback, stored := startLogin(t, server, mockStore)
+
+		mockStore.EXPECT().ConsumeOIDCAuthRequest(gomock.Any(), gomock.Any()).Times(1).Return(stored, nil)
+		mockStore.EXPECT().GetIdentity(gomock.Any(), gomock.Any()).Times(1).Return(sqlc.Identity{}, sql.ErrNoRows)
+		mockStore.EXPECT().GetUserByEmail(gomock.Any(), email).Times(1).Return(sqlc.User{ID: 10, Email: email}, nil)
+		mockStore.EXPECT().CreateIdentity(gomock.Any(), gomock.Any()).Times(0)
+
+		fragment := finishLogin(t, server, callback)
+
+		require.Contains(t, fragment.Get("error"), "already exists")
+		require.Empty(t, fragment.Get("access_token"))
+	})
+
+	t.Run("ReplayedState", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := newOIDCServer(t, mockStore)
+		callback, _ := startLogin(t, server, mockStore)
+
+		mockStore.EXPECT().ConsumeOIDCAuthRequest(gomock.Any(), gomock.Any()).Times(1).Return(sqlc.OidcAuthRequest{}, sql.ErrNoRows)
+		mockStore.EXPECT().GetIdentity(gomock.Any(), gomock.Any()).Times(0)
+
+		fragment := finishLogin(t, server, callback)
+
+		require.Equal(t, "Invalid or expired state", fragment.Get("error"))
+	})
+
+	t.Run("CallbackInOtherBrowser", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := newOIDCServer(t, mockStore)
+		callback, _ := startLogin(t, server, mockStore)
+
+		// An attacker's callback URL opened by a victim: the state is valid,
+		// but the victim's browser does not have the cookie that goes with it.
+		victim := httptest.NewRequest(http.MethodGet, callback.URL.String(), nil)
+		mockStore.EXPECT().ConsumeOIDCAuthRequest(gomock.Any(), gomock.Any()).Times(0)
+		mockStore.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+
+		fragment := finishLogin(t, server, victim)
+
+		require.Equal(t, "Invalid or expired state", fragment.Get("error"))
+	})
+
+	t.Run("SecondFactorRequired", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := newOIDCServer(t, mockStore)
+		callback, stored := startLogin(t, server, mockStore)
+
+		user := sqlc.User{ID: 10, Username: "jane", TotpEnabledAt: pgtype.Timestamptz{Time: time.Now(), Valid: true}}
+		mockStore.EXPECT().ConsumeOIDCAuthRequest(gomock.Any(), gomock.Any()).Times(1).Return(stored, nil)
+		mockStore.EXPECT().GetIdentity(gomock.Any(), gomock.Any()).Times(1).Return(sqlc.Identity{ID: 1, UserID: user.ID}, nil)
+		mockStore.EXPECT().TouchIdentity(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+		mockStore.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+		mockStore.EXPECT().DeleteExpiredMFAChallenges(gomock.Any()).Times(1).Return(int64(0), nil)
+		mockStore.EXPECT().CreateMFAChallenge(gomock.Any(), gomock.Any()).Times(1).Return(sqlc.MfaChallenge{}, nil)
+		mockStore.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+
+		fragment := finishLogin(t, server, callback)
+
+		require.NotEmpty(t, fragment.Get("mfa_token"))
+		require.Empty(t, fragment.Get("access_token"))
+	})
+}
+
+func TestPersonalAccessTokenAuth(t *testing.T) {
+	token, tokenHash, err := auth.NewPersonalAccessToken()
+	require.NoError(t, err)
+	pat := sqlc.UsePersonalAccessTokenRow{ID: 3, UserID: 10, Scopes: []string{auth.ScopePostsRead}, Username: "ci", Role: auth.RoleAuthor}
+
+	newRouter := func(server *Server) *gin.Engine {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(AuthMiddleware(server.tokenMaker, server.denylist, server.store, false))
+		ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+		router.GET("/read", RequireScope(auth.ScopePostsRead), ok)
+		router.POST("/write", RequireScope(auth.ScopePostsWrite), ok)
+		router.GET("/account", RequireSessionToken(), ok)
+		return router
+	}
+
+	testCases := []struct {
+		name         string
+		method       string
+		path         string
+		found        bool
+		expectedCode int
+	}{
+		{name: "GrantedScope", method: http.MethodGet, path: "/read", found: true, expectedCode: http.StatusOK},
+		{name: "MissingScope", method: http.MethodPost, path: "/write", found: true, expectedCode: http.StatusForbidden},
+		{name: "SessionOnlyEndpoint", method: http.MethodGet, path: "/account", found: true, expectedCode: http.StatusForbidden},
+		{name: "RevokedOrExpired", method: http.MethodGet, path: "/read", expectedCode: http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mock_sqlc.NewMockQuerier(ctrl)
+			server := setupTestServer(t, mockStore)
+			if tc.found {
+				mockStore.EXPECT().UsePersonalAccessToken(gomock.Any(), tokenHash).Times(1).Return(pat, nil)
+			} else {
+				mockStore.EXPECT().UsePersonalAccessToken(gomock.Any(), tokenHash).Times(1).Return(sqlc.UsePersonalAccessTokenRow{}, sql.ErrNoRows)
+			}
+
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			req.Header.Set(AuthorizationHeaderKey, "Bearer "+token)
+			newRouter(server).ServeHTTP(recorder, req)
+
+			require.Equal(t, tc.expectedCode, recorder.Code)
+		})
+	}
+
+	t.Run("SessionTokenHasAllScopes", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		mockStore.EXPECT().UsePersonalAccessToken(gomock.Any(), gomock.Any()).Times(0)
+		mockStore.EXPECT().ListRevokedTokens(gomock.Any(), gomock.Any()).AnyTimes().Return([]sqlc.RevokedToken{}, nil)
+		mockStore.EXPECT().ListRevokedSessions(gomock.Any(), gomock.Any()).AnyTimes().Return([]sqlc.ListRevokedSessionsRow{}, nil)
+		mockStore.EXPECT().DeleteExpiredRevokedTokens(gomock.Any()).AnyTimes().Return(int64(0), nil)
+		accessToken, err := server.tokenMaker.CreateToken(10, "writer", auth.RoleAuthor, uuid.New(), time.Minute)
+		require.NoError(t, err)
+
+		for _, route := range []struct{ method, path string }{
+			{method: http.MethodPost, path: "/write"},
+			{method: http.MethodGet, path: "/account"},
+		} {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(route.method, route.path, nil)
+			req.Header.Set(AuthorizationHeaderKey, "Bearer "+accessToken)
+			newRouter(server).ServeHTTP(recorder, req)
+			require.Equal(t, http.StatusOK, recorder.Code, route.path)
+		}
+	})
+}
+
+func TestCreatePersonalAccessTokenAPI(t *testing.T) {
+	newCreateContext := func(req CreatePersonalAccessTokenRequest) (*gin.Context, *httptest.ResponseRecorder) {
+		c, recorder := setupGinTest()
+		body, _ := json.Marshal(req)
+		c.Request = httptest.NewRequest(http.MethodPost, "/me/tokens", bytes.NewReader(body))
+		c.Set(AuthorizationPayloadKey, &auth.Payload{ID: 10, Username: "testuser", Role: auth.RoleAuthor})
+		return c, recorder
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		expiresAt := time.Now().Add(24 * time.Hour)
+		c, recorder := newCreateContext(CreatePersonalAccessTokenRequest{
+			Name:      "ci",
+			Scopes:    []string{auth.ScopePostsWrite, auth.ScopePostsRead, auth.ScopePostsWrite},
+			ExpiresAt: &expiresAt,
+		})
+
+		var stored sqlc.CreatePersonalAccessTokenParams
+		mockStore.EXPECT().
+			CreatePersonalAccessToken(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg sqlc.CreatePersonalAccessTokenParams) (sqlc.PersonalAccessToken, error) {
+				stored = arg
+				return sqlc.PersonalAccessToken{ID: 1, UserID: arg.UserID, Name: arg.Name, Scopes: arg.Scopes, ExpiresAt: arg.ExpiresAt}, nil
+			})
+
+		server.CreatePersonalAccessToken(c)
+
+		require.Equal(t, http.StatusCreated, recorder.Code)
+		var rsp CreatePersonalAccessTokenResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		require.True(t, auth.IsPersonalAccessToken(rsp.Token))
+		require.Equal(t, auth.HashToken(rsp.Token), stored.TokenHash)
+		require.Equal(t, int32(10), stored.UserID)
+		require.Equal(t, []string{auth.ScopePostsRead, auth.ScopePostsWrite}, rsp.Scopes)
+		require.NotNil(t, rsp.ExpiresAt)
+	})
+
+	t.Run("UnknownScope", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newCreateContext(CreatePersonalAccessTokenRequest{Name: "ci", Scopes: []string{"users:manage"}})
+		mockStore.EXPECT().CreatePersonalAccessToken(gomock.Any(), gomock.Any()).Times(0)
+
+		server.CreatePersonalAccessToken(c)
+
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
+
+func TestUpdateUserRoleAPI(t *testing.T) {
+	newRoleContext := func(role string) (*gin.Context, *httptest.ResponseRecorder) {
+		c, recorder := setupGinTest()
+		body, _ := json.Marshal(UpdateUserRoleRequest{Role: role})
+		c.Request = httptest.NewRequest(http.MethodPut, "/admin/users/10/role", bytes.NewReader(body))
+		c.Params = gin.Params{{Key: "id", Value: "10"}}
+		c.Set(AuthorizationPayloadKey, &auth.Payload{ID: 1, Username: "admin", Role: auth.RoleAdmin})
+		return c, recorder
+	}
+
+	t.Run("RevokesTokensAndSessions", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newRoleContext(auth.RoleReader)
+
+		gomock.InOrder(
+			mockStore.EXPECT().
+				UpdateUserRole(gomock.Any(), sqlc.UpdateUserRoleParams{ID: 10, Role: auth.RoleReader}).
+				Times(1).
+				Return(sqlc.User{ID: 10, Username: "testuser", Role: auth.RoleReader}, nil),
+			mockStore.EXPECT().RevokeUserPersonalAccessTokens(gomock.Any(), int32(10)).Times(1).Return(int64(1), nil),
+			mockStore.EXPECT().RevokeUserSessions(gomock.Any(), int32(10)).Times(1).Return(nil),
+		)
+
+		server.UpdateUserRole(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("OldAccessTokenStopsWorking", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		editorSession := uuid.New()
+		var revoked []sqlc.ListRevokedSessionsRow
+		mockStore.EXPECT().ListRevokedTokens(gomock.Any(), gomock.Any()).AnyTimes().Return([]sqlc.RevokedToken{}, nil)
+		mockStore.EXPECT().ListRevokedSessions(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+			func(_ context.Context, _ pgtype.Timestamptz) ([]sqlc.ListRevokedSessionsRow, error) {
+				return revoked, nil
+			})
+		mockStore.EXPECT().DeleteExpiredRevokedTokens(gomock.Any()).AnyTimes().Return(int64(0), nil)
+		mockStore.EXPECT().UpdateUserRole(gomock.Any(), gomock.Any()).Times(1).Return(sqlc.User{ID: 10, Username: "testuser", Role: auth.RoleAuthor}, nil)
+		mockStore.EXPECT().RevokeUserPersonalAccessTokens(gomock.Any(), int32(10)).Times(1).Return(int64(0), nil)
+		mockStore.EXPECT().
+			RevokeUserSessions(gomock.Any(), int32(10)).
+			Times(1).
+			DoAndReturn(func(_ context.Context, _ int32) error {
+				revoked = []sqlc.ListRevokedSessionsRow{{ID: editorSession, RevokedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true}}}
+				return nil
+			})
+
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		authRoutes := router.Group("/").Use(AuthMiddleware(server.tokenMaker, server.denylist, server.store, false))
+		authRoutes.GET("/editor-only", RequireRole(auth.RoleEditor), func(c *gin.Context) { c.Status(http.StatusOK) })
+		authRoutes.PUT("/admin/users/:id/role", RequireRole(auth.RoleAdmin), server.UpdateUserRole)
+
+		editorToken, err := server.tokenMaker.CreateToken(10, "testuser", auth.RoleEditor, editorSession, time.Minute)
+		require.NoError(t, err)
+		adminToken, err := server.tokenMaker.CreateToken(1, "admin", auth.RoleAdmin, uuid.New(), time.Minute)
+		require.NoError(t, err)
+		send := func(method, path, token string, body io.Reader) int {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(method, path, body)
+			req.Header.Set(AuthorizationHeaderKey, "Bearer "+token)
+			router.ServeHTTP(recorder, req)
+			return recorder.Code
+		}
+
+		require.Equal(t, http.StatusOK, send(http.MethodGet, "/editor-only", editorToken, nil))
+		require.Equal(t, http.StatusOK, send(http.MethodPut, "/admin/users/10/role", adminToken, strings.NewReader(`{"role":"author"}`)))
+		require.Equal(t, http.StatusUnauthorized, send(http.MethodGet, "/editor-only", editorToken, nil))
+	})
+
+	t.Run("RevokeError", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newRoleContext(auth.RoleReader)
+
+		mockStore.EXPECT().
+			UpdateUserRole(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(sqlc.User{ID: 10, Username: "testuser", Role: auth.RoleReader}, nil)
+		mockStore.EXPECT().RevokeUserPersonalAccessTokens(gomock.Any(), int32(10)).Times(1).Return(int64(0), fmt.Errorf("some database error"))
+
+		server.UpdateUserRole(c)
+
+		require.Equal(t, http.StatusInternalServerError, recorder.Code)
+	})
+
+	t.Run("UnknownRole", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newRoleContext("owner")
+		mockStore.EXPECT().UpdateUserRole(gomock.Any(), gomock.Any()).Times(0)
+		mockStore.EXPECT().RevokeUserPersonalAccessTokens(gomock.Any(), gomock.Any()).Times(0)
+
+		server.UpdateUserRole(c)
+
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
+
+func TestTrustedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	testCases := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		expectedIP     string
+	}{
+		{name: "SpoofedHeaderIgnored", remoteAddr: "203.0.113.7:5555", expectedIP: "203.0.113.7"},
+		{name: "UntrustedPeer", trustedProxies: []string{"10.0.0.0/8"}, remoteAddr: "203.0.113.7:5555", expectedIP: "203.0.113.7"},
+		{name: "TrustedProxy", trustedProxies: []string{"10.0.0.0/8"}, remoteAddr: "10.1.2.3:5555", expectedIP: "1.2.3.4"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := NewServer(config.Config{
+				JWTSecret:         "a_very_secret_key_should_be_longer_and_random",
+				Argon2Memory:      64,
+				Argon2Iterations:  1,
+				Argon2Parallelism: 1,
+				TrustedProxies:    tc.trustedProxies,
+			}, nil).router
+			router.GET("/ip", func(c *gin.Context) { c.String(http.StatusOK, c.ClientIP()) })
+
+			request := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			request.RemoteAddr = tc.remoteAddr
+			request.Header.Set("X-Forwarded-For", "1.2.3.4")
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.expectedIP, recorder.Body.String())
+		})
+	}
+}
+
+func TestLoginThrottle(t *testing.T) {
+	newLoginContext := func() (*gin.Context, *httptest.ResponseRecorder) {
+		c, recorder := setupGinTest()
+		body, _ := json.Marshal(LoginUserRequest{Username: "victim", Password: "guess"})
+		c.Request = httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		c.Request.RemoteAddr = "203.0.113.7:5555"
+		return c, recorder
+	}
+	newThrottledServer := func(t *testing.T, store sqlc.Querier) *Server {
+		server := setupTestServer(t, store)
+		server.throttle = NewLoginThrottle(store, 5, 20, time.Minute, time.Hour)
+		return server
+	}
+	lockoutsParams := sqlc.GetLoginLockoutsParams{Username: "victim", Ip: "203.0.113.7"}
+
+	testCases := []struct {
+		name         string
+		scope        string
+		expectedCode int
+	}{
+		{name: "UsernameLocked", scope: loginScopeUsername, expectedCode: http.StatusLocked},
+		{name: "IPThrottled", scope: loginScopeIP, expectedCode: http.StatusTooManyRequests},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mock_sqlc.NewMockQuerier(ctrl)
+			server := newThrottledServer(t, mockStore)
+			c, recorder := newLoginContext()
+
+			lockedUntil := pgtype.Timestamptz{Time: time.Now().Add(90 * time.Second), Valid: true}
+			mockStore.EXPECT().
+				GetLoginLockouts(gomock.Any(), lockoutsParams).
+				Times(1).
+				Return([]sqlc.GetLoginLockoutsRow{{Scope: tc.scope, LockedUntil: lockedUntil}}, nil)
+			mockStore.EXPECT().GetUserByUsername(gomock.Any(), gomock.Any()).Times(0)
+
+			server.LoginUser(c)
+
+			require.Equal(t, tc.expectedCode, recorder.Code)
+			require.Equal(t, "90", recorder.Header().Get("Retry-After"))
+		})
+	}
+
+	t.Run("FailureReachingLimitLocks", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := newThrottledServer(t, mockStore)
+		c, recorder := newLoginContext()
+
+		mockStore.EXPECT().GetLoginLockouts(gomock.Any(), lockoutsParams).Times(1).Return([]sqlc.GetLoginLockoutsRow{}, nil)
+		mockStore.EXPECT().GetUserByUsername(gomock.Any(), "victim").Times(1).Return(sqlc.User{}, sql.ErrNoRows)
+		mockStore.EXPECT().
+			RecordLoginFailure(gomock.Any(), gomock.Any()).
+			Times(2).
+			DoAndReturn(func(_ context.Context, arg sqlc.RecordLoginFailureParams) (int32, error) {
+				if arg.Scope == loginScopeUsername {
+					return 5, nil
+				}
+				return 1, nil
+			})
+		mockStore.EXPECT().
+			LockLogin(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg sqlc.LockLoginParams) error {
+				require.Equal(t, loginScopeUsername, arg.Scope)
+				require.Equal(t, "victim", arg.Key)
+				require.WithinDuration(t, time.Now().Add(time.Minute), arg.LockedUntil.Time, time.Second)
+				return nil
+			})
+		mockStore.EXPECT().DeleteStaleLoginFailures(gomock.Any(), gomock.Any()).AnyTimes().Return(int64(0), nil)
+
+		server.LoginUser(c)
+
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+		events := server.auditor.(*auditRecorder).events
+		require.Len(t, events, 1)
+		require.Equal(t, audit.EventLogin, events[0].Type)
+		require.Equal(t, audit.OutcomeFailure, events[0].Outcome)
+		require.Equal(t, "victim", events[0].Username)
+		require.Equal(t, "unknown_user", events[0].Details)
+		require.Equal(t, "203.0.113.7", events[0].IP)
+	})
+
+	t.Run("IPCountedWhenUsernameFails", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := newThrottledServer(t, mockStore)
+		c, recorder := newLoginContext()
+
+		mockStore.EXPECT().GetLoginLockouts(gomock.Any(), lockoutsParams).Times(1).Return([]sqlc.GetLoginLockoutsRow{}, nil)
+		mockStore.EXPECT().GetUserByUsername(gomock.Any(), "victim").Times(1).Return(sqlc.User{}, sql.ErrNoRows)
+		mockStore.EXPECT().
+			RecordLoginFailure(gomock.Any(), gomock.Any()).
+			Times(2).
+			DoAndReturn(func(_ context.Context, arg sqlc.RecordLoginFailureParams) (int32, error) {
+				if arg.Scope == loginScopeUsername {
+					return 0, fmt.Errorf("some database error")
+				}
+				require.Equal(t, "203.0.113.7", arg.Key)
+				return 1, nil
+			})
+		mockStore.EXPECT().DeleteStaleLoginFailures(gomock.Any(), gomock.Any()).AnyTimes().Return(int64(0), nil)
+
+		server.LoginUser(c)
+
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	})
+
+	t.Run("UsernameTooLong", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := newThrottledServer(t, mockStore)
+		c, recorder := setupGinTest()
+		body, _ := json.Marshal(LoginUserRequest{Username: strings.Repeat("a", 256), Password: "guess"})
+		c.Request = httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		mockStore.EXPECT().GetLoginLockouts(gomock.Any(), gomock.Any()).Times(0)
+		mockStore.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(0)
+
+		server.LoginUser(c)
+
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
+
+func TestUnlockUserAPI(t *testing.T) {
+	newUnlockContext := func(id string) (*gin.Context, *httptest.ResponseRecorder) {
+		c, recorder := setupGinTest()
+		c.Request = httptest.NewRequest(http.MethodDelete, "/admin/users/"+id+"/lockout", nil)
+		c.Params = gin.Params{{Key: "id", Value: id}}
+		c.Set(AuthorizationPayloadKey, &auth.Payload{ID: 1, Username: "admin", Role: auth.RoleAdmin})
+		return c, recorder
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		server.throttle = NewLoginThrottle(mockStore, 5, 20, time.Minute, time.Hour)
+		c, _ := newUnlockContext("10")
+
+		mockStore.EXPECT().GetUserByID(gomock.Any(), int32(10)).Times(1).Return(sqlc.User{ID: 10, Username: "victim"}, nil)
+		mockStore.EXPECT().
+			ClearLoginFailures(gomock.Any(), sqlc.ClearLoginFailuresParams{Scope: loginScopeUsername, Key: "victim"}).
+			Times(1).
+			Return(nil)
+
+		server.UnlockUser(c)
+
+		require.Equal(t, http.StatusNoContent, c.Writer.Status())
+		events := server.auditor.(*auditRecorder).events
+		require.Len(t, events, 1)
+		require.Equal(t, audit.EventUnlock, events[0].Type)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		server.throttle = NewLoginThrottle(mockStore, 5, 20, time.Minute, time.Hour)
+		c, recorder := newUnlockContext("10")
+
+		mockStore.EXPECT().GetUserByID(gomock.Any(), int32(10)).Times(1).Return(sqlc.User{}, sql.ErrNoRows)
+		mockStore.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Any()).Times(0)
+
+		server.UnlockUser(c)
+
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("InvalidID", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newUnlockContext("abc")
+		mockStore.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).Times(0)
+
+		server.UnlockUser(c)
+
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
+
+func TestLoginWithMFAKeepsFailedLogins(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock_sqlc.NewMockQuerier(ctrl)
+	server := setupTestServer(t, mockStore)
+	server.throttle = NewLoginThrottle(mockStore, 5, 20, time.Minute, time.Hour)
+	hash, err := server.hasher.Hash("secret123")
+	require.NoError(t, err)
+	user := sqlc.User{
+		ID:            10,
+		Username:      "testuser",
+		PasswordHash:  hash,
+		TotpEnabledAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+
+	mockStore.EXPECT().GetLoginLockouts(gomock.Any(), gomock.Any()).Times(1).Return([]sqlc.GetLoginLockoutsRow{}, nil)
+	mockStore.EXPECT().GetUserByUsername(gomock.Any(), "testuser").Times(1).Return(user, nil)
+	mockStore.EXPECT().DeleteExpiredMFAChallenges(gomock.Any()).Times(1).Return(int64(0), nil)
+	mockStore.EXPECT().CreateMFAChallenge(gomock.Any(), gomock.Any()).Times(1).Return(sqlc.MfaChallenge{}, nil)
+	// The password alone must not clear the failures counted against the
+	// account; only a completed login does.
+	mockStore.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Any()).Times(0)
+
+	c, recorder := setupGinTest()
+	body, _ := json.Marshal(LoginUserRequest{Username: "testuser", Password: "secret123"})
+	c.Request = httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+	server.LoginUser(c)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var rsp MFAChallengeResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.True(t, rsp.MFARequired)
+}
+
+func TestLockoutDuration(t *testing.T) {
+	require.Equal(t, time.Minute, lockoutDuration(0, time.Minute, time.Hour))
+	require.Equal(t, 8*time.Minute, lockoutDuration(3, time.Minute, time.Hour))
+	require.Equal(t, time.Hour, lockoutDuration(6, time.Minute, time.Hour))
+	require.Equal(t, time.Hour, lockoutDuration(1000, time.Minute, time.Hour))
+}
+
+func TestLoginUserRehashesPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock_sqlc.NewMockQuerier(ctrl)
+	server := setupTestServer(t, mockStore)
+
+	bcryptHasher, err := auth.NewBcryptHasher(bcrypt.MinCost)
+	require.NoError(t, err)
+	oldHash, err := bcryptHasher.Hash("secret123")
+	require.NoError(t, err)
+	user := sqlc.User{ID: 10, Username: "olduser", PasswordHash: oldHash, Role: auth.RoleAuthor}
+
+	mockStore.EXPECT().GetUserByUsername(gomock.Any(), user.Username).Times(1).Return(user, nil)
+	mockStore.EXPECT().
+		RehashUserPassword(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg sqlc.RehashUserPasswordParams) error {
+			require.Equal(t, user.ID, arg.ID)
+			require.Equal(t, oldHash, arg.PasswordHash)
+			require.True(t, strings.HasPrefix(arg.NewPasswordHash, "$argon2id$"))
+			match, needsRehash, err := server.hasher.Verify("secret123", arg.NewPasswordHash)
+			require.NoError(t, err)
+			require.True(t, match)
+			require.False(t, needsRehash)
+			return nil
+		})
+	mockStore.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1).Return(sqlc.Session{}, nil)
+
+	c, recorder := setupGinTest()
+	body, _ := json.Marshal(LoginUserRequest{Username: user.Username, Password: "secret123"})
+	c.Request = httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+	server.LoginUser(c)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestLogoutUserAPI(t *testing.T) {
+	sessionID := uuid.New()
+	newLogoutContext := func(body any) (*gin.Context, *httptest.ResponseRecorder, *auth.Payload) {
+		c, recorder := setupGinTest()
+		var reader io.Reader = http.NoBody
+		if body != nil {
+			data, _ := json.Marshal(body)
+			reader = bytes.NewReader(data)
+		}
+		c.Request = httptest.NewRequest(http.MethodPost, "/logout", reader)
+		payload := &auth.Payload{
+			ID:        10,
+			Username:  "testuser",
+			Role:      auth.RoleAuthor,
+			TokenID:   uuid.New(),
+			SessionID: sessionID,
+			ExpiresAt: time.Now().Add(time.Minute),
+		}
+		c.Set(AuthorizationPayloadKey, payload)
+		return c, recorder, payload
+	}
+
+	t.Run("RevokesTokenAndSession", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, _, payload := newLogoutContext(nil)
+
+		mockStore.EXPECT().
+			RevokeToken(gomock.Any(), sqlc.RevokeTokenParams{
+				Jti:       payload.TokenID,
+				ExpiresAt: pgtype.Timestamptz{Time: payload.ExpiresAt, Valid: true},
+			}).
+			Times(1).
+			Return(nil)
+		mockStore.EXPECT().RevokeSession(gomock.Any(), sessionID).Times(1).Return(nil)
+
+		server.LogoutUser(c)
+
+		require.Equal(t, http.StatusNoContent, c.Writer.Status())
+	})
+
+	t.Run("RevokesOwnRefreshTokenSession", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		otherSession := uuid.New()
+		refreshToken, _, err := auth.NewRefreshToken(otherSession)
+		require.NoError(t, err)
+		c, _, _ := newLogoutContext(LogoutUserRequest{RefreshToken: refreshToken})
+
+		mockStore.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+		mockStore.EXPECT().RevokeSession(gomock.Any(), sessionID).Times(1).Return(nil)
+		mockStore.EXPECT().GetSession(gomock.Any(), otherSession).Times(1).Return(sqlc.Session{ID: otherSession, UserID: 10}, nil)
+		mockStore.EXPECT().RevokeSession(gomock.Any(), otherSession).Times(1).Return(nil)
+
+		server.LogoutUser(c)
+
+		require.Equal(t, http.StatusNoContent, c.Writer.Status())
+	})
+
+	t.Run("IgnoresOthersRefreshToken", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		otherSession := uuid.New()
+		refreshToken, _, err := auth.NewRefreshToken(otherSession)
+		require.NoError(t, err)
+		c, _, _ := newLogoutContext(LogoutUserRequest{RefreshToken: refreshToken})
+
+		mockStore.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+		mockStore.EXPECT().RevokeSession(gomock.Any(), sessionID).Times(1).Return(nil)
+		mockStore.EXPECT().GetSession(gomock.Any(), otherSession).Times(1).Return(sqlc.Session{ID: otherSession, UserID: 11}, nil)
+		mockStore.EXPECT().RevokeSession(gomock.Any(), otherSession).Times(0)
+
+		server.LogoutUser(c)
+
+		require.Equal(t, http.StatusNoContent, c.Writer.Status())
+	})
+
+	t.Run("RevokeError", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder, _ := newLogoutContext(nil)
+
+		mockStore.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(1).Return(sql.ErrConnDone)
+		mockStore.EXPECT().RevokeSession(gomock.Any(), gomock.Any()).Times(0)
+
+		server.LogoutUser(c)
+
+		require.Equal(t, http.StatusInternalServerError, recorder.Code)
+	})
+}
+
+func TestTokenDenylist(t *testing.T) {
+	t.Run("MiddlewareRejectsLoggedOutToken", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		mockStore.EXPECT().ListRevokedTokens(gomock.Any(), gomock.Any()).AnyTimes().Return([]sqlc.RevokedToken{}, nil)
+		mockStore.EXPECT().ListRevokedSessions(gomock.Any(), gomock.Any()).AnyTimes().Return([]sqlc.ListRevokedSessionsRow{}, nil)
+		mockStore.EXPECT().DeleteExpiredRevokedTokens(gomock.Any()).AnyTimes().Return(int64(0), nil)
+		mockStore.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+		mockStore.EXPECT().RevokeSession(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		authRoutes := router.Group("/").Use(AuthMiddleware(server.tokenMaker, server.denylist, server.store, false))
+		authRoutes.GET("/me", func(c *gin.Context) { c.Status(http.StatusOK) })
+		authRoutes.POST("/logout", server.LogoutUser)
+
+		accessToken, err := server.tokenMaker.CreateToken(10, "testuser", auth.RoleAuthor, uuid.New(), time.Minute)
+		require.NoError(t, err)
+		send := func(method, path string) int {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(method, path, nil)
+			req.Header.Set(AuthorizationHeaderKey, "Bearer "+accessToken)
+			router.ServeHTTP(recorder, req)
+			return recorder.Code
+		}
+
+		require.Equal(t, http.StatusOK, send(http.MethodGet, "/me"))
+		require.Equal(t, http.StatusNoContent, send(http.MethodPost, "/logout"))
+		require.Equal(t, http.StatusUnauthorized, send(http.MethodGet, "/me"))
+	})
+
+	t.Run("SyncPicksUpRevocations", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		denylist := NewTokenDenylist(mockStore, time.Hour, time.Minute)
+		payload := &auth.Payload{TokenID: uuid.New(), SessionID: uuid.New(), ExpiresAt: time.Now().Add(time.Minute)}
+		now := pgtype.Timestamptz{Time: time.Now(), Valid: true}
+
+		// The first check loads the denylist; later checks within the sync
+		// interval use the cached copy until a sync is forced.
+		gomock.InOrder(
+			mockStore.EXPECT().ListRevokedTokens(gomock.Any(), gomock.Any()).Return([]sqlc.RevokedToken{}, nil),
+			mockStore.EXPECT().ListRevokedTokens(gomock.Any(), gomock.Any()).Return([]sqlc.RevokedToken{{
+				Jti:       payload.TokenID,
+				ExpiresAt: pgtype.Timestamptz{Time: payload.ExpiresAt, Valid: true},
+				RevokedAt: now,
+			}}, nil),
+			mockStore.EXPECT().ListRevokedTokens(gomock.Any(), gomock.Any()).Return([]sqlc.RevokedToken{}, nil),
+		)
+		gomock.InOrder(
+			mockStore.EXPECT().ListRevokedSessions(gomock.Any(), gomock.Any()).Return([]sqlc.ListRevokedSessionsRow{}, nil),
+			mockStore.EXPECT().ListRevokedSessions(gomock.Any(), gomock.Any()).Return([]sqlc.ListRevokedSessionsRow{}, nil),
+			mockStore.EXPECT().ListRevokedSessions(gomock.Any(), gomock.Any()).Return([]sqlc.ListRevokedSessionsRow{{ID: payload.SessionID, RevokedAt: now}}, nil),
+		)
+		mockStore.EXPECT().DeleteExpiredRevokedTokens(gomock.Any()).Times(1).Return(int64(0), nil)
+
+		ctx := context.Background()
+		for range 2 {
+			revoked, err := denylist.IsRevoked(ctx, payload)
+			require.NoError(t, err)
+			require.False(t, revoked)
+		}
+
+		denylist.ForceSync()
+		revoked, err := denylist.IsRevoked(ctx, payload)
+		require.NoError(t, err)
+		require.True(t, revoked)
+
+		// A revoked session denylists its tokens even when their jti is not.
+		other := &auth.Payload{TokenID: uuid.New(), SessionID: payload.SessionID, ExpiresAt: payload.ExpiresAt}
+		denylist.ForceSync()
+		revoked, err = denylist.IsRevoked(ctx, other)
+		require.NoError(t, err)
+		require.True(t, revoked)
+	})
+}
+
+func TestSessionRevocation(t *testing.T) {
+	revokedSession := uuid.New()
+	activeSession := uuid.New()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock_sqlc.NewMockQuerier(ctrl)
+	server := setupTestServer(t, mockStore)
+	mockStore.EXPECT().ListRevokedTokens(gomock.Any(), gomock.Any()).AnyTimes().Return([]sqlc.RevokedToken{}, nil)
+	mockStore.EXPECT().
+		ListRevokedSessions(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return([]sqlc.ListRevokedSessionsRow{{ID: revokedSession, RevokedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true}}}, nil)
+	mockStore.EXPECT().DeleteExpiredRevokedTokens(gomock.Any()).AnyTimes().Return(int64(0), nil)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/me", AuthMiddleware(server.tokenMaker, server.denylist, server.store, false), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for sessionID, expectedCode := range map[uuid.UUID]int{
+		revokedSession: http.StatusUnauthorized,
+		activeSession:  http.StatusOK,
+	} {
+		accessToken, err := server.tokenMaker.CreateToken(10, "testuser", auth.RoleAuthor, sessionID, time.Minute)
+		require.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set(AuthorizationHeaderKey, "Bearer "+accessToken)
+		router.ServeHTTP(recorder, req)
+		require.Equal(t, expectedCode, recorder.Code)
+	}
+}
+
+func TestSessionsAPI(t *testing.T) {
+	currentSession := uuid.New()
+	newSessionContext := func(method, path string) (*gin.Context, *httptest.ResponseRecorder) {
+		c, recorder := setupGinTest()
+		c.Request = httptest.NewRequest(method, path, nil)
+		c.Set(AuthorizationPayloadKey, &auth.Payload{ID: 10, Username: "testuser", Role: auth.RoleAuthor, SessionID: currentSession})
+		return c, recorder
+	}
+
+	t.Run("List", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newSessionContext(http.MethodGet, "/me/sessions")
+
+		otherSession := uuid.New()
+		mockStore.EXPECT().ListUserSessions(gomock.Any(), int32(10)).Times(1).Return([]sqlc.Session{
+			{ID: otherSession, UserID: 10, UserAgent: "curl/8.0", ClientIp: "198.51.100.4"},
+			{ID: currentSession, UserID: 10, UserAgent: "Firefox", ClientIp: "203.0.113.7"},
+		}, nil)
+
+		server.ListSessions(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var rsp []SessionResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		require.Len(t, rsp, 2)
+		require.Equal(t, "198.51.100.4", rsp[0].IP)
+		require.False(t, rsp[0].Current)
+		require.True(t, rsp[1].Current)
+	})
+
+	t.Run("RevokeNotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		otherSession := uuid.New()
+		c, recorder := newSessionContext(http.MethodDelete, "/me/sessions/"+otherSession.String())
+		c.Params = gin.Params{{Key: "id", Value: otherSession.String()}}
+
+		mockStore.EXPECT().
+			RevokeUserSession(gomock.Any(), sqlc.RevokeUserSessionParams{ID: otherSession, UserID: 10}).
+			Times(1).
+			Return(int64(0), nil)
+
+		server.RevokeSession(c)
+
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("RevokeOthers", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := newSessionContext(http.MethodDelete, "/me/sessions")
+
+		mockStore.EXPECT().
+			RevokeOtherUserSessions(gomock.Any(), sqlc.RevokeOtherUserSessionsParams{UserID: 10, ID: currentSession}).
+			Times(1).
+			Return(int64(3), nil)
+
+		server.RevokeOtherSessions(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var rsp RevokeOtherSessionsResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		require.Equal(t, int64(3), rsp.Revoked)
+	})
+}
+
+func TestCookieAuth(t *testing.T) {
+	t.Run("RenewFromCookie", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		server.config.CookieAuth = true
+		c, recorder := setupGinTest()
+
+		user := sqlc.User{ID: 10, Username: "testuser"}
+		sessionID := uuid.New()
+		refreshToken, refreshTokenHash, err := auth.NewRefreshToken(sessionID)
+		require.NoError(t, err)
+		session := sqlc.Session{
+			ID:               sessionID,
+			UserID:           user.ID,
+			RefreshTokenHash: refreshTokenHash,
+			ExpiresAt:        pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+		}
+		mockStore.EXPECT().GetSession(gomock.Any(), sessionID).Times(1).Return(session, nil)
+		mockStore.EXPECT().RotateSessionToken(gomock.Any(), gomock.Any()).Times(1).Return(session, nil)
+		mockStore.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+
+		c.Request = httptest.NewRequest(http.MethodPost, "/tokens/renew", nil)
+		c.Request.AddCookie(&http.Cookie{Name: RefreshTokenCookieName, Value: refreshToken})
+		c.Request.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: "csrf-token"})
+		server.RenewAccessToken(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.JSONEq(t, `{}`, recorder.Body.String())
+
+		cookies := map[string]*http.Cookie{}
+		for _, cookie := range recorder.Result().Cookies() {
+			cookies[cookie.Name] = cookie
+		}
+		require.NotEmpty(t, cookies[AccessTokenCookieName].Value)
+		require.True(t, cookies[AccessTokenCookieName].HttpOnly)
+		require.NotEqual(t, refreshToken, cookies[RefreshTokenCookieName].Value)
+		require.True(t, cookies[RefreshTokenCookieName].HttpOnly)
+		require.Equal(t, "csrf-token", cookies[CSRFCookieName].Value)
+		require.False(t, cookies[CSRFCookieName].HttpOnly)
+	})
+
+	t.Run("CSRF", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		mockStore.EXPECT().ListRevokedTokens(gomock.Any(), gomock.Any()).AnyTimes().Return([]sqlc.RevokedToken{}, nil)
+		mockStore.EXPECT().ListRevokedSessions(gomock.Any(), gomock.Any()).AnyTimes().Return([]sqlc.ListRevokedSessionsRow{}, nil)
+		mockStore.EXPECT().DeleteExpiredRevokedTokens(gomock.Any()).AnyTimes().Return(int64(0), nil)
+
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(CSRFMiddleware(), AuthMiddleware(server.tokenMaker, server.denylist, server.store, true))
+		router.Any("/me", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		accessToken, err := server.tokenMaker.CreateToken(10, "testuser", auth.RoleAuthor, uuid.New(), time.Minute)
+		require.NoError(t, err)
+
+		testCases := []struct {
+			name         string
+			method       string
+			setupRequest func(req *http.Request)
+			expectedCode int
+		}{
+			{
+				name:   "CookieWithCSRFToken",
+				method: http.MethodPost,
+				setupRequest: func(req *http.Request) {
+					req.AddCookie(&http.Cookie{Name: AccessTokenCookieName, Value: accessToken})
+					req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: "csrf-token"})
+					req.Header.Set(CSRFHeaderName, "csrf-token")
+				},
+				expectedCode: http.StatusOK,
+			},
+			{
+				name:   "CookieWithoutCSRFToken",
+				method: http.MethodPost,
+				setupRequest: func(req *http.Request) {
+					req.AddCookie(&http.Cookie{Name: AccessTokenCookieName, Value: accessToken})
+					req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: "csrf-token"})
+				},
+				expectedCode: http.StatusForbidden,
+			},
+			{
+				name:   "CookieWithWrongCSRFToken",
+				method: http.MethodDelete,
+				setupRequest: func(req *http.Request) {
+					req.AddCookie(&http.Cookie{Name: AccessTokenCookieName, Value: accessToken})
+					req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: "csrf-token"})
+					req.Header.Set(CSRFHeaderName, "forged")
+				},
+				expectedCode: http.StatusForbidden,
+			},
+			{
+				name:   "SafeMethodWithCookie",
+				method: http.MethodGet,
+				setupRequest: func(req *http.Request) {
+					req.AddCookie(&http.Cookie{Name: AccessTokenCookieName, Value: accessToken})
+				},
+				expectedCode: http.StatusOK,
+			},
+			{
+				name:   "BearerHeader",
+				method: http.MethodPost,
+				setupRequest: func(req *http.Request) {
+					req.Header.Set(AuthorizationHeaderKey, "Bearer "+accessToken)
+				},
+				expectedCode: http.StatusOK,
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				recorder := httptest.NewRecorder()
+				req := httptest.NewRequest(tc.method, "/me", nil)
+				tc.setupRequest(req)
+				router.ServeHTTP(recorder, req)
+				require.Equal(t, tc.expectedCode, recorder.Code)
+			})
+		}
+	})
+
+	t.Run("CookieIgnoredWhenDisabled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.GET("/me", AuthMiddleware(server.tokenMaker, server.denylist, server.store, false), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		accessToken, err := server.tokenMaker.CreateToken(10, "testuser", auth.RoleAuthor, uuid.New(), time.Minute)
+		require.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.AddCookie(&http.Cookie{Name: AccessTokenCookieName, Value: accessToken})
+		router.ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	})
+}
+
+func TestListAuditEventsAPI(t *testing.T) {
+	t.Run("Filters", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := setupGinTest()
+		c.Request = httptest.NewRequest(http.MethodGet, "/admin/audit-events?user_id=10&event_type=login&since=2026-01-02T15:04:05Z&limit=20", nil)
+
+		since := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+		mockStore.EXPECT().
+			ListAuditEvents(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg sqlc.ListAuditEventsParams) ([]sqlc.AuditEvent, error) {
+				require.Equal(t, pgtype.Int4{Int32: 10, Valid: true}, arg.UserID)
+				require.Equal(t, pgtype.Text{String: audit.EventLogin, Valid: true}, arg.EventType)
+				require.True(t, since.Equal(arg.Since.Time))
+				require.False(t, arg.Until.Valid)
+				require.Equal(t, int32(20), arg.Limit)
+				require.Equal(t, int32(0), arg.Offset)
+				return []sqlc.AuditEvent{{
+					ID:        1,
+					EventType: audit.EventLogin,
+					Outcome:   audit.OutcomeFailure,
+					UserID:    pgtype.Int4{Int32: 10, Valid: true},
+					Username:  "testuser",
+					Ip:        "203.0.113.7",
+					Details:   "wrong_password",
+				}}, nil
+			})
+
+		server.ListAuditEvents(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var rsp []AuditEventResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		require.Len(t, rsp, 1)
+		require.Equal(t, int32(10), *rsp[0].UserID)
+		require.Nil(t, rsp[0].ActorID)
+		require.Equal(t, "wrong_password", rsp[0].Details)
+	})
+
+	t.Run("UnknownEventType", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		c, recorder := setupGinTest()
+		c.Request = httptest.NewRequest(http.MethodGet, "/admin/audit-events?event_type=nope", nil)
+
+		mockStore.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Times(0)
+
+		server.ListAuditEvents(c)
+
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
+
+func TestAccountDeletionAPI(t *testing.T) {
+	newAccountContext := func(method, path string, body any) (*gin.Context, *httptest.ResponseRecorder) {
+		c, recorder := setupGinTest()
+		var reader io.Reader
+		if body != nil {
+			data, _ := json.Marshal(body)
+			reader = bytes.NewReader(data)
+		}
+		c.Request = httptest.NewRequest(method, path, reader)
+		c.Set(AuthorizationPayloadKey, &auth.Payload{ID: 10, Username: "testuser", Role: auth.RoleAuthor})
+		return c, recorder
+	}
+
+	t.Run("Schedule", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		server.config.AccountDeletionGracePeriod = 24 * time.Hour
+		hash, err := server.hasher.Hash("secret123")
+		require.NoError(t, err)
+		user := sqlc.User{ID: 10, Username: "testuser", PasswordHash: hash}
+
+		mockStore.EXPECT().GetUserByID(gomock.Any(), int32(10)).Times(1).Return(user, nil)
+		mockStore.EXPECT().
+			ScheduleUserDeletion(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg sqlc.ScheduleUserDeletionParams) (sqlc.User, error) {
+				require.Equal(t, user.ID, arg.ID)
+				require.WithinDuration(t, time.Now().Add(24*time.Hour), arg.DeletionScheduledAt.Time, time.Second)
+				scheduled := user
+				scheduled.DeletionScheduledAt = arg.DeletionScheduledAt
+				return scheduled, nil
+			})
+
+		c, recorder := newAccountContext(http.MethodDelete, "/me", DeleteAccountRequest{Password: "secret123"})
+		server.DeleteAccount(c)
+
+		require.Equal(t, http.StatusAccepted, recorder.Code)
+		var rsp DeleteAccountResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		require.WithinDuration(t, time.Now().Add(24*time.Hour), rsp.DeletionScheduledAt, time.Second)
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		hash, err := server.hasher.Hash("secret123")
+		require.NoError(t, err)
+
+		mockStore.EXPECT().GetUserByID(gomock.Any(), int32(10)).Times(1).Return(sqlc.User{ID: 10, Username: "testuser", PasswordHash: hash}, nil)
+		mockStore.EXPECT().DeleteStaleLoginFailures(gomock.Any(), gomock.Any()).AnyTimes().Return(int64(0), nil)
+		mockStore.EXPECT().ScheduleUserDeletion(gomock.Any(), gomock.Any()).Times(0)
+
+		c, recorder := newAccountContext(http.MethodDelete, "/me", DeleteAccountRequest{Password: "wrong"})
+		server.DeleteAccount(c)
+
+		require.Equal(t, http.StatusForbidden, recorder.Code)
+	})
+
+	t.Run("OIDCAccount", func(t *testing.T) {
+		sessionID := uuid.New()
+		secret, err := auth.NewTOTPSecret()
+		require.NoError(t, err)
+		code, err := auth.TOTPCode(secret, auth.TOTPStep(time.Now()))
+		require.NoError(t, err)
+
+		testCases := []struct {
+			name         string
+			totpEnabled  bool
+			code         string
+			sessionAge   time.Duration
+			expectedCode int
+		}{
+			{name: "RecentLogin", sessionAge: time.Minute, expectedCode: http.StatusAccepted},
+			{name: "LoginTooOld", sessionAge: time.Hour, expectedCode: http.StatusForbidden},
+			{name: "Code", totpEnabled: true, code: code, sessionAge: time.Hour, expectedCode: http.StatusAccepted},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				mockStore := mock_sqlc.NewMockQuerier(ctrl)
+				server := setupTestServer(t, mockStore)
+				user := sqlc.User{ID: 10, Username: "testuser", PasswordHash: oidcPasswordHash}
+				if tc.totpEnabled {
+					user.TotpSecret = pgtype.Text{String: secret, Valid: true}
+					user.TotpEnabledAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+				}
+
+				mockStore.EXPECT().GetUserByID(gomock.Any(), int32(10)).Times(1).Return(user, nil)
+				if tc.totpEnabled {
+					mockStore.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+				} else {
+					mockStore.EXPECT().
+						GetSession(gomock.Any(), sessionID).
+						Times(1).
+						Return(sqlc.Session{ID: sessionID, UserID: 10, CreatedAt: pgtype.Timestamptz{Time: time.Now().Add(-tc.sessionAge), Valid: true}}, nil)
+				}
+				// Without a password there is nothing to guess, so a refusal
+				// must not lock the user out.
+				mockStore.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(0)
+				expectedSchedules := 0
+				if tc.expectedCode == http.StatusAccepted {
+					expectedSchedules = 1
+				}
+				mockStore.EXPECT().ScheduleUserDeletion(gomock.Any(), gomock.Any()).Times(expectedSchedules).Return(user, nil)
+
+				c, recorder := newAccountContext(http.MethodDelete, "/me", DeleteAccountRequest{Code: tc.code})
+				c.Set(AuthorizationPayloadKey, &auth.Payload{ID: 10, SessionID: sessionID, Username: "testuser", Role: auth.RoleAuthor})
+				server.DeleteAccount(c)
+
+				require.Equal(t, tc.expectedCode, recorder.Code)
+			})
+		}
+	})
+
+	t.Run("CancelWithoutScheduledDeletion", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+
+		mockStore.EXPECT().GetUserByID(gomock.Any(), int32(10)).Times(1).Return(sqlc.User{ID: 10, Username: "testuser"}, nil)
+		mockStore.EXPECT().CancelUserDeletion(gomock.Any(), int32(10)).Times(1).Return(int64(0), nil)
+
+		c, recorder := newAccountContext(http.MethodDelete, "/me/deletion", nil)
+		server.CancelAccountDeletion(c)
+
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("Export", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+
+		mockStore.EXPECT().GetUserByID(gomock.Any(), int32(10)).Times(1).Return(sqlc.User{ID: 10, Username: "testuser"}, nil)
+		mockStore.EXPECT().ListUserPosts(gomock.Any(), int32(10)).Times(1).Return([]sqlc.Post{{ID: 1, UserID: 10, Title: "Hello", Content: "World"}}, nil)
+		mockStore.EXPECT().
+			ListUserComments(gomock.Any(), pgtype.Int4{Int32: 10, Valid: true}).
+			Times(1).
+			Return([]sqlc.Comment{{ID: 3, PostID: 1, UserID: pgtype.Int4{Int32: 10, Valid: true}, Content: "Nice"}}, nil)
+		mockStore.EXPECT().ListUserReactions(gomock.Any(), int32(10)).Times(1).Return([]sqlc.PostReaction{{PostID: 2, UserID: 10, Kind: "like"}}, nil)
+		mockStore.EXPECT().ListUserSessions(gomock.Any(), int32(10)).Times(1).Return([]sqlc.Session{}, nil)
+		mockStore.EXPECT().ListPersonalAccessTokens(gomock.Any(), int32(10)).Times(1).Return([]sqlc.PersonalAccessToken{}, nil)
+
+		c, recorder := newAccountContext(http.MethodGet, "/me/export", nil)
+		server.ExportAccount(c)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, `attachment; filename="plog-export-testuser.json"`, recorder.Header().Get("Content-Disposition"))
+		var export AccountExport
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &export))
+		require.Equal(t, "testuser", export.Profile.Username)
+		require.Len(t, export.Posts, 1)
+		require.Equal(t, "Hello", export.Posts[0].Title)
+		require.Len(t, export.Comments, 1)
+		require.Equal(t, "Nice", export.Comments[0].Content)
+		require.Len(t, export.Reactions, 1)
+		require.Equal(t, "like", export.Reactions[0].Kind)
+	})
+}
+
+func TestDeleteDueAccounts(t *testing.T) {
+	t.Run("Anonymize", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		server.config.AccountDeletionMode = config.AccountDeletionAnonymize
+
+		mockStore.EXPECT().AnonymizeDueUsers(gomock.Any()).Times(1).Return([]sqlc.AnonymizeDueUsersRow{{ID: 10, Username: "testuser"}}, nil)
+		mockStore.EXPECT().DeleteDueUsers(gomock.Any(), gomock.Any()).Times(0)
+
+		server.deleteDueAccounts(context.Background())
+
+		events := server.auditor.(*auditRecorder).events
+		require.Len(t, events, 1)
+		require.Equal(t, audit.EventAccountDelete, events[0].Type)
+		require.Equal(t, int32(10), events[0].UserID)
+		require.Equal(t, "testuser", events[0].Username)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		server.config.AccountDeletionMode = config.AccountDeletionDelete
+
+		gomock.InOrder(
+			mockStore.EXPECT().RevokeDueUserSessions(gomock.Any()).Times(1).Return(int64(2), nil),
+			mockStore.EXPECT().
+				DeleteDueUsers(gomock.Any(), gomock.Any()).
+				Times(1).
+				DoAndReturn(func(_ context.Context, revokedBefore pgtype.Timestamptz) ([]sqlc.DeleteDueUsersRow, error) {
+					// Only sessions revoked an access token lifetime ago let their account go.
+					require.WithinDuration(t, time.Now().Add(-server.config.AccessTokenDuration), revokedBefore.Time, time.Second)
+					return []sqlc.DeleteDueUsersRow{{ID: 10, Username: "testuser"}}, nil
+				}),
+		)
+		mockStore.EXPECT().AnonymizeDueUsers(gomock.Any()).Times(0)
+
+		server.deleteDueAccounts(context.Background())
+
+		events := server.auditor.(*auditRecorder).events
+		require.Len(t, events, 1)
+		require.Equal(t, audit.EventAccountDelete, events[0].Type)
+		require.Equal(t, "testuser", events[0].Username)
+	})
+
+	t.Run("RevokeFails", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_sqlc.NewMockQuerier(ctrl)
+		server := setupTestServer(t, mockStore)
+		server.config.AccountDeletionMode = config.AccountDeletionDelete
+
+		mockStore.EXPECT().RevokeDueUserSessions(gomock.Any()).Times(1).Return(int64(0), fmt.Errorf("some database error"))
+		mockStore.EXPECT().DeleteDueUsers(gomock.Any(), gomock.Any()).Times(0)
+
+		server.deleteDueAccounts(context.Background())
+
+		require.Empty(t, server.auditor.(*auditRecorder).events)
+	})
 }
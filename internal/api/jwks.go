@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lshigami/Plog/internal/auth"
+)
+
+// GetJWKS godoc
+// @Summary Get the token verification keys
+// @Description Public keys (JWKS) that verify access tokens. Only available when tokens are signed with an asymmetric algorithm (TOKEN_MAKER=jwt_eddsa or jwt_rs256).
+// @Tags authentication
+// @Produce json
+// @Success 200 {object} auth.JWKS "JSON Web Key Set"
+// @Failure 404 {object} map[string]string "Tokens are not signed with public keys"
+// @Router /.well-known/jwks.json [get]
+func (server *Server) GetJWKS(c *gin.Context) {
+	provider, ok := server.tokenMaker.(auth.JWKSProvider)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Resource not found"})
+		return
+	}
+
+	// Verifiers should refetch on an unknown kid, so a short cache is enough.
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, provider.JWKS())
+}
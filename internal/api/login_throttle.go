@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/lshigami/Plog/internal/db/sqlc"
+)
+
+const (
+	loginScopeUsername = "username"
+	loginScopeIP       = "ip"
+
+	loginThrottlePurgeInterval = time.Hour
+)
+
+// LoginThrottle counts failed password logins per username and per client IP
+// in Postgres, so that all instances share them. Once a username or an IP
+// reaches its limit, further attempts are refused for a lockout that doubles
+// with every additional failure, up to maxLockout. Failures are
+// forgotten maxLockout after the last one.
+type LoginThrottle struct {
+	store            sqlc.Querier
+	maxFailures      int
+	maxFailuresPerIP int
+	lockout          time.Duration
+	maxLockout       time.Duration
+
+	mu       sync.Mutex
+	purgedAt time.Time
+}
+
+// NewLoginThrottle returns a LoginThrottle. A limit of zero or less turns
+// off tracking for that scope.
+func NewLoginThrottle(store sqlc.Querier, maxFailures, maxFailuresPerIP int, lockout, maxLockout time.Duration) *LoginThrottle {
+	return &LoginThrottle{
+		store:            store,
+		maxFailures:      maxFailures,
+		maxFailuresPerIP: maxFailuresPerIP,
+		lockout:          lockout,
+		maxLockout:       maxLockout,
+	}
+}
+
+func (t *LoginThrottle) enabled() bool {
+	return t.maxFailures > 0 || t.maxFailuresPerIP > 0
+}
+
+// Check returns the scope that currently blocks logins for username from ip
+// and until when, or an empty scope if logins are allowed. When both are
+// blocked, the longer lockout wins.
+func (t *LoginThrottle) Check(ctx context.Context, username, ip string) (string, time.Time, error) {
+	if !t.enabled() {
+		return "", time.Time{}, nil
+	}
+	lockouts, err := t.store.GetLoginLockouts(ctx, sqlc.GetLoginLockoutsParams{Username: username, Ip: ip})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	var scope string
+	var until time.Time
+	for _, lockout := range lockouts {
+		if lockout.LockedUntil.Time.After(until) {
+			scope, until = lockout.Scope, lockout.LockedUntil.Time
+		}
+	}
+	return scope, until, nil
+}
+
+// RecordFailure counts a failed login for username from ip and starts a
+// lockout for each scope that went over its limit. Each scope is recorded
+// even if the other fails, so that an unusual username cannot keep its
+// client IP from being counted.
+func (t *LoginThrottle) RecordFailure(ctx context.Context, username, ip string) error {
+	ipErr := t.recordFailure(ctx, loginScopeIP, ip, t.maxFailuresPerIP)
+	usernameErr := t.recordFailure(ctx, loginScopeUsername, username, t.maxFailures)
+	t.purge(ctx)
+	return errors.Join(ipErr, usernameErr)
+}
+
+func (t *LoginThrottle) recordFailure(ctx context.Context, scope, key string, maxFailures int) error {
+	if maxFailures <= 0 {
+		return nil
+	}
+	now := time.Now()
+	failures, err := t.store.RecordLoginFailure(ctx, sqlc.RecordLoginFailureParams{
+		Scope:       scope,
+		Key:         key,
+		ResetBefore: pgtype.Timestamptz{Time: now.Add(-t.maxLockout), Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	if int(failures) < maxFailures {
+		return nil
+	}
+	return t.store.LockLogin(ctx, sqlc.LockLoginParams{
+		Scope:       scope,
+		Key:         key,
+		LockedUntil: pgtype.Timestamptz{Time: now.Add(lockoutDuration(int(failures)-maxFailures, t.lockout, t.maxLockout)), Valid: true},
+	})
+}
+
+// Reset forgets the failed logins of username, after it logged in or an admin
+// unlocked it. Failures of the client IP are kept.
+func (t *LoginThrottle) Reset(ctx context.Context, username string) error {
+	if t.maxFailures <= 0 {
+		return nil
+	}
+	return t.store.ClearLoginFailures(ctx, sqlc.ClearLoginFailuresParams{Scope: loginScopeUsername, Key: username})
+}
+
+func (t *LoginThrottle) purge(ctx context.Context) {
+	t.mu.Lock()
+	now := time.Now()
+	due := now.Sub(t.purgedAt) >= loginThrottlePurgeInterval
+	if due {
+		t.purgedAt = now
+	}
+	t.mu.Unlock()
+	if !due {
+		return
+	}
+
+	if _, err := t.store.DeleteStaleLoginFailures(ctx, pgtype.Timestamptz{Time: now.Add(-t.maxLockout), Valid: true}); err != nil {
+		log.Printf("Warning: could not purge stale login failures: %v", err)
+	}
+}
+
+// lockoutDuration returns how long to lock out after excess failures beyond
+// the limit: base for the failure that reached it, doubling for each one
+// after that, capped at limit.
+func lockoutDuration(excess int, base, limit time.Duration) time.Duration {
+	d := base
+	for i := 0; i < excess && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
@@ -1,11 +1,15 @@
 package api
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lshigami/Plog/internal/auth"
+	"github.com/lshigami/Plog/internal/db/sqlc"
 )
 
 const (
@@ -15,13 +19,21 @@ const (
 	UserIDKey               = "user_id"
 )
 
-func AuthMiddleware(maker auth.Maker) gin.HandlerFunc {
+// AuthMiddleware accepts access tokens issued by maker as well as personal
+// access tokens, which are looked up in store. With cookieAuth, an access
+// token in the AccessTokenCookieName cookie is accepted when the request has
+// no Authorization header.
+func AuthMiddleware(maker auth.Maker, denylist *TokenDenylist, store sqlc.Querier, cookieAuth bool) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 
 		authorizationHeader := ctx.GetHeader(AuthorizationHeaderKey)
 		if len(authorizationHeader) == 0 {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
-			return
+			cookie, err := ctx.Cookie(AccessTokenCookieName)
+			if !cookieAuth || err != nil || cookie == "" {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
+				return
+			}
+			authorizationHeader = AuthorizationTypeBearer + " " + cookie
 		}
 		fields := strings.Fields(authorizationHeader)
 
@@ -37,14 +49,105 @@ func AuthMiddleware(maker auth.Maker) gin.HandlerFunc {
 		}
 
 		accessToken := fields[1]
+		if auth.IsPersonalAccessToken(accessToken) {
+			authenticatePersonalAccessToken(ctx, store, accessToken)
+			return
+		}
+
 		claims, err := maker.VerifyToken(accessToken)
 		if err != nil {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
+
+		revoked, err := denylist.IsRevoked(ctx.Request.Context(), claims)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check token revocation"})
+			return
+		}
+		if revoked {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			return
+		}
 		ctx.Set(AuthorizationPayloadKey, claims)
 		ctx.Set(UserIDKey, claims.ID)
 		ctx.Next()
 
 	}
 }
+
+func authenticatePersonalAccessToken(ctx *gin.Context, store sqlc.Querier, token string) {
+	pat, err := store.UsePersonalAccessToken(ctx.Request.Context(), auth.HashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid, expired or revoked personal access token"})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check personal access token"})
+		return
+	}
+
+	claims := &auth.Payload{
+		ID:                    pat.UserID,
+		Username:              pat.Username,
+		Role:                  pat.Role,
+		ExpiresAt:             pat.ExpiresAt.Time,
+		PersonalAccessTokenID: pat.ID,
+		Scopes:                pat.Scopes,
+	}
+	ctx.Set(AuthorizationPayloadKey, claims)
+	ctx.Set(UserIDKey, claims.ID)
+	ctx.Next()
+}
+
+// RequireScope only lets personal access tokens through that were granted
+// scope. Session tokens always pass. It must run after AuthMiddleware.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := ctx.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+		if !payload.HasScope(scope) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Personal access token is missing scope " + scope})
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// RequireSessionToken rejects personal access tokens, for endpoints that
+// manage the account itself. It must run after AuthMiddleware.
+func RequireSessionToken() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := ctx.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+		if payload.IsPersonalAccessToken() {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Personal access tokens cannot be used for this endpoint"})
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// RequireRole only lets requests through whose token carries one of roles.
+// It must run after AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := ctx.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+		if !slices.Contains(roles, payload.Role) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You don't have permission to perform this action"})
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// RequirePermission only lets requests through whose role grants permission.
+// It must run after AuthMiddleware.
+func RequirePermission(permission auth.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := ctx.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+		if !auth.HasPermission(payload.Role, permission) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You don't have permission to perform this action"})
+			return
+		}
+		ctx.Next()
+	}
+}
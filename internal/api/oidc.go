@@ -0,0 +1,366 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/lshigami/Plog/internal/audit"
+	"github.com/lshigami/Plog/internal/auth"
+	"github.com/lshigami/Plog/internal/config"
+	"github.com/lshigami/Plog/internal/db/sqlc"
+	"github.com/lshigami/Plog/internal/oidc"
+)
+
+const (
+	oidcStateBytes      = 32
+	oidcAuthRequestTTL  = 10 * time.Minute
+	oidcUsernameRetries = 5
+
+	// OIDCStateCookieName holds the state of a login started in this
+	// browser, so that a callback URL from someone else's login is refused.
+	OIDCStateCookieName = "plog_oidc_state"
+	oidcStateCookiePath = "/api/v1/oidc"
+	// oidcAppCallbackPath is the page of the frontend that OIDCCallback
+	// redirects to. It reads the outcome from the URL fragment.
+	oidcAppCallbackPath = "/oidc/callback"
+
+	// oidcPasswordHash marks accounts created through OIDC. It is not a valid
+	// hash, so password login fails until the user sets a password with a
+	// reset link.
+	oidcPasswordHash = "!"
+	// reauthenticationWindow is how recently the user of such an account
+	// must have signed in to confirm a sensitive change, unless they have a
+	// second factor.
+	reauthenticationWindow = 10 * time.Minute
+
+	errReauthenticationRequired = "Sign in again to confirm this change"
+)
+
+var errEmailAccountExists = errors.New("an account with this email address exists but the address is not verified")
+
+func newOIDCProviders(cfg config.Config) map[string]*oidc.Provider {
+	providers := make(map[string]*oidc.Provider, len(cfg.OIDCProviders))
+	for _, p := range cfg.OIDCProviders {
+		providers[p.Name] = oidc.NewProvider(oidc.Config{
+			Name:         p.Name,
+			IssuerURL:    p.IssuerURL,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			Scopes:       p.Scopes,
+			RedirectURL:  cfg.AppBaseURL + "/api/v1/oidc/" + p.Name + "/callback",
+		})
+	}
+	return providers
+}
+
+// setOIDCStateCookie binds a login to the browser that started it. The
+// cookie is Lax, not Strict: the provider's redirect back is a cross-site
+// navigation.
+func (server *Server) setOIDCStateCookie(c *gin.Context, state string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     OIDCStateCookieName,
+		Value:    state,
+		Path:     oidcStateCookiePath,
+		Domain:   server.config.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   server.config.CookieSecure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// redirectToApp ends a login with a provider by sending the browser back to
+// the frontend, with the outcome in the URL fragment. Fragments are not sent
+// to servers, so the tokens stay out of access logs and Referer headers.
+func (server *Server) redirectToApp(c *gin.Context, fragment url.Values) {
+	target := server.config.AppBaseURL + oidcAppCallbackPath
+	if len(fragment) > 0 {
+		target += "#" + fragment.Encode()
+	}
+	c.Redirect(http.StatusFound, target)
+}
+
+// ListOIDCProviders godoc
+// @Summary List OpenID Connect providers
+// @Description List the names of the providers users can sign in with.
+// @Tags authentication
+// @Produce json
+// @Success 200 {array} string "Provider names"
+// @Router /oidc/providers [get]
+func (server *Server) ListOIDCProviders(c *gin.Context) {
+	names := make([]string, 0, len(server.oidcProviders))
+	for name := range server.oidcProviders {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	c.JSON(http.StatusOK, names)
+}
+
+// OIDCLogin godoc
+// @Summary Sign in with an OpenID Connect provider
+// @Description Redirect to the provider's login page (authorization code flow with PKCE). The provider redirects back to /oidc/{provider}/callback, which only accepts the login in the browser that started it.
+// @Tags authentication
+// @Param provider path string true "Provider name from OIDC_PROVIDERS"
+// @Success 302 "Redirect to the provider"
+// @Failure 404 {object} map[string]string "Unknown provider"
+// @Failure 502 {object} map[string]string "Provider unavailable"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /oidc/{provider}/login [get]
+func (server *Server) OIDCLogin(c *gin.Context) {
+	provider, ok := server.oidcProviders[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown OIDC provider"})
+		return
+	}
+
+	state, err := auth.RandomToken(oidcStateBytes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create state"})
+		return
+	}
+	nonce, err := auth.RandomToken(oidcStateBytes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create nonce"})
+		return
+	}
+	verifier := oidc.GenerateVerifier()
+
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), state, nonce, verifier)
+	if err != nil {
+		log.Printf("Warning: OIDC provider %s unavailable: %v", provider.Name(), err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "OIDC provider unavailable"})
+		return
+	}
+
+	if _, err := server.store.DeleteExpiredOIDCAuthRequests(c.Request.Context()); err != nil {
+		log.Printf("Warning: could not delete expired OIDC auth requests: %v", err)
+	}
+	err = server.store.CreateOIDCAuthRequest(c.Request.Context(), sqlc.CreateOIDCAuthRequestParams{
+		StateHash:    auth.HashToken(state),
+		Provider:     provider.Name(),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    pgtype.Timestamptz{Time: time.Now().Add(oidcAuthRequestTTL), Valid: true},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save OIDC request: " + err.Error()})
+		return
+	}
+
+	server.setOIDCStateCookie(c, state, int(oidcAuthRequestTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback godoc
+// @Summary Finish signing in with an OpenID Connect provider
+// @Description Called by the provider after login, in the browser that started it. Verifies the ID token, finds the Plog account linked to the provider account (linking by verified email or creating a new account on first login) and redirects to the frontend's /oidc/callback page. The URL fragment carries access_token and refresh_token (none with cookie authentication, which sets the cookies instead), mfa_token when a second factor is required, or error.
+// @Tags authentication
+// @Param provider path string true "Provider name from OIDC_PROVIDERS"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State from the login redirect"
+// @Success 302 "Redirect to the frontend"
+// @Failure 404 {object} map[string]string "Unknown provider"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /oidc/{provider}/callback [get]
+func (server *Server) OIDCCallback(c *gin.Context) {
+	provider, ok := server.oidcProviders[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown OIDC provider"})
+		return
+	}
+	cookieState, _ := c.Cookie(OIDCStateCookieName)
+	server.setOIDCStateCookie(c, "", -1)
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		server.redirectToApp(c, url.Values{"error": {"OIDC login failed: " + providerErr}})
+		return
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		server.redirectToApp(c, url.Values{"error": {"Missing code or state"}})
+		return
+	}
+	// Without this check anyone could log a victim into the attacker's
+	// account by sending them the callback URL of the attacker's own login.
+	if cookieState == "" || subtle.ConstantTimeCompare([]byte(cookieState), []byte(state)) != 1 {
+		server.audit(c, audit.Event{Type: audit.EventLoginOIDC, Outcome: audit.OutcomeFailure, Details: provider.Name() + " state_mismatch"})
+		server.redirectToApp(c, url.Values{"error": {"Invalid or expired state"}})
+		return
+	}
+
+	// Deleting the request makes the state single use.
+	request, err := server.store.ConsumeOIDCAuthRequest(c.Request.Context(), auth.HashToken(state))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			server.redirectToApp(c, url.Values{"error": {"Invalid or expired state"}})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get OIDC request: " + err.Error()})
+		return
+	}
+	if request.Provider != provider.Name() {
+		server.redirectToApp(c, url.Values{"error": {"Invalid or expired state"}})
+		return
+	}
+
+	claims, err := provider.Exchange(c.Request.Context(), code, request.Nonce, request.CodeVerifier)
+	if err != nil {
+		log.Printf("Warning: OIDC login with %s failed: %v", provider.Name(), err)
+		server.audit(c, audit.Event{Type: audit.EventLoginOIDC, Outcome: audit.OutcomeFailure, Details: provider.Name()})
+		server.redirectToApp(c, url.Values{"error": {"OIDC login failed"}})
+		return
+	}
+
+	user, err := server.userForIdentity(c.Request.Context(), provider.Name(), claims)
+	if err != nil {
+		if errors.Is(err, errEmailAccountExists) {
+			server.redirectToApp(c, url.Values{"error": {"An account with this email address already exists. Sign in with your password and verify the address to link it."}})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign in: " + err.Error()})
+		return
+	}
+
+	if user.TotpEnabledAt.Valid {
+		server.audit(c, audit.Event{Type: audit.EventLoginOIDC, Outcome: audit.OutcomeSuccess, UserID: user.ID, Username: user.Username, Details: provider.Name() + " mfa_required"})
+		challenge, ok := server.newMFAChallenge(c, user)
+		if !ok {
+			return
+		}
+		server.redirectToApp(c, url.Values{"mfa_token": {challenge.MFAToken}})
+		return
+	}
+	rsp, ok := server.newLogin(c, user, audit.EventLoginOIDC)
+	if !ok {
+		return
+	}
+	fragment := url.Values{}
+	if rsp.AccessToken != "" {
+		fragment.Set("access_token", rsp.AccessToken)
+		fragment.Set("refresh_token", rsp.RefreshToken)
+	}
+	server.redirectToApp(c, fragment)
+}
+
+// userForIdentity returns the user linked to the provider account. On first
+// login the account is linked to the user with the same email address if both
+// the provider and Plog have verified it, or a new user is created.
+func (server *Server) userForIdentity(ctx context.Context, provider string, claims *oidc.Claims) (sqlc.User, error) {
+	email := pgtype.Text{}
+	if claims.Email != "" && claims.EmailVerified {
+		email = pgtype.Text{String: normalizeEmail(claims.Email), Valid: true}
+	}
+
+	identity, err := server.store.GetIdentity(ctx, sqlc.GetIdentityParams{Provider: provider, Subject: claims.Subject})
+	if err == nil {
+		if err := server.store.TouchIdentity(ctx, sqlc.TouchIdentityParams{ID: identity.ID, Email: email}); err != nil {
+			log.Printf("Warning: could not update identity %d: %v", identity.ID, err)
+		}
+		return server.store.GetUserByID(ctx, identity.UserID)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return sqlc.User{}, err
+	}
+
+	var user sqlc.User
+	if email.Valid {
+		user, err = server.store.GetUserByEmail(ctx, email)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return sqlc.User{}, err
+		}
+		// Linking to an address nobody proved to own would let whoever
+		// registered it first take over the provider account.
+		if err == nil && !user.EmailVerifiedAt.Valid {
+			return sqlc.User{}, errEmailAccountExists
+		}
+	}
+	if user.ID == 0 {
+		user, err = server.createOIDCUser(ctx, claims, email)
+		if err != nil {
+			return sqlc.User{}, err
+		}
+	}
+
+	_, err = server.store.CreateIdentity(ctx, sqlc.CreateIdentityParams{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    email,
+	})
+	if err != nil {
+		return sqlc.User{}, err
+	}
+	return user, nil
+}
+
+// createOIDCUser creates a user without a password for a first OIDC login.
+func (server *Server) createOIDCUser(ctx context.Context, claims *oidc.Claims, email pgtype.Text) (sqlc.User, error) {
+	base := oidcUsernameBase(claims)
+	for attempt := 0; attempt < oidcUsernameRetries; attempt++ {
+		username := base
+		if attempt > 0 {
+			suffix, err := rand.Int(rand.Reader, big.NewInt(10000))
+			if err != nil {
+				return sqlc.User{}, err
+			}
+			username = fmt.Sprintf("%s%04d", base[:min(len(base), 46)], suffix.Int64())
+		}
+
+		user, err := server.store.CreateUser(ctx, sqlc.CreateUserParams{
+			Username:     username,
+			PasswordHash: oidcPasswordHash,
+			Email:        email,
+		})
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			continue
+		}
+		if err != nil {
+			return sqlc.User{}, err
+		}
+
+		if email.Valid {
+			// The provider has verified the address already.
+			verified, err := server.store.MarkUserEmailVerified(ctx, sqlc.MarkUserEmailVerifiedParams{ID: user.ID, Email: email})
+			if err != nil {
+				log.Printf("Warning: could not mark email of user %d as verified: %v", user.ID, err)
+			} else {
+				user = verified
+			}
+		}
+		return user, nil
+	}
+	return sqlc.User{}, errors.New("could not find a free username")
+}
+
+// oidcUsernameBase derives a valid username from the provider's claims.
+func oidcUsernameBase(claims *oidc.Claims) string {
+	candidates := []string{claims.PreferredUsername, strings.Split(claims.Email, "@")[0], claims.Name}
+	for _, candidate := range candidates {
+		username := strings.Map(func(r rune) rune {
+			if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				return r
+			}
+			return -1
+		}, candidate)
+		if len(username) >= 3 {
+			return username[:min(len(username), 50)]
+		}
+	}
+	return "user"
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/lshigami/Plog/internal/audit"
+	"github.com/lshigami/Plog/internal/auth"
+	"github.com/lshigami/Plog/internal/db/sqlc"
+	"github.com/lshigami/Plog/internal/mail"
+)
+
+const passwordResetTokenBytes = 32
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Email a single-use password reset link to the account with this address, if it has been verified. The response is the same whether or not such an account exists.
+// @Tags authentication
+// @Accept json
+// @Produce json
+// @Param request body ForgotPasswordRequest true "Account email"
+// @Success 202 {object} map[string]string "Reset link sent if the account exists"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /password/forgot [post]
+func (server *Server) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	accepted := gin.H{"message": "If an account with that email exists, a password reset link has been sent"}
+
+	user, err := server.store.GetUserByEmail(c.Request.Context(), pgtype.Text{String: normalizeEmail(req.Email), Valid: true})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusAccepted, accepted)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user: " + err.Error()})
+		return
+	}
+
+	// Only an address the user has proven to own may receive reset links.
+	if !user.EmailVerifiedAt.Valid {
+		server.audit(c, audit.Event{Type: audit.EventPasswordResetSent, Outcome: audit.OutcomeFailure, UserID: user.ID, Username: user.Username, Details: "unverified_email"})
+		c.JSON(http.StatusAccepted, accepted)
+		return
+	}
+
+	token, err := auth.RandomToken(passwordResetTokenBytes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reset token"})
+		return
+	}
+	_, err = server.store.CreatePasswordResetToken(c.Request.Context(), sqlc.CreatePasswordResetTokenParams{
+		UserID:    user.ID,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(server.config.PasswordResetTTL), Valid: true},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reset token: " + err.Error()})
+		return
+	}
+
+	server.audit(c, audit.Event{Type: audit.EventPasswordResetSent, Outcome: audit.OutcomeSuccess, UserID: user.ID, Username: user.Username})
+
+	link := server.config.AppBaseURL + "/reset-password?token=" + url.QueryEscape(token)
+	server.sendMail(mail.Message{
+		To:      user.Email.String,
+		Subject: "Reset your Plog password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in %s and can only be used once.\n\n%s\n\nIf you did not ask for a password reset, you can ignore this email.\n",
+			user.Username, server.config.PasswordResetTTL, link),
+	})
+
+	c.JSON(http.StatusAccepted, accepted)
+}
+
+// ResetPassword godoc
+// @Summary Reset a password
+// @Description Set a new password with a token from a password reset email. The token can only be used once, and every session and personal access token of the account is revoked.
+// @Tags authentication
+// @Accept json
+// @Param request body ResetPasswordRequest true "Reset token and new password"
+// @Success 204 "Password changed"
+// @Failure 400 {object} map[string]string "Invalid input or invalid/expired token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /password/reset [post]
+func (server *Server) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	// Consuming the token is a single conditional UPDATE, so two concurrent
+	// requests cannot both redeem it.
+	resetToken, err := server.store.ConsumePasswordResetToken(c.Request.Context(), auth.HashToken(req.Token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			server.audit(c, audit.Event{Type: audit.EventPasswordReset, Outcome: audit.OutcomeFailure, Details: "invalid_token"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to redeem reset token: " + err.Error()})
+		return
+	}
+
+	hashedPassword, err := server.hasher.Hash(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	err = server.store.UpdateUserPassword(c.Request.Context(), sqlc.UpdateUserPasswordParams{
+		ID:           resetToken.UserID,
+		PasswordHash: hashedPassword,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password: " + err.Error()})
+		return
+	}
+
+	if err := server.store.InvalidatePasswordResetTokens(c.Request.Context(), resetToken.UserID); err != nil {
+		log.Printf("Warning: could not invalidate reset tokens of user %d: %v", resetToken.UserID, err)
+	}
+	if err := server.store.RevokeUserSessions(c.Request.Context(), resetToken.UserID); err != nil {
+		log.Printf("Warning: could not revoke sessions of user %d: %v", resetToken.UserID, err)
+	}
+	if _, err := server.store.RevokeUserPersonalAccessTokens(c.Request.Context(), resetToken.UserID); err != nil {
+		log.Printf("Warning: could not revoke personal access tokens of user %d: %v", resetToken.UserID, err)
+	}
+	server.denylist.ForceSync()
+	server.audit(c, audit.Event{Type: audit.EventPasswordReset, Outcome: audit.OutcomeSuccess, UserID: resetToken.UserID})
+
+	c.Status(http.StatusNoContent)
+}
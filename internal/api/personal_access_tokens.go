@@ -0,0 +1,185 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/lshigami/Plog/internal/audit"
+	"github.com/lshigami/Plog/internal/auth"
+	"github.com/lshigami/Plog/internal/db/sqlc"
+)
+
+type CreatePersonalAccessTokenRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type PersonalAccessTokenResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreatePersonalAccessTokenResponse struct {
+	PersonalAccessTokenResponse
+	// Token is only ever returned here; Plog stores just its hash.
+	Token string `json:"token"`
+}
+
+func newPersonalAccessTokenResponse(pat sqlc.PersonalAccessToken) PersonalAccessTokenResponse {
+	rsp := PersonalAccessTokenResponse{
+		ID:        pat.ID,
+		Name:      pat.Name,
+		Scopes:    pat.Scopes,
+		CreatedAt: pat.CreatedAt.Time,
+	}
+	if pat.ExpiresAt.Valid {
+		rsp.ExpiresAt = &pat.ExpiresAt.Time
+	}
+	if pat.LastUsedAt.Valid {
+		rsp.LastUsedAt = &pat.LastUsedAt.Time
+	}
+	return rsp
+}
+
+// CreatePersonalAccessToken godoc
+// @Summary Create a personal access token
+// @Description Create a long-lived token for scripts, limited to the given scopes (posts:read, posts:write, comments:write, reactions:write). Send it as a Bearer token. The token is only shown in this response. Without expires_at it is valid until revoked.
+// @Tags tokens
+// @Accept json
+// @Produce json
+// @Param request body CreatePersonalAccessTokenRequest true "Token name, scopes and optional expiry"
+// @Success 201 {object} CreatePersonalAccessTokenResponse "Token created"
+// @Failure 400 {object} map[string]string "Invalid input, unknown scope or expiry in the past"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Called with a personal access token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /me/tokens [post]
+func (server *Server) CreatePersonalAccessToken(c *gin.Context) {
+	var req CreatePersonalAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !auth.IsValidScope(scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown scope: " + scope})
+			return
+		}
+	}
+	slices.Sort(req.Scopes)
+	req.Scopes = slices.Compact(req.Scopes)
+
+	var expiresAt pgtype.Timestamptz
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Expiry must be in the future"})
+			return
+		}
+		expiresAt = pgtype.Timestamptz{Time: *req.ExpiresAt, Valid: true}
+	}
+
+	token, tokenHash, err := auth.NewPersonalAccessToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token: " + err.Error()})
+		return
+	}
+
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+	pat, err := server.store.CreatePersonalAccessToken(c.Request.Context(), sqlc.CreatePersonalAccessTokenParams{
+		UserID:    payload.ID,
+		Name:      req.Name,
+		TokenHash: tokenHash,
+		Scopes:    req.Scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token: " + err.Error()})
+		return
+	}
+
+	server.audit(c, audit.Event{Type: audit.EventTokenCreate, Outcome: audit.OutcomeSuccess, UserID: payload.ID, Username: payload.Username,
+		Details: fmt.Sprintf("id=%d scopes=%s", pat.ID, strings.Join(pat.Scopes, ","))})
+
+	c.JSON(http.StatusCreated, CreatePersonalAccessTokenResponse{
+		PersonalAccessTokenResponse: newPersonalAccessTokenResponse(pat),
+		Token:                       token,
+	})
+}
+
+// ListPersonalAccessTokens godoc
+// @Summary List personal access tokens
+// @Description List the current user's personal access tokens that have not been revoked, newest first, including when each was last used.
+// @Tags tokens
+// @Produce json
+// @Success 200 {array} PersonalAccessTokenResponse "Tokens"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Called with a personal access token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /me/tokens [get]
+func (server *Server) ListPersonalAccessTokens(c *gin.Context) {
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+	pats, err := server.store.ListPersonalAccessTokens(c.Request.Context(), payload.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list tokens: " + err.Error()})
+		return
+	}
+
+	rsp := make([]PersonalAccessTokenResponse, 0, len(pats))
+	for _, pat := range pats {
+		rsp = append(rsp, newPersonalAccessTokenResponse(pat))
+	}
+	c.JSON(http.StatusOK, rsp)
+}
+
+// RevokePersonalAccessToken godoc
+// @Summary Revoke a personal access token
+// @Description Revoke one of the current user's personal access tokens. It stops working immediately.
+// @Tags tokens
+// @Produce json
+// @Param id path int true "Token ID"
+// @Success 204 "Token revoked"
+// @Failure 400 {object} map[string]string "Invalid token ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Called with a personal access token"
+// @Failure 404 {object} map[string]string "Token not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /me/tokens/{id} [delete]
+func (server *Server) RevokePersonalAccessToken(c *gin.Context) {
+	tokenID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID format"})
+		return
+	}
+
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+	revoked, err := server.store.RevokePersonalAccessToken(c.Request.Context(), sqlc.RevokePersonalAccessTokenParams{
+		ID:     tokenID,
+		UserID: payload.ID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token: " + err.Error()})
+		return
+	}
+	if revoked == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+		return
+	}
+	server.audit(c, audit.Event{Type: audit.EventTokenRevoke, Outcome: audit.OutcomeSuccess, UserID: payload.ID, Username: payload.Username,
+		Details: fmt.Sprintf("id=%d", tokenID)})
+
+	c.Status(http.StatusNoContent)
+}
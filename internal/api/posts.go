@@ -0,0 +1,331 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/lshigami/Plog/internal/auth"
+	"github.com/lshigami/Plog/internal/db/sqlc"
+	"github.com/lshigami/Plog/internal/slug"
+)
+
+// Post statuses. Only published posts are shown on the public endpoints.
+const (
+	PostStatusDraft     = "draft"
+	PostStatusPublished = "published"
+	PostStatusScheduled = "scheduled"
+	PostStatusArchived  = "archived"
+)
+
+// renderBatchSize is how many posts renderStalePosts loads at a time.
+const renderBatchSize = 100
+
+// postSchedulerInterval is how often each instance publishes the scheduled
+// posts whose time has come.
+const postSchedulerInterval = time.Minute
+
+type ListMyPostsRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=draft published scheduled archived"`
+	Limit  int32  `form:"limit,default=10" binding:"min=1,max=100"`
+	Offset int32  `form:"offset,default=0" binding:"min=0"`
+}
+
+// resolvePublication works out the status and published_at a post ends up
+// with when a client asks for status and publishedAt, either of which may be
+// left empty to keep the current values.
+func resolvePublication(status string, publishedAt *time.Time, currentStatus string, currentPublishedAt pgtype.Timestamptz) (string, pgtype.Timestamptz, error) {
+	if status == "" {
+		status = currentStatus
+	}
+	if publishedAt != nil && status != PostStatusScheduled {
+		return "", pgtype.Timestamptz{}, errors.New("published_at can only be set for scheduled posts")
+	}
+
+	switch status {
+	case PostStatusDraft:
+		return status, pgtype.Timestamptz{}, nil
+	case PostStatusPublished:
+		// Keep the original date when an archived post is published again.
+		if currentPublishedAt.Valid && (currentStatus == PostStatusPublished || currentStatus == PostStatusArchived) {
+			return status, currentPublishedAt, nil
+		}
+		return status, pgtype.Timestamptz{Time: time.Now(), Valid: true}, nil
+	case PostStatusScheduled:
+		if publishedAt == nil {
+			if currentStatus == PostStatusScheduled {
+				return status, currentPublishedAt, nil
+			}
+			return "", pgtype.Timestamptz{}, errors.New("published_at is required for scheduled posts")
+		}
+		if !publishedAt.After(time.Now()) {
+			return "", pgtype.Timestamptz{}, errors.New("published_at must be in the future")
+		}
+		return status, pgtype.Timestamptz{Time: *publishedAt, Valid: true}, nil
+	default:
+		return status, currentPublishedAt, nil
+	}
+}
+
+// uniqueSlug returns the slug of title, with a numeric suffix if another
+// post has or had it. postID is the post the slug is for, or zero for a new
+// post.
+func (server *Server) uniqueSlug(ctx context.Context, title string, postID int32) (string, error) {
+	base := slug.Make(title)
+	taken, err := server.store.ListTakenPostSlugs(ctx, sqlc.ListTakenPostSlugsParams{Slug: base, PostID: postID})
+	if err != nil {
+		return "", err
+	}
+	candidate := base
+	for n := 2; slices.Contains(taken, candidate); n++ {
+		candidate = base + "-" + strconv.Itoa(n)
+	}
+	return candidate, nil
+}
+
+// savePost stores a new version of existing, of which arg has everything but
+// the slug and the rendered content, and responds with the saved post. The
+// post keeps its tags when tags is nil.
+func (server *Server) savePost(c *gin.Context, existing sqlc.GetPostByIDRow, arg sqlc.UpdatePostParams, tags *postTags) {
+	// The slug only follows the title when the title would give a different
+	// one, so fixing a typo in punctuation keeps links stable.
+	arg.Slug = existing.Slug
+	if slug.Make(arg.Title) != slug.Make(existing.Title) {
+		var err error
+		arg.Slug, err = server.uniqueSlug(c.Request.Context(), arg.Title, existing.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create slug: " + err.Error()})
+			return
+		}
+	}
+
+	contentHTML, err := server.renderer.Render(arg.Format, arg.Content)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render content: " + err.Error()})
+		return
+	}
+	arg.ContentHtml = pgtype.Text{String: contentHTML, Valid: true}
+	arg.RenderedWith = pgtype.Text{String: server.renderer.Fingerprint(), Valid: true}
+	if tags != nil {
+		arg.TagSlugs, arg.TagNames, arg.SetTags = tags.slugs, tags.names, true
+	}
+
+	post, err := server.store.UpdatePost(c.Request.Context(), arg)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			c.JSON(http.StatusConflict, gin.H{"error": "Another post just took this slug, please try again"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post: " + err.Error()})
+		return
+	}
+	fullPost, err := server.store.GetPostByID(c.Request.Context(), post.ID)
+	if err != nil {
+		log.Printf("Warning: could not fetch full post details after update: %v", err)
+		c.JSON(http.StatusOK, post)
+		return
+	}
+
+	c.JSON(http.StatusOK, fullPost)
+}
+
+// GetPostBySlug godoc
+// @Summary Get a post by slug
+// @Description Get a published post by its URL slug. The slug a post had before it was renamed answers with a redirect to the current one.
+// @Tags posts
+// @Produce json
+// @Param slug path string true "Post slug"
+// @Success 200 {object} SwaggerPost "Post details"
+// @Success 301 "Post renamed, see Location"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /posts/by-slug/{slug} [get]
+func (server *Server) GetPostBySlug(c *gin.Context) {
+	postSlug := c.Param("slug")
+
+	post, err := server.store.GetPostBySlug(c.Request.Context(), postSlug)
+	if err == nil {
+		if post.Status != PostStatusPublished {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusOK, post)
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get post: " + err.Error()})
+		return
+	}
+
+	// Not a current slug, but possibly one the post had before a rename.
+	current, err := server.store.GetRenamedPostSlug(c.Request.Context(), postSlug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get post: " + err.Error()})
+		return
+	}
+	location := path.Join(path.Dir(c.Request.URL.Path), url.PathEscape(current))
+	c.Redirect(http.StatusMovedPermanently, location)
+}
+
+// ListMyPosts godoc
+// @Summary List own posts
+// @Description List the current user's posts in every status, including drafts and scheduled posts, most recently updated first.
+// @Tags posts
+// @Produce json
+// @Param status query string false "Only posts in this status" Enums(draft, published, scheduled, archived)
+// @Param limit query int false "Limit" minimum(1) maximum(100) default(10)
+// @Param offset query int false "Offset" minimum(0) default(0)
+// @Success 200 {array} SwaggerPost "Posts"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Personal access token without the posts:read scope"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /my-posts [get]
+func (server *Server) ListMyPosts(c *gin.Context) {
+	var req ListMyPostsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+
+	posts, err := server.store.ListPostsByAuthor(c.Request.Context(), sqlc.ListPostsByAuthorParams{
+		UserID: payload.ID,
+		Status: pgtype.Text{String: req.Status, Valid: req.Status != ""},
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list posts: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, posts)
+}
+
+// GetMyPost godoc
+// @Summary Get an own post
+// @Description Get a post in any status, including drafts. Authors can only get their own posts; editors and admins can get any post.
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} SwaggerPost "Post details"
+// @Failure 400 {object} map[string]string "Invalid post ID format"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "No permission to see this post"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /my-posts/{id} [get]
+func (server *Server) GetMyPost(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID format"})
+		return
+	}
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+
+	post, err := server.store.GetPostByID(c.Request.Context(), int32(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get post: " + err.Error()})
+		return
+	}
+	if post.UserID != payload.ID && !auth.HasPermission(payload.Role, auth.PermissionUpdateAnyPost) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to see this post"})
+		return
+	}
+
+	c.JSON(http.StatusOK, post)
+}
+
+// renderStalePosts renders the posts whose stored HTML was made with other
+// renderer settings, such as before HTML_POLICY was changed, or by an older
+// version of the renderer.
+func (server *Server) renderStalePosts(ctx context.Context) {
+	fingerprint := pgtype.Text{String: server.renderer.Fingerprint(), Valid: true}
+	var afterID int32
+	rendered := 0
+	for {
+		posts, err := server.store.ListPostsToRender(ctx, sqlc.ListPostsToRenderParams{
+			RenderedWith: fingerprint,
+			AfterID:      afterID,
+			Limit:        renderBatchSize,
+		})
+		if err != nil {
+			log.Printf("Warning: could not list posts to render: %v", err)
+			return
+		}
+		for _, post := range posts {
+			afterID = post.ID
+			contentHTML, err := server.renderer.Render(post.Format, post.Content)
+			if err != nil {
+				log.Printf("Warning: could not render post %d: %v", post.ID, err)
+				continue
+			}
+			err = server.store.UpdatePostContentHTML(ctx, sqlc.UpdatePostContentHTMLParams{
+				ID:           post.ID,
+				ContentHtml:  pgtype.Text{String: contentHTML, Valid: true},
+				RenderedWith: fingerprint,
+				UpdatedAt:    post.UpdatedAt,
+			})
+			if err != nil {
+				log.Printf("Warning: could not store rendered post %d: %v", post.ID, err)
+				continue
+			}
+			rendered++
+		}
+		if len(posts) < renderBatchSize {
+			break
+		}
+	}
+	if rendered > 0 {
+		log.Printf("Rendered %d posts again", rendered)
+	}
+}
+
+func (server *Server) runPostScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		server.publishDuePosts(context.Background())
+		<-ticker.C
+	}
+}
+
+// publishDuePosts publishes the scheduled posts whose time has come. Every
+// instance runs it; each post is claimed by a single statement, so none is
+// published twice.
+func (server *Server) publishDuePosts(ctx context.Context) {
+	posts, err := server.store.PublishDuePosts(ctx)
+	if err != nil {
+		log.Printf("Warning: could not publish scheduled posts: %v", err)
+		return
+	}
+	for _, post := range posts {
+		log.Printf("Published scheduled post %d of user %d", post.ID, post.UserID)
+	}
+}
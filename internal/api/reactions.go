@@ -0,0 +1,190 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lshigami/Plog/internal/auth"
+	"github.com/lshigami/Plog/internal/db/sqlc"
+)
+
+// Reaction kinds.
+const (
+	ReactionLike       = "like"
+	ReactionLove       = "love"
+	ReactionInsightful = "insightful"
+	ReactionFunny      = "funny"
+	ReactionCelebrate  = "celebrate"
+)
+
+// ReactionKinds lists every kind of reaction, as the post_reactions table
+// allows them.
+var ReactionKinds = []string{ReactionLike, ReactionLove, ReactionInsightful, ReactionFunny, ReactionCelebrate}
+
+type ListReactedPostsRequest struct {
+	Kind   string `form:"kind,default=like" binding:"oneof=like love insightful funny celebrate"`
+	Limit  int32  `form:"limit,default=10" binding:"min=1,max=100"`
+	Offset int32  `form:"offset,default=0" binding:"min=0"`
+}
+
+// reactionKind reads the kind path parameter. It responds and returns false
+// when it is not a kind of reaction.
+func reactionKind(c *gin.Context) (string, bool) {
+	kind := c.Param("kind")
+	if !slices.Contains(ReactionKinds, kind) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown reaction kind " + kind})
+		return "", false
+	}
+	return kind, true
+}
+
+// respondPostReactions responds with the reaction counts of a post and the
+// kinds the user reacted with.
+func (server *Server) respondPostReactions(c *gin.Context, postID, userID int32) {
+	reactions, err := server.store.GetPostReactions(c.Request.Context(), sqlc.GetPostReactionsParams{
+		PostID: postID,
+		UserID: userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get reactions: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reactions)
+}
+
+// GetPostReactions godoc
+// @Summary Get post reactions
+// @Description Get the number of reactions of each kind to a published post, and the kinds the current user reacted with.
+// @Tags reactions
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} SwaggerPostReactions "Reactions"
+// @Failure 400 {object} map[string]string "Invalid post ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /posts/{id}/reactions [get]
+func (server *Server) GetPostReactions(c *gin.Context) {
+	post, ok := server.publishedPost(c)
+	if !ok {
+		return
+	}
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+
+	server.respondPostReactions(c, post.ID, payload.ID)
+}
+
+// AddPostReaction godoc
+// @Summary React to a post
+// @Description React to a published post. Each user has at most one reaction of each kind per post, so reacting again changes nothing.
+// @Tags reactions
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param kind path string true "Reaction kind" Enums(like, love, insightful, funny, celebrate)
+// @Success 200 {object} SwaggerPostReactions "Reactions after the change"
+// @Failure 400 {object} map[string]string "Invalid post ID or unknown reaction kind"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /posts/{id}/reactions/{kind} [put]
+func (server *Server) AddPostReaction(c *gin.Context) {
+	kind, ok := reactionKind(c)
+	if !ok {
+		return
+	}
+	post, ok := server.publishedPost(c)
+	if !ok {
+		return
+	}
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+
+	err := server.store.AddPostReaction(c.Request.Context(), sqlc.AddPostReactionParams{
+		PostID: post.ID,
+		UserID: payload.ID,
+		Kind:   kind,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add reaction: " + err.Error()})
+		return
+	}
+
+	server.respondPostReactions(c, post.ID, payload.ID)
+}
+
+// RemovePostReaction godoc
+// @Summary Remove a reaction
+// @Description Take back a reaction to a published post. Removing a reaction that does not exist changes nothing.
+// @Tags reactions
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param kind path string true "Reaction kind" Enums(like, love, insightful, funny, celebrate)
+// @Success 200 {object} SwaggerPostReactions "Reactions after the change"
+// @Failure 400 {object} map[string]string "Invalid post ID or unknown reaction kind"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /posts/{id}/reactions/{kind} [delete]
+func (server *Server) RemovePostReaction(c *gin.Context) {
+	kind, ok := reactionKind(c)
+	if !ok {
+		return
+	}
+	post, ok := server.publishedPost(c)
+	if !ok {
+		return
+	}
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+
+	err := server.store.RemovePostReaction(c.Request.Context(), sqlc.RemovePostReactionParams{
+		PostID: post.ID,
+		UserID: payload.ID,
+		Kind:   kind,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove reaction: " + err.Error()})
+		return
+	}
+
+	server.respondPostReactions(c, post.ID, payload.ID)
+}
+
+// ListReactedPosts godoc
+// @Summary List posts I reacted to
+// @Description List the published posts the current user reacted to with a kind of reaction, by default the ones they liked, most recent reaction first.
+// @Tags reactions
+// @Produce json
+// @Param kind query string false "Reaction kind" Enums(like, love, insightful, funny, celebrate) default(like)
+// @Param limit query int false "Limit" minimum(1) maximum(100) default(10)
+// @Param offset query int false "Offset" minimum(0) default(0)
+// @Success 200 {array} SwaggerPost "Posts, with reacted_at"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /my-reactions [get]
+func (server *Server) ListReactedPosts(c *gin.Context) {
+	var req ListReactedPostsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+
+	posts, err := server.store.ListReactedPosts(c.Request.Context(), sqlc.ListReactedPostsParams{
+		UserID: payload.ID,
+		Kind:   req.Kind,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list posts: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, posts)
+}
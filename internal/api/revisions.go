@@ -0,0 +1,259 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lshigami/Plog/internal/auth"
+	"github.com/lshigami/Plog/internal/db/sqlc"
+	"github.com/lshigami/Plog/internal/diff"
+)
+
+// Diff modes.
+const (
+	DiffModeLine = "line"
+	DiffModeWord = "word"
+)
+
+type ListPostRevisionsRequest struct {
+	Limit  int32 `form:"limit,default=20" binding:"min=1,max=100"`
+	Offset int32 `form:"offset,default=0" binding:"min=0"`
+}
+
+type DiffPostRevisionsRequest struct {
+	From int32 `form:"from" binding:"required,min=1"`
+	// To defaults to the post's current revision.
+	To   int32  `form:"to" binding:"omitempty,min=1"`
+	Mode string `form:"mode,default=line" binding:"oneof=line word"`
+}
+
+type PostRevisionDiff struct {
+	From    int32        `json:"from"`
+	To      int32        `json:"to"`
+	Mode    string       `json:"mode"`
+	Title   []diff.Chunk `json:"title"`
+	Content []diff.Chunk `json:"content"`
+}
+
+// editablePost loads the post named by the id path parameter and checks that
+// the current user may edit it, and so see its history. It responds and
+// returns false when not.
+func (server *Server) editablePost(c *gin.Context) (sqlc.GetPostByIDRow, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID format"})
+		return sqlc.GetPostByIDRow{}, false
+	}
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+
+	post, err := server.store.GetPostByID(c.Request.Context(), int32(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return sqlc.GetPostByIDRow{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get post: " + err.Error()})
+		return sqlc.GetPostByIDRow{}, false
+	}
+	if post.UserID != payload.ID && !auth.HasPermission(payload.Role, auth.PermissionUpdateAnyPost) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to see the history of this post"})
+		return sqlc.GetPostByIDRow{}, false
+	}
+	return post, true
+}
+
+// getPostRevision responds with 404 and returns false when the post has no
+// such revision.
+func (server *Server) getPostRevision(c *gin.Context, postID, revision int32) (sqlc.GetPostRevisionRow, bool) {
+	rev, err := server.store.GetPostRevision(c.Request.Context(), sqlc.GetPostRevisionParams{PostID: postID, Revision: revision})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Revision " + strconv.Itoa(int(revision)) + " not found"})
+			return sqlc.GetPostRevisionRow{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get revision: " + err.Error()})
+		return sqlc.GetPostRevisionRow{}, false
+	}
+	return rev, true
+}
+
+// revisionParam parses the rev path parameter. It responds and returns false
+// when it is not a revision number.
+func revisionParam(c *gin.Context) (int32, bool) {
+	rev, err := strconv.ParseInt(c.Param("rev"), 10, 32)
+	if err != nil || rev < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision number"})
+		return 0, false
+	}
+	return int32(rev), true
+}
+
+// ListPostRevisions godoc
+// @Summary List post revisions
+// @Description List the saved versions of a post, newest first. Every create, update and restore saves one. Authors can only see the history of their own posts; editors and admins can see any.
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param limit query int false "Limit" minimum(1) maximum(100) default(20)
+// @Param offset query int false "Offset" minimum(0) default(0)
+// @Success 200 {array} SwaggerPostRevision "Revisions, without their content"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "No permission to see this post's history"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /posts/{id}/revisions [get]
+func (server *Server) ListPostRevisions(c *gin.Context) {
+	var req ListPostRevisionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	post, ok := server.editablePost(c)
+	if !ok {
+		return
+	}
+
+	revisions, err := server.store.ListPostRevisions(c.Request.Context(), sqlc.ListPostRevisionsParams{
+		PostID: post.ID,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list revisions: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetPostRevision godoc
+// @Summary Get a post revision
+// @Description Get one saved version of a post, with its content.
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param rev path int true "Revision number"
+// @Success 200 {object} SwaggerPostRevision "Revision"
+// @Failure 400 {object} map[string]string "Invalid post ID or revision number"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "No permission to see this post's history"
+// @Failure 404 {object} map[string]string "Post or revision not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /posts/{id}/revisions/{rev} [get]
+func (server *Server) GetPostRevision(c *gin.Context) {
+	revision, ok := revisionParam(c)
+	if !ok {
+		return
+	}
+	post, ok := server.editablePost(c)
+	if !ok {
+		return
+	}
+	rev, ok := server.getPostRevision(c, post.ID, revision)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, rev)
+}
+
+// DiffPostRevisions godoc
+// @Summary Compare post revisions
+// @Description Show what changed in a post's title and content between two revisions, line by line or word by word.
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param from query int true "Older revision" minimum(1)
+// @Param to query int false "Newer revision, by default the current one" minimum(1)
+// @Param mode query string false "Compare lines or words" Enums(line, word) default(line)
+// @Success 200 {object} PostRevisionDiff "Differences"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "No permission to see this post's history"
+// @Failure 404 {object} map[string]string "Post or revision not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /posts/{id}/revisions/diff [get]
+func (server *Server) DiffPostRevisions(c *gin.Context) {
+	var req DiffPostRevisionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	post, ok := server.editablePost(c)
+	if !ok {
+		return
+	}
+	if req.To == 0 {
+		req.To = post.Revision
+	}
+
+	from, ok := server.getPostRevision(c, post.ID, req.From)
+	if !ok {
+		return
+	}
+	to, ok := server.getPostRevision(c, post.ID, req.To)
+	if !ok {
+		return
+	}
+
+	compare := diff.Lines
+	if req.Mode == DiffModeWord {
+		compare = diff.Words
+	}
+	c.JSON(http.StatusOK, PostRevisionDiff{
+		From:    from.Revision,
+		To:      to.Revision,
+		Mode:    req.Mode,
+		Title:   diff.Words(from.Title, to.Title),
+		Content: compare(from.Content, to.Content),
+	})
+}
+
+// RestorePostRevision godoc
+// @Summary Restore a post revision
+// @Description Bring back the title, content and format a post had in an earlier revision. The restored version is saved as a new revision, so the history is kept; the status stays as it is.
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param rev path int true "Revision number"
+// @Success 200 {object} SwaggerPost "Restored post"
+// @Failure 400 {object} map[string]string "Invalid post ID or revision number"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "No permission to update this post"
+// @Failure 404 {object} map[string]string "Post or revision not found"
+// @Failure 409 {object} map[string]string "Slug conflict, try again"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /posts/{id}/revisions/{rev}/restore [post]
+func (server *Server) RestorePostRevision(c *gin.Context) {
+	revision, ok := revisionParam(c)
+	if !ok {
+		return
+	}
+	post, ok := server.editablePost(c)
+	if !ok {
+		return
+	}
+	rev, ok := server.getPostRevision(c, post.ID, revision)
+	if !ok {
+		return
+	}
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+
+	server.savePost(c, post, sqlc.UpdatePostParams{
+		ID:          post.ID,
+		Title:       rev.Title,
+		Content:     rev.Content,
+		Status:      post.Status,
+		PublishedAt: post.PublishedAt,
+		Format:      rev.Format,
+		EditorID:    payload.ID,
+	}, nil)
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+	"log"
 	"net/http"
 	"path/filepath"
 	"strings"
@@ -8,6 +10,7 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	_ "github.com/lshigami/Plog/docs"
+	"github.com/lshigami/Plog/internal/auth"
 	"github.com/lshigami/Plog/internal/config"
 	"github.com/lshigami/Plog/internal/db/sqlc"
 	swaggerFiles "github.com/swaggo/files"
@@ -19,24 +22,42 @@ const (
 	staticIndexFile = "index.html"
 )
 
+// newEngine returns a gin engine that takes the client IP from
+// X-Forwarded-For only on requests from one of cfg.TrustedProxies, so other
+// clients cannot choose the IP that login throttling, sessions and the audit
+// log record for them.
+func newEngine(cfg config.Config) *gin.Engine {
+	router := gin.Default()
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	return router
+}
+
 func SetupRouter(store sqlc.Querier, cfg config.Config) *gin.Engine {
 
-	router := gin.Default()
+	// --- Server Instance ---
+	server := NewServer(cfg, store)
+	router := server.router
 
 	// --- CORS Configuration ---
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowOrigins = []string{"http://localhost:3000", "*"} // Allow all origins for testing
 	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", CSRFHeaderName}
 	corsConfig.AllowCredentials = true
 	router.Use(cors.New(corsConfig))
 
-	// --- Server Instance ---
-	server := NewServer(cfg, store)
-	server.router = router
+	go server.runAccountDeletion(accountDeletionInterval)
+	go server.runPostScheduler(postSchedulerInterval)
+	go server.renderStalePosts(context.Background())
+	go server.updateSearchConfig(context.Background())
 
 	// --- API Routes (/api/v1) ---
 	apiV1 := router.Group("/api/v1")
+	if cfg.CookieAuth {
+		apiV1.Use(CSRFMiddleware())
+	}
 	{
 		// Swagger
 		apiV1.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -45,21 +66,80 @@ func SetupRouter(store sqlc.Querier, cfg config.Config) *gin.Engine {
 		// Auth
 		apiV1.POST("/register", server.RegisterUser)
 		apiV1.POST("/login", server.LoginUser)
+		apiV1.POST("/login/mfa", server.VerifyLoginMFA)
+		apiV1.GET("/oidc/providers", server.ListOIDCProviders)
+		apiV1.GET("/oidc/:provider/login", server.OIDCLogin)
+		apiV1.GET("/oidc/:provider/callback", server.OIDCCallback)
+		apiV1.POST("/tokens/renew", server.RenewAccessToken)
+		apiV1.POST("/password/forgot", server.ForgotPassword)
+		apiV1.POST("/password/reset", server.ResetPassword)
+		apiV1.POST("/verify-email", server.VerifyEmail)
 		// Posts (Public)
 		postRoutes := apiV1.Group("/posts")
 		{
 			postRoutes.GET("", server.ListPosts)
 			postRoutes.GET("/:id", server.GetPost)
+			postRoutes.GET("/by-slug/:slug", server.GetPostBySlug)
+			postRoutes.GET("/:id/comments", server.ListComments)
 		}
+		apiV1.GET("/tags", server.ListTags)
+		apiV1.GET("/search", server.SearchPosts)
 		// Posts (Authenticated)
 		authRoutes := apiV1.Group("/")
-		authRoutes.Use(AuthMiddleware(server.tokenMaker)) // Đảm bảo AuthMiddleware đúng
+		authRoutes.Use(AuthMiddleware(server.tokenMaker, server.denylist, server.store, cfg.CookieAuth)) // Đảm bảo AuthMiddleware đúng
+		{
+			authRoutes.POST("/posts", RequireScope(auth.ScopePostsWrite), RequirePermission(auth.PermissionCreatePost), server.CreatePost)
+			authRoutes.PUT("/posts/:id", RequireScope(auth.ScopePostsWrite), server.UpdatePost)
+			authRoutes.DELETE("/posts/:id", RequireScope(auth.ScopePostsWrite), server.DeletePost)
+			authRoutes.GET("/posts/:id/revisions", RequireScope(auth.ScopePostsRead), server.ListPostRevisions)
+			authRoutes.GET("/posts/:id/revisions/diff", RequireScope(auth.ScopePostsRead), server.DiffPostRevisions)
+			authRoutes.GET("/posts/:id/revisions/:rev", RequireScope(auth.ScopePostsRead), server.GetPostRevision)
+			authRoutes.POST("/posts/:id/revisions/:rev/restore", RequireScope(auth.ScopePostsWrite), server.RestorePostRevision)
+			authRoutes.POST("/posts/:id/comments", RequireScope(auth.ScopeCommentsWrite), server.CreateComment)
+			authRoutes.PUT("/posts/:id/comments/lock", RequireScope(auth.ScopePostsWrite), server.LockComments)
+			authRoutes.DELETE("/posts/:id/comments/lock", RequireScope(auth.ScopePostsWrite), server.UnlockComments)
+			authRoutes.PUT("/posts/:id/comments/:comment_id", RequireScope(auth.ScopeCommentsWrite), server.UpdateComment)
+			authRoutes.DELETE("/posts/:id/comments/:comment_id", RequireScope(auth.ScopeCommentsWrite), server.DeleteComment)
+			authRoutes.GET("/posts/:id/reactions", RequireScope(auth.ScopePostsRead), server.GetPostReactions)
+			authRoutes.PUT("/posts/:id/reactions/:kind", RequireScope(auth.ScopeReactionsWrite), server.AddPostReaction)
+			authRoutes.DELETE("/posts/:id/reactions/:kind", RequireScope(auth.ScopeReactionsWrite), server.RemovePostReaction)
+			authRoutes.GET("/my-reactions", RequireScope(auth.ScopePostsRead), server.ListReactedPosts)
+			authRoutes.GET("/my-posts", RequireScope(auth.ScopePostsRead), server.ListMyPosts)
+			authRoutes.GET("/my-posts/:id", RequireScope(auth.ScopePostsRead), server.GetMyPost)
+		}
+		// Account (login sessions only, not personal access tokens)
+		accountRoutes := apiV1.Group("/")
+		accountRoutes.Use(AuthMiddleware(server.tokenMaker, server.denylist, server.store, cfg.CookieAuth), RequireSessionToken())
+		{
+			accountRoutes.POST("/logout", server.LogoutUser)
+			accountRoutes.GET("/me/export", server.ExportAccount)
+			accountRoutes.DELETE("/me", server.DeleteAccount)
+			accountRoutes.DELETE("/me/deletion", server.CancelAccountDeletion)
+			accountRoutes.PUT("/me/email", server.UpdateEmail)
+			accountRoutes.POST("/me/email/verification", server.ResendVerificationEmail)
+			accountRoutes.POST("/me/2fa/totp", server.EnrollTOTP)
+			accountRoutes.POST("/me/2fa/totp/confirm", server.ConfirmTOTP)
+			accountRoutes.DELETE("/me/2fa/totp", server.DisableTOTP)
+			accountRoutes.POST("/me/2fa/recovery-codes", server.RegenerateRecoveryCodes)
+			accountRoutes.GET("/me/sessions", server.ListSessions)
+			accountRoutes.DELETE("/me/sessions", server.RevokeOtherSessions)
+			accountRoutes.DELETE("/me/sessions/:id", server.RevokeSession)
+			accountRoutes.POST("/me/tokens", server.CreatePersonalAccessToken)
+			accountRoutes.GET("/me/tokens", server.ListPersonalAccessTokens)
+			accountRoutes.DELETE("/me/tokens/:id", server.RevokePersonalAccessToken)
+		}
+		// Admin
+		adminRoutes := apiV1.Group("/admin")
+		adminRoutes.Use(AuthMiddleware(server.tokenMaker, server.denylist, server.store, cfg.CookieAuth), RequireSessionToken(), RequireRole(auth.RoleAdmin))
 		{
-			authRoutes.POST("/posts", server.CreatePost)
-			authRoutes.PUT("/posts/:id", server.UpdatePost)
-			// authRoutes.DELETE("/posts/:id", server.DeletePost) // Nếu có
+			adminRoutes.PUT("/users/:id/role", server.UpdateUserRole)
+			adminRoutes.DELETE("/users/:id/lockout", server.UnlockUser)
+			adminRoutes.GET("/audit-events", server.ListAuditEvents)
 		}
 	}
+	// Public keys for verifying Plog tokens from other services
+	router.GET("/.well-known/jwks.json", server.GetJWKS)
+
 	//docker pull public.ecr.aws/r8o3t2l0/go/plog:6f985261517feced3e770b422eb7204707542703
 
 	// --- Static Frontend Files Serving ---
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lshigami/Plog/internal/db/sqlc"
+)
+
+// The characters SearchPosts wraps matches in, from Unicode's private use
+// area so that they do not clash with the text of posts.
+const (
+	matchStart = "\ue000"
+	matchStop  = "\ue001"
+)
+
+// highlighter turns the match markers into mark elements once the text around
+// them is escaped.
+var highlighter = strings.NewReplacer(matchStart, "<mark>", matchStop, "</mark>")
+
+type SearchPostsRequest struct {
+	Q      string `form:"q" binding:"required,max=200"`
+	Limit  int32  `form:"limit,default=10" binding:"min=1,max=100"`
+	Offset int32  `form:"offset,default=0" binding:"min=0"`
+}
+
+// SearchResult is a post matching a search. TitleHighlight and Snippet are
+// HTML: escaped text with the matching words in mark elements.
+type SearchResult struct {
+	ID             int32     `json:"id"`
+	Title          string    `json:"title"`
+	Slug           string    `json:"slug"`
+	AuthorUsername string    `json:"author_username"`
+	PublishedAt    time.Time `json:"published_at"`
+	Rank           float32   `json:"rank"`
+	TitleHighlight string    `json:"title_highlight"`
+	Snippet        string    `json:"snippet"`
+}
+
+// highlight escapes a headline from the database and marks its matches.
+func highlight(headline string) string {
+	return highlighter.Replace(html.EscapeString(headline))
+}
+
+// SearchPosts godoc
+// @Summary Search posts
+// @Description Full-text search of published posts, best matches first. Matches in the title count more than matches in the content. The query supports "quoted phrases", OR and -excluded words.
+// @Tags posts
+// @Produce json
+// @Param q query string true "Search query" maxlength(200)
+// @Param limit query int false "Limit" minimum(1) maximum(100) default(10)
+// @Param offset query int false "Offset" minimum(0) default(0)
+// @Success 200 {array} SearchResult "Matching posts"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /search [get]
+func (server *Server) SearchPosts(c *gin.Context) {
+	var req SearchPostsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Q) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query is empty"})
+		return
+	}
+
+	rows, err := server.store.SearchPosts(c.Request.Context(), sqlc.SearchPostsParams{
+		Config: server.config.SearchConfig,
+		Query:  req.Q,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search posts: " + err.Error()})
+		return
+	}
+
+	results := make([]SearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = SearchResult{
+			ID:             row.ID,
+			Title:          row.Title,
+			Slug:           row.Slug,
+			AuthorUsername: row.AuthorUsername,
+			PublishedAt:    row.PublishedAt.Time,
+			Rank:           row.Rank,
+			TitleHighlight: highlight(row.TitleHeadline),
+			Snippet:        highlight(row.Snippet),
+		}
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// updateSearchConfig makes the search documents use the configured text
+// search configuration, rebuilding them if they were built with another one.
+func (server *Server) updateSearchConfig(ctx context.Context) {
+	rebuilt, err := server.store.UpdateSearchConfig(ctx, server.config.SearchConfig)
+	if err != nil {
+		log.Printf("Warning: could not set the search configuration to %s: %v", server.config.SearchConfig, err)
+		return
+	}
+	if rebuilt > 0 {
+		log.Printf("Rebuilt the search documents of %d posts with the %s configuration", rebuilt, server.config.SearchConfig)
+	}
+}
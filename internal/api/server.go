@@ -1,35 +1,149 @@
 package api
 
 import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/lshigami/Plog/internal/audit"
 	"github.com/lshigami/Plog/internal/auth"
 	"github.com/lshigami/Plog/internal/config"
 	"github.com/lshigami/Plog/internal/db/sqlc"
+	"github.com/lshigami/Plog/internal/mail"
+	"github.com/lshigami/Plog/internal/oidc"
+	"github.com/lshigami/Plog/internal/render"
 )
 
+const mailSendTimeout = 30 * time.Second
+
 type Server struct {
 	config     config.Config
 	store      sqlc.Querier
 	tokenMaker auth.Maker
+	hasher     auth.PasswordHasher
+	denylist   *TokenDenylist
+	throttle   *LoginThrottle
+	mailer     mail.Sender
+	auditor    audit.Logger
+	renderer   *render.Renderer
 	router     *gin.Engine
+
+	oidcProviders map[string]*oidc.Provider
 }
 
 func NewServer(config config.Config, store sqlc.Querier) *Server {
 
-	tokenMaker := auth.NewJWTMaker(config.JWTSecret)
+	tokenMaker, err := newTokenMaker(config)
+	if err != nil {
+		log.Fatalf("Could not create token maker: %v", err)
+	}
+
+	hasher, err := newPasswordHasher(config)
+	if err != nil {
+		log.Fatalf("Could not create password hasher: %v", err)
+	}
+
+	mailer, err := newMailSender(config)
+	if err != nil {
+		log.Fatalf("Could not create mail sender: %v", err)
+	}
+
+	renderer, err := newRenderer(config)
+	if err != nil {
+		log.Fatalf("Could not create renderer: %v", err)
+	}
 
 	server := &Server{
 		config:     config,
 		store:      store,
 		tokenMaker: tokenMaker,
+		hasher:     hasher,
+		denylist:   NewTokenDenylist(store, denylistSyncInterval, config.AccessTokenDuration),
+		throttle: NewLoginThrottle(store, config.LoginMaxFailures, config.LoginMaxFailuresPerIP,
+			config.LoginLockoutDuration, config.LoginMaxLockoutDuration),
+		mailer:   mailer,
+		auditor:  audit.NewStoreLogger(store),
+		renderer: renderer,
+
+		oidcProviders: newOIDCProviders(config),
 	}
-	router := gin.Default()
-	router.Use(gin.Recovery())
-	router.Use(gin.Logger())
-	server.router = router
+	// gin.Default already adds the logger and recovery middleware.
+	server.router = newEngine(config)
 	return server
 }
 
+func newPasswordHasher(cfg config.Config) (auth.PasswordHasher, error) {
+	if cfg.PasswordHasher == config.PasswordHasherBcrypt {
+		return auth.NewBcryptHasher(cfg.BcryptCost)
+	}
+	if cfg.Argon2Memory <= 0 || cfg.Argon2Iterations <= 0 || cfg.Argon2Parallelism <= 0 || cfg.Argon2Parallelism > 255 {
+		return nil, fmt.Errorf("invalid argon2id parameters: memory %d KiB, %d iterations, parallelism %d",
+			cfg.Argon2Memory, cfg.Argon2Iterations, cfg.Argon2Parallelism)
+	}
+	params := auth.DefaultArgon2idParams
+	params.Memory = uint32(cfg.Argon2Memory)
+	params.Iterations = uint32(cfg.Argon2Iterations)
+	params.Parallelism = uint8(cfg.Argon2Parallelism)
+	return auth.NewArgon2idHasher(params)
+}
+
+func newTokenMaker(cfg config.Config) (auth.Maker, error) {
+	switch cfg.TokenMaker {
+	case config.TokenMakerPasetoLocal:
+		return auth.NewPasetoLocalMaker(cfg.PasetoSymmetricKey)
+	case config.TokenMakerPasetoPublic:
+		return auth.NewPasetoPublicMaker(cfg.PasetoSecretKey)
+	case config.TokenMakerJWTEdDSA, config.TokenMakerJWTRS256:
+		algorithm := auth.AlgorithmEdDSA
+		if cfg.TokenMaker == config.TokenMakerJWTRS256 {
+			algorithm = auth.AlgorithmRS256
+		}
+		// A retired key must keep verifying until the last token it signed expires.
+		keys, err := auth.NewKeySet(cfg.JWTKeyDir, algorithm, cfg.AccessTokenDuration)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.JWTKeyRotation > 0 {
+			go keys.RotateEvery(cfg.JWTKeyRotation)
+		}
+		return auth.NewAsymmetricJWTMaker(keys), nil
+	default:
+		return auth.NewJWTMaker(cfg.JWTSecret), nil
+	}
+}
+
+func newMailSender(cfg config.Config) (mail.Sender, error) {
+	switch cfg.MailSender {
+	case config.MailSenderSMTP:
+		return mail.NewSMTPSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	case config.MailSenderFile:
+		return mail.NewFileSender(cfg.MailDir, cfg.MailFrom)
+	default:
+		return mail.LogSender{}, nil
+	}
+}
+
+func newRenderer(cfg config.Config) (*render.Renderer, error) {
+	return render.New(render.Options{
+		Basic:         cfg.HTMLPolicy == config.HTMLPolicyBasic,
+		ExtraElements: cfg.HTMLAllowedElements,
+	})
+}
+
+// sendMail delivers msg in the background so that slow mail servers neither
+// delay responses nor reveal through timing whether an account exists.
+func (server *Server) sendMail(msg mail.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+		defer cancel()
+		if err := server.mailer.Send(ctx, msg); err != nil {
+			log.Printf("Warning: could not send email %q to %s: %v", msg.Subject, msg.To, err)
+		}
+	}()
+}
+
 func (server *Server) Start(address string) error {
 	return server.router.Run(address)
 }
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lshigami/Plog/internal/audit"
+	"github.com/lshigami/Plog/internal/auth"
+	"github.com/lshigami/Plog/internal/db/sqlc"
+)
+
+// maxUserAgentLength matches the size of sessions.user_agent.
+const maxUserAgentLength = 512
+
+type SessionResponse struct {
+	ID         uuid.UUID `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Current marks the session of the access token used for the request.
+	Current bool `json:"current"`
+}
+
+type RevokeOtherSessionsResponse struct {
+	Revoked int64 `json:"revoked"`
+}
+
+func newSessionResponse(session sqlc.Session, currentID uuid.UUID) SessionResponse {
+	return SessionResponse{
+		ID:         session.ID,
+		UserAgent:  session.UserAgent,
+		IP:         session.ClientIp,
+		CreatedAt:  session.CreatedAt.Time,
+		LastSeenAt: session.LastSeenAt.Time,
+		ExpiresAt:  session.ExpiresAt.Time,
+		Current:    session.ID == currentID,
+	}
+}
+
+// truncate shortens s to at most n characters.
+func truncate(s string, n int) string {
+	runes := []rune(strings.ToValidUTF8(s, ""))
+	if len(runes) <= n {
+		return string(runes)
+	}
+	return string(runes[:n])
+}
+
+// ListSessions godoc
+// @Summary List login sessions
+// @Description List the current user's active sessions, most recently used first, with the device and IP address they were last used from. Last seen times are updated whenever a session renews its access token.
+// @Tags sessions
+// @Produce json
+// @Success 200 {array} SessionResponse "Sessions"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Called with a personal access token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /me/sessions [get]
+func (server *Server) ListSessions(c *gin.Context) {
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+	sessions, err := server.store.ListUserSessions(c.Request.Context(), payload.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list sessions: " + err.Error()})
+		return
+	}
+
+	rsp := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		rsp = append(rsp, newSessionResponse(session, payload.SessionID))
+	}
+	c.JSON(http.StatusOK, rsp)
+}
+
+// RevokeSession godoc
+// @Summary Sign out a session
+// @Description Revoke one of the current user's sessions. Its refresh token stops working at once and its access tokens within seconds.
+// @Tags sessions
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 204 "Session revoked"
+// @Failure 400 {object} map[string]string "Invalid session ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Called with a personal access token"
+// @Failure 404 {object} map[string]string "Session not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /me/sessions/{id} [delete]
+func (server *Server) RevokeSession(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID format"})
+		return
+	}
+
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+	revoked, err := server.store.RevokeUserSession(c.Request.Context(), sqlc.RevokeUserSessionParams{
+		ID:     sessionID,
+		UserID: payload.ID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session: " + err.Error()})
+		return
+	}
+	if revoked == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	server.denylist.ForceSync()
+	server.audit(c, audit.Event{Type: audit.EventSessionRevoke, Outcome: audit.OutcomeSuccess, UserID: payload.ID, Username: payload.Username, Details: sessionID.String()})
+
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeOtherSessions godoc
+// @Summary Sign out everywhere else
+// @Description Revoke every session of the current user except the one of the access token used for this request.
+// @Tags sessions
+// @Produce json
+// @Success 200 {object} RevokeOtherSessionsResponse "Number of sessions revoked"
+// @Failure 400 {object} map[string]string "Access token does not belong to a session, log in again"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Called with a personal access token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /me/sessions [delete]
+func (server *Server) RevokeOtherSessions(c *gin.Context) {
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+	// Tokens issued before sessions were recorded in them cannot tell which
+	// session to keep.
+	if payload.SessionID == uuid.Nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Access token does not belong to a session, log in again"})
+		return
+	}
+
+	revoked, err := server.store.RevokeOtherUserSessions(c.Request.Context(), sqlc.RevokeOtherUserSessionsParams{
+		UserID: payload.ID,
+		ID:     payload.SessionID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions: " + err.Error()})
+		return
+	}
+	server.denylist.ForceSync()
+	server.audit(c, audit.Event{Type: audit.EventSessionRevokeOther, Outcome: audit.OutcomeSuccess, UserID: payload.ID, Username: payload.Username,
+		Details: strconv.FormatInt(revoked, 10) + " revoked"})
+
+	c.JSON(http.StatusOK, RevokeOtherSessionsResponse{Revoked: revoked})
+}
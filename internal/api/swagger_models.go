@@ -4,13 +4,65 @@ import "time"
 
 // SwaggerPost represents a blog post for Swagger documentation
 // This is a duplicate of sqlc.Post but with standard Go types
+// Only lists of posts have comment_count
 // @Description A blog post
 type SwaggerPost struct {
-	ID        int32     `json:"id"`
-	UserID    int32     `json:"user_id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Username  string    `json:"username"`
+	ID             int32            `json:"id"`
+	UserID         int32            `json:"user_id"`
+	Title          string           `json:"title"`
+	Content        string           `json:"content"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	Status         string           `json:"status" enums:"draft,published,scheduled,archived"`
+	PublishedAt    *time.Time       `json:"published_at"`
+	Slug           string           `json:"slug"`
+	Revision       int32            `json:"revision"`
+	Format         string           `json:"format" enums:"markdown,plain,html"`
+	ContentHTML    string           `json:"content_html"`
+	Username       string           `json:"username"`
+	Tags           []string         `json:"tags"`
+	CommentsLocked bool             `json:"comments_locked"`
+	CommentCount   int64            `json:"comment_count"`
+	Reactions      map[string]int64 `json:"reactions"`
+}
+
+// SwaggerPostReactions represents the reactions to a post for Swagger
+// documentation.
+// @Description Reactions to a blog post
+type SwaggerPostReactions struct {
+	// Reactions counts the reactions of each kind; kinds nobody used are left out.
+	Reactions map[string]int64 `json:"reactions" example:"like:3,love:1"`
+	Mine      []string         `json:"mine"`
+}
+
+// SwaggerPostRevision represents a saved version of a post for Swagger
+// documentation. The list of revisions leaves out post_id, content and format.
+// @Description A saved version of a blog post
+type SwaggerPostRevision struct {
+	PostID         int32     `json:"post_id"`
+	Revision       int32     `json:"revision"`
+	UserID         *int32    `json:"user_id"`
+	AuthorUsername *string   `json:"author_username"`
+	Title          string    `json:"title"`
+	Content        string    `json:"content"`
+	Format         string    `json:"format" enums:"markdown,plain,html"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// SwaggerComment represents a comment for Swagger documentation. Only created
+// and updated comments have post_id; only listed ones have author_username
+// and depth, and in the tree view replies.
+// @Description A comment on a blog post
+type SwaggerComment struct {
+	ID             int32            `json:"id"`
+	PostID         int32            `json:"post_id"`
+	ParentID       *int32           `json:"parent_id"`
+	UserID         *int32           `json:"user_id"`
+	AuthorUsername *string          `json:"author_username"`
+	Content        string           `json:"content"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	DeletedAt      *time.Time       `json:"deleted_at"`
+	Depth          int32            `json:"depth"`
+	Replies        []SwaggerComment `json:"replies"`
 }
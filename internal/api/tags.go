@@ -0,0 +1,109 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lshigami/Plog/internal/db/sqlc"
+	"github.com/lshigami/Plog/internal/slug"
+)
+
+// Ways ListPosts can match several tags.
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+// maxTagsPerPost is also the most tags ListPosts filters by.
+const maxTagsPerPost = 10
+
+type ListTagsRequest struct {
+	Limit  int32 `form:"limit,default=50" binding:"min=1,max=200"`
+	Offset int32 `form:"offset,default=0" binding:"min=0"`
+}
+
+// postTags are the normalized tags of a post, slugs and the names they were
+// written as at the same index.
+type postTags struct {
+	slugs []string
+	names []string
+}
+
+// normalizeTags cleans up the tags a client sent: surrounding and repeated
+// whitespace is removed, and tags with the same slug are merged into the
+// first one.
+func normalizeTags(names []string) (*postTags, error) {
+	tags := &postTags{slugs: []string{}, names: []string{}}
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.Join(strings.Fields(name), " ")
+		if !strings.ContainsFunc(name, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) {
+			return nil, errors.New("tags need at least one letter or digit")
+		}
+		tagSlug := slug.Make(name)
+		if seen[tagSlug] {
+			continue
+		}
+		seen[tagSlug] = true
+		tags.slugs = append(tags.slugs, tagSlug)
+		tags.names = append(tags.names, name)
+	}
+	return tags, nil
+}
+
+// tagFilter returns the slugs of the tags to filter posts by. Each value may
+// hold several tags separated by commas.
+func tagFilter(values []string) ([]string, error) {
+	var slugs []string
+	seen := map[string]bool{}
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			if strings.TrimSpace(name) == "" {
+				continue
+			}
+			tagSlug := slug.Make(name)
+			if seen[tagSlug] {
+				continue
+			}
+			seen[tagSlug] = true
+			slugs = append(slugs, tagSlug)
+		}
+	}
+	if len(slugs) > maxTagsPerPost {
+		return nil, errors.New("too many tags")
+	}
+	return slugs, nil
+}
+
+// ListTags godoc
+// @Summary List tags
+// @Description List the tags of published posts with the number of published posts that have each, most used first.
+// @Tags posts
+// @Produce json
+// @Param limit query int false "Limit" minimum(1) maximum(200) default(50)
+// @Param offset query int false "Offset" minimum(0) default(0)
+// @Success 200 {array} sqlc.ListTagsRow "Tags"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /tags [get]
+func (server *Server) ListTags(c *gin.Context) {
+	var req ListTagsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	tags, err := server.store.ListTags(c.Request.Context(), sqlc.ListTagsParams{
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list tags: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
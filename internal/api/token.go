@@ -0,0 +1,233 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/lshigami/Plog/internal/audit"
+	"github.com/lshigami/Plog/internal/auth"
+	"github.com/lshigami/Plog/internal/db/sqlc"
+)
+
+// RenewAccessTokenRequest may be empty when the refresh token is sent as a
+// cookie.
+type RenewAccessTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RenewAccessTokenResponse struct {
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+type LogoutUserRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// createSession starts a new refresh token family for the user and returns its
+// first refresh token.
+func (server *Server) createSession(c *gin.Context, userID int32) (uuid.UUID, string, error) {
+	sessionID := uuid.New()
+	refreshToken, refreshTokenHash, err := auth.NewRefreshToken(sessionID)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	arg := sqlc.CreateSessionParams{
+		ID:               sessionID,
+		UserID:           userID,
+		RefreshTokenHash: refreshTokenHash,
+		ExpiresAt:        pgtype.Timestamptz{Time: time.Now().Add(server.config.RefreshTokenDuration), Valid: true},
+		UserAgent:        truncate(c.Request.UserAgent(), maxUserAgentLength),
+		ClientIp:         c.ClientIP(),
+	}
+	if _, err := server.store.CreateSession(c.Request.Context(), arg); err != nil {
+		return uuid.Nil, "", err
+	}
+	return sessionID, refreshToken, nil
+}
+
+// revokeReusedSession is called when a refresh token that has already been
+// rotated is presented again. The token was most likely stolen, so the whole
+// session family is revoked and both parties have to log in again.
+func (server *Server) revokeReusedSession(c *gin.Context, session sqlc.Session) {
+	log.Printf("Warning: refresh token reuse detected for session %s, revoking it", session.ID)
+	if err := server.store.RevokeSession(c.Request.Context(), session.ID); err != nil {
+		log.Printf("Warning: could not revoke session %s: %v", session.ID, err)
+	}
+	server.denylist.ForceSync()
+	server.audit(c, audit.Event{Type: audit.EventRefreshTokenReuse, Outcome: audit.OutcomeFailure, UserID: session.UserID, Details: session.ID.String()})
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token has already been used"})
+}
+
+// RenewAccessToken godoc
+// @Summary Renew an access token
+// @Description Exchange a refresh token for a new access token. The refresh token is rotated: the response contains a new one and the old one stops working. Replaying an old refresh token revokes the whole session. With cookie authentication the refresh token may be sent as the plog_refresh_token cookie instead, and the new tokens are set as cookies rather than returned.
+// @Tags authentication
+// @Accept json
+// @Produce json
+// @Param request body RenewAccessTokenRequest false "Refresh token"
+// @Success 200 {object} RenewAccessTokenResponse "Tokens renewed"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 401 {object} map[string]string "Invalid, expired, revoked or reused refresh token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /tokens/renew [post]
+func (server *Server) RenewAccessToken(c *gin.Context) {
+	var req RenewAccessTokenRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+	}
+	if req.RefreshToken == "" && server.config.CookieAuth {
+		req.RefreshToken, _ = c.Cookie(RefreshTokenCookieName)
+	}
+	if req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: refresh_token is required"})
+		return
+	}
+
+	sessionID, refreshTokenHash, err := auth.ParseRefreshToken(req.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	session, err := server.store.GetSession(c.Request.Context(), sessionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get session: " + err.Error()})
+		return
+	}
+
+	if session.IsRevoked {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
+		return
+	}
+	if time.Now().After(session.ExpiresAt.Time) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has expired"})
+		return
+	}
+	if session.RefreshTokenHash != refreshTokenHash {
+		server.revokeReusedSession(c, session)
+		return
+	}
+
+	newRefreshToken, newRefreshTokenHash, err := auth.NewRefreshToken(session.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create refresh token"})
+		return
+	}
+
+	arg := sqlc.RotateSessionTokenParams{
+		NewRefreshTokenHash: newRefreshTokenHash,
+		UserAgent:           truncate(c.Request.UserAgent(), maxUserAgentLength),
+		ClientIp:            c.ClientIP(),
+		ID:                  session.ID,
+		RefreshTokenHash:    refreshTokenHash,
+	}
+	if _, err := server.store.RotateSessionToken(c.Request.Context(), arg); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Another request rotated the same token first.
+			server.revokeReusedSession(c, session)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate refresh token: " + err.Error()})
+		return
+	}
+
+	user, err := server.store.GetUserByID(c.Request.Context(), session.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user: " + err.Error()})
+		return
+	}
+
+	accessToken, err := server.tokenMaker.CreateToken(user.ID, user.Username, user.Role, session.ID, server.config.AccessTokenDuration)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create access token"})
+		return
+	}
+
+	if server.config.CookieAuth {
+		if err := server.setAuthCookies(c, accessToken, newRefreshToken); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create CSRF token"})
+			return
+		}
+		c.JSON(http.StatusOK, RenewAccessTokenResponse{})
+		return
+	}
+
+	rsp := RenewAccessTokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: newRefreshToken,
+	}
+	c.JSON(http.StatusOK, rsp)
+}
+
+// LogoutUser godoc
+// @Summary Logout a user
+// @Description Revoke the access token used for this request and the session it belongs to. If a refresh token of another session is supplied, that session is revoked as well. Authentication cookies are cleared.
+// @Tags authentication
+// @Accept json
+// @Produce json
+// @Param request body LogoutUserRequest false "Refresh token of the session to end"
+// @Success 204 "Logged out"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /logout [post]
+func (server *Server) LogoutUser(c *gin.Context) {
+	var req LogoutUserRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+	}
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+
+	// Tokens issued before jti was introduced cannot be denylisted; they
+	// simply run until they expire.
+	if payload.TokenID != uuid.Nil {
+		if err := server.denylist.Revoke(c.Request.Context(), payload); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token: " + err.Error()})
+			return
+		}
+	}
+
+	if payload.SessionID != uuid.Nil {
+		if err := server.store.RevokeSession(c.Request.Context(), payload.SessionID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session: " + err.Error()})
+			return
+		}
+	}
+	if req.RefreshToken != "" {
+		sessionID, _, err := auth.ParseRefreshToken(req.RefreshToken)
+		if err == nil && sessionID != payload.SessionID {
+			session, err := server.store.GetSession(c.Request.Context(), sessionID)
+			if err == nil && session.UserID == payload.ID {
+				if err := server.store.RevokeSession(c.Request.Context(), sessionID); err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session: " + err.Error()})
+					return
+				}
+			}
+		}
+	}
+	if server.config.CookieAuth {
+		server.clearAuthCookies(c)
+	}
+	server.audit(c, audit.Event{Type: audit.EventLogout, Outcome: audit.OutcomeSuccess, UserID: payload.ID, Username: payload.Username})
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,440 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/lshigami/Plog/internal/audit"
+	"github.com/lshigami/Plog/internal/auth"
+	"github.com/lshigami/Plog/internal/db/sqlc"
+)
+
+const (
+	mfaTokenBytes     = 32
+	mfaMaxAttempts    = 5
+	recoveryCodeCount = 10
+)
+
+const (
+	errInvalidMFACode  = "Invalid authentication code"
+	errTOTPNotEnabled  = "Two-factor authentication is not enabled"
+	errTOTPEnabled     = "Two-factor authentication is already enabled"
+	errTOTPNotEnrolled = "Start two-factor enrollment first"
+)
+
+type MFAChallengeResponse struct {
+	MFARequired bool      `json:"mfa_required"`
+	MFAToken    string    `json:"mfa_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type VerifyLoginMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type TOTPEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TOTPChangeRequest struct {
+	// Password is required unless the account was created through OIDC
+	// and has no password.
+	Password string `json:"password"`
+	// Code is a TOTP or recovery code.
+	Code string `json:"code" binding:"required"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// startMFAChallenge answers a correct password for an account with 2FA: the
+// client gets a short-lived token to exchange at /login/mfa together with a
+// code, instead of an access token.
+func (server *Server) startMFAChallenge(c *gin.Context, user sqlc.User) {
+	rsp, ok := server.newMFAChallenge(c, user)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, rsp)
+}
+
+// newMFAChallenge stores a challenge for user to answer with a code. It
+// responds and returns false when that fails.
+func (server *Server) newMFAChallenge(c *gin.Context, user sqlc.User) (MFAChallengeResponse, bool) {
+	token, err := auth.RandomToken(mfaTokenBytes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create MFA token"})
+		return MFAChallengeResponse{}, false
+	}
+
+	if _, err := server.store.DeleteExpiredMFAChallenges(c.Request.Context()); err != nil {
+		log.Printf("Warning: could not delete expired MFA challenges: %v", err)
+	}
+	expiresAt := time.Now().Add(server.config.MFAChallengeTTL)
+	_, err = server.store.CreateMFAChallenge(c.Request.Context(), sqlc.CreateMFAChallengeParams{
+		UserID:    user.ID,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create MFA challenge: " + err.Error()})
+		return MFAChallengeResponse{}, false
+	}
+
+	return MFAChallengeResponse{
+		MFARequired: true,
+		MFAToken:    token,
+		ExpiresAt:   expiresAt,
+	}, true
+}
+
+// checkSecondFactor accepts a current TOTP code or an unused recovery code.
+// Either is consumed, so it cannot be used again.
+func (server *Server) checkSecondFactor(ctx context.Context, user sqlc.User, code string) (bool, error) {
+	if !user.TotpEnabledAt.Valid {
+		return false, nil
+	}
+
+	if step, ok := auth.ValidateTOTP(user.TotpSecret.String, code, time.Now()); ok {
+		rows, err := server.store.UseTOTPStep(ctx, sqlc.UseTOTPStepParams{ID: user.ID, TotpLastStep: step})
+		if err != nil {
+			return false, err
+		}
+		return rows == 1, nil
+	}
+
+	rows, err := server.store.UseRecoveryCode(ctx, sqlc.UseRecoveryCodeParams{
+		UserID:   user.ID,
+		CodeHash: auth.HashRecoveryCode(code),
+	})
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// newRecoveryCodes replaces the recovery codes of userID and returns the new
+// ones in plain text. They cannot be retrieved again.
+func (server *Server) newRecoveryCodes(ctx context.Context, userID int32) ([]string, error) {
+	codes, err := auth.NewRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = auth.HashRecoveryCode(code)
+	}
+
+	if err := server.store.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return nil, err
+	}
+	err = server.store.CreateRecoveryCodes(ctx, sqlc.CreateRecoveryCodesParams{
+		UserID:     userID,
+		CodeHashes: hashes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// currentUser loads the user of the access token, responding with an error
+// if that fails.
+func (server *Server) currentUser(c *gin.Context) (sqlc.User, bool) {
+	payload := c.MustGet(AuthorizationPayloadKey).(*auth.Payload)
+	user, err := server.store.GetUserByID(c.Request.Context(), payload.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return sqlc.User{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user: " + err.Error()})
+		return sqlc.User{}, false
+	}
+	return user, true
+}
+
+// VerifyLoginMFA godoc
+// @Summary Complete a two-factor login
+// @Description Exchange the MFA token from /login and a TOTP or recovery code for an access token and a refresh token. An MFA token allows a limited number of attempts, and wrong codes count as failed logins of the account and the client IP.
+// @Tags authentication
+// @Accept json
+// @Produce json
+// @Param request body VerifyLoginMFARequest true "MFA token and code"
+// @Success 200 {object} LoginUserResponse "Login successful"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 401 {object} map[string]string "Invalid or expired MFA token, or invalid code"
+// @Failure 423 {object} map[string]string "Account temporarily locked after too many failed logins, see Retry-After"
+// @Failure 429 {object} map[string]string "Too many failed logins from this IP, see Retry-After"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /login/mfa [post]
+func (server *Server) VerifyLoginMFA(c *gin.Context) {
+	var req VerifyLoginMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	// Counting the attempt before checking the code bounds the number of
+	// guesses even when requests race.
+	challenge, err := server.store.AttemptMFAChallenge(c.Request.Context(), sqlc.AttemptMFAChallengeParams{
+		TokenHash:   auth.HashToken(req.MFAToken),
+		MaxAttempts: mfaMaxAttempts,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check MFA token: " + err.Error()})
+		return
+	}
+
+	user, err := server.store.GetUserByID(c.Request.Context(), challenge.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user: " + err.Error()})
+		return
+	}
+
+	// Wrong codes count towards the same lockouts as wrong passwords, so
+	// starting new challenges does not give unlimited guesses.
+	ip := c.ClientIP()
+	if !server.checkLoginLockout(c, user.Username, ip, audit.EventLoginMFA) {
+		return
+	}
+	ok, err := server.checkSecondFactor(c.Request.Context(), user, req.Code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check code: " + err.Error()})
+		return
+	}
+	if !ok {
+		server.recordLoginFailure(c, audit.EventLoginMFA, user.ID, user.Username, ip, "invalid_code")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": errInvalidMFACode})
+		return
+	}
+
+	// Deleting the challenge makes the MFA token single use.
+	rows, err := server.store.DeleteMFAChallenge(c.Request.Context(), challenge.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete MFA challenge: " + err.Error()})
+		return
+	}
+	if rows == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token"})
+		return
+	}
+
+	server.completeLogin(c, user, audit.EventLoginMFA)
+}
+
+// EnrollTOTP godoc
+// @Summary Start TOTP enrollment
+// @Description Generate a TOTP secret for the current user. Add it to an authenticator app (the otpauth URI is the QR code payload), then confirm with a code to turn two-factor authentication on. Starting again replaces a secret that was not confirmed yet.
+// @Tags two-factor
+// @Produce json
+// @Success 200 {object} TOTPEnrollmentResponse "Secret and otpauth URI"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 409 {object} map[string]string "Two-factor authentication already enabled"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /me/2fa/totp [post]
+func (server *Server) EnrollTOTP(c *gin.Context) {
+	user, ok := server.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.TotpEnabledAt.Valid {
+		c.JSON(http.StatusConflict, gin.H{"error": errTOTPEnabled})
+		return
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create TOTP secret"})
+		return
+	}
+	rows, err := server.store.SetUserTOTPSecret(c.Request.Context(), sqlc.SetUserTOTPSecretParams{
+		ID:         user.ID,
+		TotpSecret: pgtype.Text{String: secret, Valid: true},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save TOTP secret: " + err.Error()})
+		return
+	}
+	if rows == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": errTOTPEnabled})
+		return
+	}
+
+	c.JSON(http.StatusOK, TOTPEnrollmentResponse{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI(server.config.TOTPIssuer, user.Username, secret),
+	})
+}
+
+// ConfirmTOTP godoc
+// @Summary Turn on TOTP
+// @Description Confirm enrollment with a code from the authenticator app. Two-factor authentication is enabled and recovery codes are returned; they are shown only once.
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Param request body TOTPCodeRequest true "Code from the authenticator app"
+// @Success 200 {object} RecoveryCodesResponse "Two-factor authentication enabled"
+// @Failure 400 {object} map[string]string "Invalid input or code, or enrollment not started"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 409 {object} map[string]string "Two-factor authentication already enabled"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /me/2fa/totp/confirm [post]
+func (server *Server) ConfirmTOTP(c *gin.Context) {
+	var req TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	user, ok := server.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.TotpEnabledAt.Valid {
+		c.JSON(http.StatusConflict, gin.H{"error": errTOTPEnabled})
+		return
+	}
+	if !user.TotpSecret.Valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTOTPNotEnrolled})
+		return
+	}
+
+	step, ok := auth.ValidateTOTP(user.TotpSecret.String, req.Code, time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidMFACode})
+		return
+	}
+
+	// Create the recovery codes first, so 2FA is never on without them.
+	codes, err := server.newRecoveryCodes(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recovery codes: " + err.Error()})
+		return
+	}
+	rows, err := server.store.EnableUserTOTP(c.Request.Context(), sqlc.EnableUserTOTPParams{
+		ID:           user.ID,
+		TotpLastStep: step,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication: " + err.Error()})
+		return
+	}
+	if rows == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": errTOTPEnabled})
+		return
+	}
+	server.audit(c, audit.Event{Type: audit.EventTOTPEnable, Outcome: audit.OutcomeSuccess, UserID: user.ID, Username: user.Username})
+
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTOTP godoc
+// @Summary Turn off TOTP
+// @Description Disable two-factor authentication for the current user. Requires the password and a TOTP or recovery code; wrong answers count as failed logins.
+// @Tags two-factor
+// @Accept json
+// @Param request body TOTPChangeRequest true "Password and a TOTP or recovery code"
+// @Success 204 "Two-factor authentication disabled"
+// @Failure 400 {object} map[string]string "Invalid input, or not enabled"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Wrong password or code"
+// @Failure 423 {object} map[string]string "Account temporarily locked after too many failed logins, see Retry-After"
+// @Failure 429 {object} map[string]string "Too many failed logins from this IP, see Retry-After"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /me/2fa/totp [delete]
+func (server *Server) DisableTOTP(c *gin.Context) {
+	var req TOTPChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	user, ok := server.currentUser(c)
+	if !ok {
+		return
+	}
+	if !user.TotpEnabledAt.Valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTOTPNotEnabled})
+		return
+	}
+
+	if !server.confirmIdentity(c, user, req.Password, req.Code, audit.EventTOTPDisable) {
+		return
+	}
+
+	if err := server.store.DisableUserTOTP(c.Request.Context(), user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication: " + err.Error()})
+		return
+	}
+	if err := server.store.DeleteRecoveryCodes(c.Request.Context(), user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete recovery codes: " + err.Error()})
+		return
+	}
+	server.audit(c, audit.Event{Type: audit.EventTOTPDisable, Outcome: audit.OutcomeSuccess, UserID: user.ID, Username: user.Username})
+
+	c.Status(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replace all recovery codes of the current user. Requires the password and a TOTP or recovery code; wrong answers count as failed logins. The new codes are shown only once.
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Param request body TOTPChangeRequest true "Password and a TOTP or recovery code"
+// @Success 200 {object} RecoveryCodesResponse "New recovery codes"
+// @Failure 400 {object} map[string]string "Invalid input, or not enabled"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Wrong password or code"
+// @Failure 423 {object} map[string]string "Account temporarily locked after too many failed logins, see Retry-After"
+// @Failure 429 {object} map[string]string "Too many failed logins from this IP, see Retry-After"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Security BearerAuth
+// @Router /me/2fa/recovery-codes [post]
+func (server *Server) RegenerateRecoveryCodes(c *gin.Context) {
+	var req TOTPChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	user, ok := server.currentUser(c)
+	if !ok {
+		return
+	}
+	if !user.TotpEnabledAt.Valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTOTPNotEnabled})
+		return
+	}
+
+	if !server.confirmIdentity(c, user, req.Password, req.Code, audit.EventRecoveryCodesReset) {
+		return
+	}
+
+	codes, err := server.newRecoveryCodes(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recovery codes: " + err.Error()})
+		return
+	}
+	server.audit(c, audit.Event{Type: audit.EventRecoveryCodesReset, Outcome: audit.OutcomeSuccess, UserID: user.ID, Username: user.Username})
+
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
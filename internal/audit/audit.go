@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/lshigami/Plog/internal/db/sqlc"
+)
+
+// Event types.
+const (
+	EventRegister           = "user.register"
+	EventLogin              = "login"
+	EventLoginMFA           = "login.mfa"
+	EventLoginOIDC          = "login.oidc"
+	EventLogout             = "logout"
+	EventRefreshTokenReuse  = "token.refresh_reuse"
+	EventPasswordResetSent  = "password.reset_requested"
+	EventPasswordReset      = "password.reset"
+	EventEmailChange        = "email.change"
+	EventTOTPEnable         = "mfa.totp_enable"
+	EventTOTPDisable        = "mfa.totp_disable"
+	EventRecoveryCodesReset = "mfa.recovery_codes_regenerate"
+	EventSessionRevoke      = "session.revoke"
+	EventSessionRevokeOther = "session.revoke_others"
+	EventTokenCreate        = "pat.create"
+	EventTokenRevoke        = "pat.revoke"
+	EventRoleChange         = "admin.role_change"
+	EventUnlock             = "admin.unlock"
+	EventAccountExport      = "account.export"
+	EventDeletionSchedule   = "account.deletion_scheduled"
+	EventDeletionCancel     = "account.deletion_cancelled"
+	EventAccountDelete      = "account.deleted"
+)
+
+// EventTypes lists every event type, for validating filters.
+var EventTypes = []string{
+	EventRegister, EventLogin, EventLoginMFA, EventLoginOIDC, EventLogout,
+	EventRefreshTokenReuse, EventPasswordResetSent, EventPasswordReset,
+	EventEmailChange, EventTOTPEnable, EventTOTPDisable, EventRecoveryCodesReset,
+	EventSessionRevoke, EventSessionRevokeOther, EventTokenCreate, EventTokenRevoke,
+	EventRoleChange, EventUnlock, EventAccountExport, EventDeletionSchedule,
+	EventDeletionCancel, EventAccountDelete,
+}
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+const writeTimeout = 5 * time.Second
+
+// Event is a security-relevant action. UserID is the account the event is
+// about and ActorID the authenticated user who caused it; zero means none
+// or unknown.
+type Event struct {
+	Type      string
+	Outcome   string
+	UserID    int32
+	ActorID   int32
+	Username  string
+	IP        string
+	UserAgent string
+	// Details is a short machine-readable reason or note, such as
+	// "wrong_password".
+	Details string
+}
+
+// Logger records events.
+type Logger interface {
+	Log(ctx context.Context, event Event)
+}
+
+type storeLogger struct {
+	store sqlc.Querier
+}
+
+// NewStoreLogger returns a Logger that writes to the audit_events table.
+// Failures to write are logged but do not fail the request that caused the
+// event.
+func NewStoreLogger(store sqlc.Querier) Logger {
+	return &storeLogger{store: store}
+}
+
+func (l *storeLogger) Log(ctx context.Context, event Event) {
+	// Record the event even if the client has gone away.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), writeTimeout)
+	defer cancel()
+
+	err := l.store.CreateAuditEvent(ctx, sqlc.CreateAuditEventParams{
+		EventType: event.Type,
+		Outcome:   event.Outcome,
+		UserID:    optionalID(event.UserID),
+		ActorID:   optionalID(event.ActorID),
+		Username:  event.Username,
+		Ip:        event.IP,
+		UserAgent: event.UserAgent,
+		Details:   event.Details,
+	})
+	if err != nil {
+		log.Printf("Warning: could not record audit event %s for %q: %v", event.Type, event.Username, err)
+	}
+}
+
+func optionalID(id int32) pgtype.Int4 {
+	return pgtype.Int4{Int32: id, Valid: id != 0}
+}
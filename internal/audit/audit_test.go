@@ -0,0 +1,45 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	mock_sqlc "github.com/lshigami/Plog/internal/db/mock"
+	"github.com/lshigami/Plog/internal/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestStoreLogger(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock_sqlc.NewMockQuerier(ctrl)
+	store.EXPECT().CreateAuditEvent(gomock.Any(), sqlc.CreateAuditEventParams{
+		EventType: EventLogin,
+		Outcome:   OutcomeFailure,
+		UserID:    pgtype.Int4{Int32: 7, Valid: true},
+		Username:  "alice",
+		Ip:        "198.51.100.1",
+		UserAgent: "curl/8.0",
+		Details:   "wrong_password",
+	}).Times(1).DoAndReturn(func(ctx context.Context, _ sqlc.CreateAuditEventParams) error {
+		require.NoError(t, ctx.Err())
+		return nil
+	})
+
+	// A cancelled request must not keep the event from being written.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	NewStoreLogger(store).Log(ctx, Event{
+		Type:      EventLogin,
+		Outcome:   OutcomeFailure,
+		UserID:    7,
+		Username:  "alice",
+		IP:        "198.51.100.1",
+		UserAgent: "curl/8.0",
+		Details:   "wrong_password",
+	})
+}
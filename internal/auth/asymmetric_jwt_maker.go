@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// AsymmetricJWTMaker signs JWTs with the current key of a KeySet (EdDSA or
+// RS256) and puts its ID in the "kid" header, so that other services can
+// verify Plog tokens with the public keys published as a JWKS.
+type AsymmetricJWTMaker struct {
+	keys   *KeySet
+	method jwt.SigningMethod
+	parser *jwt.Parser
+}
+
+func NewAsymmetricJWTMaker(keys *KeySet) Maker {
+	method := jwt.SigningMethod(jwt.SigningMethodEdDSA)
+	if keys.Algorithm() == AlgorithmRS256 {
+		method = jwt.SigningMethodRS256
+	}
+	return &AsymmetricJWTMaker{
+		keys:   keys,
+		method: method,
+		parser: jwt.NewParser(jwt.WithValidMethods([]string{method.Alg()})),
+	}
+}
+
+func (maker *AsymmetricJWTMaker) CreateToken(id int32, username string, role string, sessionID uuid.UUID, duration time.Duration) (string, error) {
+	payload, err := NewPayload(id, username, role, sessionID, duration)
+	if err != nil {
+		return "", err
+	}
+
+	key := maker.keys.SigningKey()
+	token := jwt.NewWithClaims(maker.method, payload)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+func (maker *AsymmetricJWTMaker) VerifyToken(token string) (*Payload, error) {
+	parsedToken, err := maker.parser.ParseWithClaims(token, &Payload{}, func(t *jwt.Token) (interface{}, error) {
+		kid, ok := t.Header["kid"].(string)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		return maker.keys.PublicKey(kid)
+	})
+
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, ErrInvalidToken
+	}
+
+	payload, ok := parsedToken.Claims.(*Payload)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	if err := payload.Valid(); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// JWKS returns the public halves of every key that can still verify tokens.
+func (maker *AsymmetricJWTMaker) JWKS() JWKS {
+	keys := maker.keys.Keys()
+	jwks := JWKS{Keys: make([]JWK, 0, len(keys))}
+	for _, key := range keys {
+		jwks.Keys = append(jwks.Keys, newJWK(key, maker.method.Alg()))
+	}
+	return jwks
+}
@@ -1,9 +1,11 @@
 package auth
 
 import (
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type JWTMaker struct {
@@ -17,9 +19,9 @@ func NewJWTMaker(secretKey string) Maker {
 	return &JWTMaker{secretKey}
 }
 
-func (maker *JWTMaker) CreateToken(id int32, username string, duration time.Duration) (string, error) {
+func (maker *JWTMaker) CreateToken(id int32, username string, role string, sessionID uuid.UUID, duration time.Duration) (string, error) {
 
-	payload, err := NewPayload(id, username, duration)
+	payload, err := NewPayload(id, username, role, sessionID, duration)
 	if err != nil {
 		return "", err
 	}
@@ -40,7 +42,10 @@ func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
 	})
 
 	if err != nil {
-		return nil, err
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, ErrInvalidToken
 	}
 
 	payload, ok := parsedToken.Claims.(*Payload)
@@ -0,0 +1,333 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	AlgorithmEdDSA = "EdDSA"
+	AlgorithmRS256 = "RS256"
+
+	rsaKeyBits        = 2048
+	keyFileExt        = ".pem"
+	keyIDTimeFormat   = "20060102T150405.000000Z"
+	keyReloadInterval = 30 * time.Second
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// SigningKey is a private key from a KeySet. ID is used as the JWT "kid".
+type SigningKey struct {
+	ID        string
+	CreatedAt time.Time
+	Private   crypto.Signer
+}
+
+// KeySet holds the signing keys stored as PEM files in a directory, one file
+// per key named "<kid>.pem". Generated kids start with the time the key was
+// created, so every instance agrees on the age of a key whatever the file's
+// modification time; other files count as created when last modified. The
+// most recently created key signs new tokens; older keys keep verifying tokens
+// until retention has passed since they were superseded, after which their
+// files are removed. Instances that issue tokens for each other must share the
+// same directory: each one reloads it periodically and whenever it sees an
+// unknown kid.
+type KeySet struct {
+	dir       string
+	algorithm string
+	retention time.Duration
+
+	mu       sync.RWMutex
+	keys     map[string]*SigningKey
+	current  *SigningKey
+	loadedAt time.Time
+}
+
+// NewKeySet loads the keys in dir, generating a first key if there is none.
+func NewKeySet(dir, algorithm string, retention time.Duration) (*KeySet, error) {
+	if algorithm != AlgorithmEdDSA && algorithm != AlgorithmRS256 {
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	ks := &KeySet{
+		dir:       dir,
+		algorithm: algorithm,
+		retention: retention,
+	}
+	if err := ks.Reload(); err != nil {
+		return nil, err
+	}
+	if ks.SigningKey() == nil {
+		if err := ks.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+	return ks, nil
+}
+
+func (ks *KeySet) Algorithm() string {
+	return ks.algorithm
+}
+
+// SigningKey returns the key new tokens should be signed with.
+func (ks *KeySet) SigningKey() *SigningKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.current
+}
+
+// PublicKey returns the public key for kid, reloading the directory once if
+// the key is not known yet.
+func (ks *KeySet) PublicKey(kid string) (crypto.PublicKey, error) {
+	ks.mu.RLock()
+	key, ok := ks.keys[kid]
+	stale := time.Since(ks.loadedAt) >= keyReloadInterval
+	ks.mu.RUnlock()
+
+	if !ok && stale {
+		if err := ks.Reload(); err != nil {
+			return nil, err
+		}
+		ks.mu.RLock()
+		key, ok = ks.keys[kid]
+		ks.mu.RUnlock()
+	}
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key.Private.Public(), nil
+}
+
+// Keys returns every key that can currently verify tokens, newest first.
+func (ks *KeySet) Keys() []*SigningKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	keys := make([]*SigningKey, 0, len(ks.keys))
+	for _, key := range ks.keys {
+		keys = append(keys, key)
+	}
+	sortKeys(keys)
+	return keys
+}
+
+// Reload re-reads the key directory.
+func (ks *KeySet) Reload() error {
+	entries, err := os.ReadDir(ks.dir)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*SigningKey)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != keyFileExt {
+			continue
+		}
+		key, err := ks.loadKey(entry)
+		if err != nil {
+			log.Printf("Warning: skipping signing key %s: %v", entry.Name(), err)
+			continue
+		}
+		keys[key.ID] = key
+	}
+
+	sorted := make([]*SigningKey, 0, len(keys))
+	for _, key := range keys {
+		sorted = append(sorted, key)
+	}
+	sortKeys(sorted)
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys = keys
+	ks.current = nil
+	if len(sorted) > 0 {
+		ks.current = sorted[0]
+	}
+	ks.loadedAt = time.Now()
+	return nil
+}
+
+// Rotate generates a new key, stores it in the directory and makes it the
+// signing key.
+func (ks *KeySet) Rotate() error {
+	private, err := generateKey(ks.algorithm)
+	if err != nil {
+		return err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	kid := time.Now().UTC().Format(keyIDTimeFormat) + "-" + hex.EncodeToString(suffix)
+
+	path := filepath.Join(ks.dir, kid+keyFileExt)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := pem.Encode(file, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	log.Printf("Generated new %s signing key %s", ks.algorithm, kid)
+	return ks.Reload()
+}
+
+// RotateEvery rotates the signing key once it is older than interval and
+// removes keys that are no longer needed. It never returns, so run it in its
+// own goroutine.
+func (ks *KeySet) RotateEvery(interval time.Duration) {
+	checkInterval := min(interval/10, time.Hour)
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := ks.Reload(); err != nil {
+			log.Printf("Warning: could not reload signing keys: %v", err)
+			continue
+		}
+		if current := ks.SigningKey(); current == nil || time.Since(current.CreatedAt) >= interval {
+			if err := ks.Rotate(); err != nil {
+				log.Printf("Warning: could not rotate signing key: %v", err)
+				continue
+			}
+		}
+		ks.prune()
+	}
+}
+
+// prune deletes the files of keys that were superseded more than retention
+// ago: every token they signed has expired by now.
+func (ks *KeySet) prune() {
+	keys := ks.Keys()
+	for i := 1; i < len(keys); i++ {
+		supersededAt := keys[i-1].CreatedAt
+		if time.Since(supersededAt) < ks.retention {
+			continue
+		}
+		path := filepath.Join(ks.dir, keys[i].ID+keyFileExt)
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Warning: could not remove retired signing key %s: %v", keys[i].ID, err)
+			continue
+		}
+		log.Printf("Removed retired signing key %s", keys[i].ID)
+	}
+	if err := ks.Reload(); err != nil {
+		log.Printf("Warning: could not reload signing keys: %v", err)
+	}
+}
+
+func (ks *KeySet) loadKey(entry os.DirEntry) (*SigningKey, error) {
+	path := filepath.Join(ks.dir, entry.Name())
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := entry.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	var private any
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := signerFor(ks.algorithm, private)
+	if err != nil {
+		return nil, err
+	}
+	kid := strings.TrimSuffix(entry.Name(), keyFileExt)
+	createdAt, ok := keyCreatedAt(kid)
+	if !ok {
+		createdAt = info.ModTime()
+	}
+	return &SigningKey{
+		ID:        kid,
+		CreatedAt: createdAt,
+		Private:   signer,
+	}, nil
+}
+
+// keyCreatedAt reads the creation time from a kid generated by Rotate.
+func keyCreatedAt(kid string) (time.Time, bool) {
+	prefix, _, ok := strings.Cut(kid, "-")
+	if !ok {
+		return time.Time{}, false
+	}
+	createdAt, err := time.Parse(keyIDTimeFormat, prefix)
+	return createdAt, err == nil
+}
+
+func signerFor(algorithm string, private any) (crypto.Signer, error) {
+	switch key := private.(type) {
+	case ed25519.PrivateKey:
+		if algorithm == AlgorithmEdDSA {
+			return key, nil
+		}
+	case *rsa.PrivateKey:
+		if algorithm == AlgorithmRS256 {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("key type %T cannot be used for %s", private, algorithm)
+}
+
+func generateKey(algorithm string) (crypto.Signer, error) {
+	if algorithm == AlgorithmRS256 {
+		return rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	}
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	return private, err
+}
+
+// sortKeys orders keys newest first.
+func sortKeys(keys []*SigningKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.After(keys[j].CreatedAt)
+		}
+		return keys[i].ID > keys[j].ID
+	})
+}
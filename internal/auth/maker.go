@@ -3,6 +3,8 @@ package auth
 import (
 	"errors"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 const (
@@ -16,6 +18,6 @@ var (
 )
 
 type Maker interface {
-	CreateToken(id int32, username string, duration time.Duration) (string, error)
+	CreateToken(id int32, username string, role string, sessionID uuid.UUID, duration time.Duration) (string, error)
 	VerifyToken(token string) (*Payload, error)
 }
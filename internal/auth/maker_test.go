@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "a_very_secret_key_should_be_longer_and_random"
+
+func newTestMakers(t *testing.T) map[string]Maker {
+	pasetoLocal, err := NewPasetoLocalMaker(testSecret[:32])
+	require.NoError(t, err)
+	pasetoPublic, err := NewPasetoPublicMaker(paseto.NewV4AsymmetricSecretKey().ExportHex())
+	require.NoError(t, err)
+
+	eddsaKeys, err := NewKeySet(t.TempDir(), AlgorithmEdDSA, time.Hour)
+	require.NoError(t, err)
+	rsaKeys, err := NewKeySet(t.TempDir(), AlgorithmRS256, time.Hour)
+	require.NoError(t, err)
+
+	return map[string]Maker{
+		"JWT":          NewJWTMaker(testSecret),
+		"JWTEdDSA":     NewAsymmetricJWTMaker(eddsaKeys),
+		"JWTRS256":     NewAsymmetricJWTMaker(rsaKeys),
+		"PasetoLocal":  pasetoLocal,
+		"PasetoPublic": pasetoPublic,
+	}
+}
+
+func TestMakers(t *testing.T) {
+	for name, maker := range newTestMakers(t) {
+		t.Run(name+"/Valid", func(t *testing.T) {
+			sessionID := uuid.New()
+			token, err := maker.CreateToken(10, "testuser", RoleAuthor, sessionID, time.Minute)
+			require.NoError(t, err)
+
+			payload, err := maker.VerifyToken(token)
+			require.NoError(t, err)
+			require.Equal(t, int32(10), payload.ID)
+			require.Equal(t, "testuser", payload.Username)
+			require.Equal(t, RoleAuthor, payload.Role)
+			require.NotEmpty(t, payload.TokenID)
+			require.Equal(t, sessionID, payload.SessionID)
+			require.WithinDuration(t, time.Now().Add(time.Minute), payload.ExpiresAt, time.Second)
+		})
+
+		t.Run(name+"/Expired", func(t *testing.T) {
+			token, err := maker.CreateToken(10, "testuser", RoleAuthor, uuid.Nil, -time.Minute)
+			require.NoError(t, err)
+
+			payload, err := maker.VerifyToken(token)
+			require.ErrorIs(t, err, ErrTokenExpired)
+			require.Nil(t, payload)
+		})
+
+		t.Run(name+"/Tampered", func(t *testing.T) {
+			token, err := maker.CreateToken(10, "testuser", RoleAuthor, uuid.Nil, time.Minute)
+			require.NoError(t, err)
+
+			// Change a character well inside the signature; the last one may
+			// only carry padding bits.
+			tampered := []byte(token)
+			i := len(tampered) - 10
+			if tampered[i] == 'A' {
+				tampered[i] = 'B'
+			} else {
+				tampered[i] = 'A'
+			}
+			payload, err := maker.VerifyToken(string(tampered))
+			require.ErrorIs(t, err, ErrInvalidToken)
+			require.Nil(t, payload)
+		})
+	}
+}
+
+func TestPasetoMakerRejectsOtherKeys(t *testing.T) {
+	maker, err := NewPasetoLocalMaker(testSecret[:32])
+	require.NoError(t, err)
+	other, err := NewPasetoLocalMaker(testSecret[1:33])
+	require.NoError(t, err)
+
+	token, err := other.CreateToken(10, "testuser", RoleAuthor, uuid.Nil, time.Minute)
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = NewPasetoLocalMaker("too-short")
+	require.ErrorIs(t, err, ErrInvalidKeySize)
+}
+
+func TestAsymmetricJWTMakerKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	keys, err := NewKeySet(dir, AlgorithmEdDSA, time.Hour)
+	require.NoError(t, err)
+	maker := NewAsymmetricJWTMaker(keys)
+
+	oldToken, err := maker.CreateToken(10, "testuser", RoleAuthor, uuid.Nil, time.Minute)
+	require.NoError(t, err)
+	oldKey := keys.SigningKey()
+
+	require.NoError(t, keys.Rotate())
+	require.NotEqual(t, oldKey.ID, keys.SigningKey().ID)
+
+	// Tokens signed with the retired key still verify, also on another
+	// instance sharing the key directory.
+	otherKeys, err := NewKeySet(dir, AlgorithmEdDSA, time.Hour)
+	require.NoError(t, err)
+	for _, m := range []Maker{maker, NewAsymmetricJWTMaker(otherKeys)} {
+		_, err = m.VerifyToken(oldToken)
+		require.NoError(t, err)
+	}
+
+	jwks := maker.(JWKSProvider).JWKS()
+	require.Len(t, jwks.Keys, 2)
+	require.Equal(t, keys.SigningKey().ID, jwks.Keys[0].KeyID)
+	require.Equal(t, "OKP", jwks.Keys[0].KeyType)
+}
+
+func TestKeySetAgeIgnoresModificationTime(t *testing.T) {
+	dir := t.TempDir()
+	keys, err := NewKeySet(dir, AlgorithmEdDSA, time.Hour)
+	require.NoError(t, err)
+	oldKey := keys.SigningKey()
+	require.NoError(t, keys.Rotate())
+	newKey := keys.SigningKey()
+
+	// Copying the directory to another host, for instance, touches the files
+	// in some arbitrary order.
+	future := time.Now().Add(24 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, oldKey.ID+keyFileExt), future, future))
+	require.NoError(t, keys.Reload())
+
+	require.Equal(t, newKey.ID, keys.SigningKey().ID)
+	require.Equal(t, oldKey.CreatedAt, keys.Keys()[1].CreatedAt)
+	require.WithinDuration(t, time.Now(), newKey.CreatedAt, time.Minute)
+}
+
+func TestAsymmetricJWTMakerRejectsHS256(t *testing.T) {
+	keys, err := NewKeySet(t.TempDir(), AlgorithmEdDSA, time.Hour)
+	require.NoError(t, err)
+	maker := NewAsymmetricJWTMaker(keys)
+
+	token, err := NewJWTMaker(testSecret).CreateToken(10, "testuser", RoleAuthor, uuid.Nil, time.Minute)
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
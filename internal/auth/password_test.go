@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testArgon2idParams = Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2idHasher(t *testing.T) {
+	hasher, err := NewArgon2idHasher(testArgon2idParams)
+	require.NoError(t, err)
+
+	hash, err := hasher.Hash("correct horse")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"), hash)
+
+	other, err := hasher.Hash("correct horse")
+	require.NoError(t, err)
+	require.NotEqual(t, hash, other, "hashes must be salted")
+
+	match, needsRehash, err := hasher.Verify("correct horse", hash)
+	require.NoError(t, err)
+	require.True(t, match)
+	require.False(t, needsRehash)
+
+	match, _, err = hasher.Verify("battery staple", hash)
+	require.NoError(t, err)
+	require.False(t, match)
+
+	stronger := testArgon2idParams
+	stronger.Iterations = 2
+	upgraded, err := NewArgon2idHasher(stronger)
+	require.NoError(t, err)
+	match, needsRehash, err = upgraded.Verify("correct horse", hash)
+	require.NoError(t, err)
+	require.True(t, match)
+	require.True(t, needsRehash)
+}
+
+func TestPasswordHasherMigratesAlgorithms(t *testing.T) {
+	argon2idHasher, err := NewArgon2idHasher(testArgon2idParams)
+	require.NoError(t, err)
+	bcryptHasher, err := NewBcryptHasher(bcrypt.MinCost)
+	require.NoError(t, err)
+
+	bcryptHash, err := bcryptHasher.Hash("correct horse")
+	require.NoError(t, err)
+	match, needsRehash, err := argon2idHasher.Verify("correct horse", bcryptHash)
+	require.NoError(t, err)
+	require.True(t, match)
+	require.True(t, needsRehash)
+
+	argon2idHash, err := argon2idHasher.Hash("correct horse")
+	require.NoError(t, err)
+	match, needsRehash, err = bcryptHasher.Verify("correct horse", argon2idHash)
+	require.NoError(t, err)
+	require.True(t, match)
+	require.True(t, needsRehash)
+
+	costlier, err := NewBcryptHasher(bcrypt.MinCost + 1)
+	require.NoError(t, err)
+	match, needsRehash, err = costlier.Verify("correct horse", bcryptHash)
+	require.NoError(t, err)
+	require.True(t, match)
+	require.True(t, needsRehash)
+}
+
+func TestPasswordHasherRejectsUnusableHashes(t *testing.T) {
+	hasher, err := NewArgon2idHasher(testArgon2idParams)
+	require.NoError(t, err)
+
+	// Accounts without a password store a marker that is not a hash.
+	match, _, err := hasher.Verify("", "!")
+	require.NoError(t, err)
+	require.False(t, match)
+
+	_, _, err = hasher.Verify("correct horse", "$argon2id$v=19$m=64,t=1,p=1$not base64!$AAAA")
+	require.ErrorIs(t, err, ErrInvalidPasswordHash)
+
+	_, err = NewBcryptHasher(bcrypt.MaxCost + 1)
+	require.Error(t, err)
+	_, err = NewArgon2idHasher(Argon2idParams{Memory: 64, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	require.Error(t, err)
+}
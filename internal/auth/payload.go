@@ -1,23 +1,41 @@
 package auth
 
 import (
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type Payload struct {
 	ID        int32     `json:"id"`
+	TokenID   uuid.UUID `json:"jti"`
+	SessionID uuid.UUID `json:"sid"`
 	Username  string    `json:"username"`
+	Role      string    `json:"role"`
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiresAt time.Time `json:"expired_at"`
+
+	// PersonalAccessTokenID is set when the request was authenticated with a
+	// personal access token, which may only use the listed Scopes. It is never
+	// part of a token issued by a Maker.
+	PersonalAccessTokenID int64    `json:"-"`
+	Scopes                []string `json:"-"`
 }
 
-func NewPayload(id int32, username string, duration time.Duration) (*Payload, error) {
+func NewPayload(id int32, username string, role string, sessionID uuid.UUID, duration time.Duration) (*Payload, error) {
+	tokenID, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
 
 	return &Payload{
 		ID:        id,
+		TokenID:   tokenID,
+		SessionID: sessionID,
 		Username:  username,
+		Role:      role,
 		IssuedAt:  time.Now(),
 		ExpiresAt: time.Now().Add(duration),
 	}, nil
@@ -32,6 +50,18 @@ func (p *Payload) Valid() error {
 	return nil
 }
 
+// IsPersonalAccessToken reports whether the payload came from a personal
+// access token rather than a login session.
+func (p *Payload) IsPersonalAccessToken() bool {
+	return p.PersonalAccessTokenID != 0
+}
+
+// HasScope reports whether the token may be used for scope. Session tokens
+// have every scope.
+func (p *Payload) HasScope(scope string) bool {
+	return !p.IsPersonalAccessToken() || slices.Contains(p.Scopes, scope)
+}
+
 // GetAudience implements jwt.Claims.
 func (p *Payload) GetAudience() (jwt.ClaimStrings, error) {
 	return nil, nil
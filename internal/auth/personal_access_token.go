@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"slices"
+	"strings"
+)
+
+// PersonalAccessTokenPrefix starts every personal access token, which tells
+// them apart from access tokens issued by a Maker and makes leaked tokens easy
+// to find with secret scanners.
+const PersonalAccessTokenPrefix = "plog_pat_"
+
+const personalAccessTokenSecretSize = 32
+
+const (
+	ScopePostsRead      = "posts:read"
+	ScopePostsWrite     = "posts:write"
+	ScopeCommentsWrite  = "comments:write"
+	ScopeReactionsWrite = "reactions:write"
+)
+
+// Scopes lists every scope a personal access token can be granted.
+var Scopes = []string{ScopePostsRead, ScopePostsWrite, ScopeCommentsWrite, ScopeReactionsWrite}
+
+func IsValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+// NewPersonalAccessToken returns a new personal access token and the hash to
+// store for it.
+func NewPersonalAccessToken() (string, string, error) {
+	secret, err := RandomToken(personalAccessTokenSecretSize)
+	if err != nil {
+		return "", "", err
+	}
+	token := PersonalAccessTokenPrefix + secret
+	return token, HashToken(token), nil
+}
+
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const refreshTokenSecretSize = 32
+
+// NewRefreshToken returns an opaque refresh token for the given session along
+// with the hash that should be stored for it. Only the hash is ever persisted.
+func NewRefreshToken(sessionID uuid.UUID) (token string, hash string, err error) {
+	secret, err := RandomToken(refreshTokenSecretSize)
+	if err != nil {
+		return "", "", err
+	}
+	return sessionID.String() + "." + secret, HashToken(secret), nil
+}
+
+// ParseRefreshToken splits a refresh token into its session ID and the hash of
+// its secret part.
+func ParseRefreshToken(token string) (uuid.UUID, string, error) {
+	sessionPart, secret, ok := strings.Cut(token, ".")
+	if !ok || secret == "" {
+		return uuid.Nil, "", ErrInvalidToken
+	}
+	sessionID, err := uuid.Parse(sessionPart)
+	if err != nil {
+		return uuid.Nil, "", ErrInvalidToken
+	}
+	return sessionID, HashToken(secret), nil
+}
+
+// RandomToken returns n random bytes encoded as unpadded base64url.
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hex encoded SHA-256 of a high-entropy token. It is not
+// suitable for passwords.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,53 @@
+package auth
+
+import "slices"
+
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleAuthor = "author"
+	RoleReader = "reader"
+)
+
+type Permission string
+
+const (
+	PermissionCreatePost    Permission = "posts:create"
+	PermissionUpdateAnyPost Permission = "posts:update_any"
+	PermissionDeleteAnyPost Permission = "posts:delete_any"
+	// PermissionModerateComments allows deleting any comment and locking the
+	// comments of any post.
+	PermissionModerateComments Permission = "comments:moderate"
+	PermissionManageUsers      Permission = "users:manage"
+)
+
+// rolePermissions lists what each role may do beyond reading public content
+// and managing its own posts and comments.
+var rolePermissions = map[string][]Permission{
+	RoleAdmin: {
+		PermissionCreatePost,
+		PermissionUpdateAnyPost,
+		PermissionDeleteAnyPost,
+		PermissionModerateComments,
+		PermissionManageUsers,
+	},
+	RoleEditor: {
+		PermissionCreatePost,
+		PermissionUpdateAnyPost,
+		PermissionDeleteAnyPost,
+		PermissionModerateComments,
+	},
+	RoleAuthor: {
+		PermissionCreatePost,
+	},
+	RoleReader: {},
+}
+
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+func HasPermission(role string, permission Permission) bool {
+	return slices.Contains(rolePermissions[role], permission)
+}
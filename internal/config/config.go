@@ -2,18 +2,119 @@ package config
 
 import (
 	"log"
+	"net"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
+const (
+	TokenMakerJWT          = "jwt"
+	TokenMakerPasetoLocal  = "paseto_local"
+	TokenMakerPasetoPublic = "paseto_public"
+	TokenMakerJWTEdDSA     = "jwt_eddsa"
+	TokenMakerJWTRS256     = "jwt_rs256"
+
+	MailSenderLog  = "log"
+	MailSenderFile = "file"
+	MailSenderSMTP = "smtp"
+
+	PasswordHasherArgon2id = "argon2id"
+	PasswordHasherBcrypt   = "bcrypt"
+
+	CookieSameSiteLax    = "lax"
+	CookieSameSiteStrict = "strict"
+	CookieSameSiteNone   = "none"
+
+	AccountDeletionDelete    = "delete"
+	AccountDeletionAnonymize = "anonymize"
+
+	HTMLPolicyUGC   = "ugc"
+	HTMLPolicyBasic = "basic"
+)
+
+// OIDCProvider is an external OpenID Connect provider users can sign in with.
+type OIDCProvider struct {
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+var oidcProviderNameRegexp = regexp.MustCompile(`^[a-z0-9-]{1,50}$`)
+
+var htmlElementRegexp = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+var searchConfigRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\.[a-z_][a-z0-9_]*)?$`)
+
 type Config struct {
-	DatabaseURL         string
-	JWTSecret           string
-	ServerPort          string
-	AccessTokenDuration time.Duration
+	DatabaseURL          string
+	PasswordHasher       string
+	Argon2Memory         int
+	Argon2Iterations     int
+	Argon2Parallelism    int
+	BcryptCost           int
+	TokenMaker           string
+	JWTSecret            string
+	PasetoSymmetricKey   string
+	PasetoSecretKey      string
+	JWTKeyDir            string
+	JWTKeyRotation       time.Duration
+	ServerPort           string
+	AccessTokenDuration  time.Duration
+	RefreshTokenDuration time.Duration
+	AppBaseURL           string
+	MailSender           string
+	MailFrom             string
+	MailDir              string
+	SMTPHost             string
+	SMTPPort             int
+	SMTPUsername         string
+	SMTPPassword         string
+	PasswordResetTTL     time.Duration
+	EmailRequired        bool
+	RequireVerifiedEmail bool
+	EmailVerificationTTL time.Duration
+	TOTPIssuer           string
+	MFAChallengeTTL      time.Duration
+	OIDCProviders        []OIDCProvider
+
+	LoginMaxFailures        int
+	LoginMaxFailuresPerIP   int
+	LoginLockoutDuration    time.Duration
+	LoginMaxLockoutDuration time.Duration
+
+	// CookieAuth makes logins also set the tokens as HttpOnly cookies for
+	// browser clients, guarded by a double-submit CSRF token.
+	CookieAuth     bool
+	CookieSecure   bool
+	CookieDomain   string
+	CookieSameSite string
+
+	// AccountDeletionGracePeriod is how long a deletion request can be
+	// cancelled. AccountDeletionMode decides whether the account and its
+	// posts are then deleted, or the account is anonymized and its posts kept.
+	AccountDeletionGracePeriod time.Duration
+	AccountDeletionMode        string
+
+	// HTMLPolicy decides which HTML post content may contain, and
+	// HTMLAllowedElements are allowed on top of it.
+	HTMLPolicy          string
+	HTMLAllowedElements []string
+
+	// SearchConfig is the Postgres text search configuration of post search,
+	// such as simple or english.
+	SearchConfig string
+
+	// TrustedProxies are the IPs and CIDRs of reverse proxies whose
+	// X-Forwarded-For header is believed. The client IP of other requests is
+	// the peer address.
+	TrustedProxies []string
 }
 
 func LoadConfig() (*Config, error) {
@@ -28,9 +129,81 @@ func LoadConfig() (*Config, error) {
 		log.Fatal("DATABASE_URL environment variable is required")
 	}
 
+	tokenMaker := os.Getenv("TOKEN_MAKER")
+	if tokenMaker == "" {
+		tokenMaker = TokenMakerJWT
+	}
+
 	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		log.Fatal("JWT_SECRET environment variable is required")
+	pasetoSymmetricKey := os.Getenv("PASETO_SYMMETRIC_KEY")
+	pasetoSecretKey := os.Getenv("PASETO_SECRET_KEY")
+	switch tokenMaker {
+	case TokenMakerJWT:
+		if jwtSecret == "" {
+			log.Fatal("JWT_SECRET environment variable is required")
+		}
+	case TokenMakerPasetoLocal:
+		if pasetoSymmetricKey == "" {
+			log.Fatal("PASETO_SYMMETRIC_KEY environment variable is required when TOKEN_MAKER=paseto_local")
+		}
+	case TokenMakerPasetoPublic:
+		if pasetoSecretKey == "" {
+			log.Fatal("PASETO_SECRET_KEY environment variable is required when TOKEN_MAKER=paseto_public")
+		}
+	case TokenMakerJWTEdDSA, TokenMakerJWTRS256:
+	default:
+		log.Fatalf("Invalid TOKEN_MAKER: %s", tokenMaker)
+	}
+
+	passwordHasher := os.Getenv("PASSWORD_HASHER")
+	if passwordHasher == "" {
+		passwordHasher = PasswordHasherArgon2id
+	}
+	switch passwordHasher {
+	case PasswordHasherArgon2id, PasswordHasherBcrypt:
+	default:
+		log.Fatalf("Invalid PASSWORD_HASHER: %s", passwordHasher)
+	}
+	argon2Memory := 19 * 1024
+	if argon2MemoryStr := os.Getenv("ARGON2_MEMORY"); argon2MemoryStr != "" {
+		argon2Memory, err = strconv.Atoi(argon2MemoryStr)
+		if err != nil {
+			log.Fatalf("Invalid ARGON2_MEMORY: %v", err)
+		}
+	}
+	argon2Iterations := 2
+	if argon2IterationsStr := os.Getenv("ARGON2_ITERATIONS"); argon2IterationsStr != "" {
+		argon2Iterations, err = strconv.Atoi(argon2IterationsStr)
+		if err != nil {
+			log.Fatalf("Invalid ARGON2_ITERATIONS: %v", err)
+		}
+	}
+	argon2Parallelism := 1
+	if argon2ParallelismStr := os.Getenv("ARGON2_PARALLELISM"); argon2ParallelismStr != "" {
+		argon2Parallelism, err = strconv.Atoi(argon2ParallelismStr)
+		if err != nil {
+			log.Fatalf("Invalid ARGON2_PARALLELISM: %v", err)
+		}
+	}
+	bcryptCost := 12
+	if bcryptCostStr := os.Getenv("BCRYPT_COST"); bcryptCostStr != "" {
+		bcryptCost, err = strconv.Atoi(bcryptCostStr)
+		if err != nil {
+			log.Fatalf("Invalid BCRYPT_COST: %v", err)
+		}
+	}
+
+	jwtKeyDir := os.Getenv("JWT_KEY_DIR")
+	if jwtKeyDir == "" {
+		jwtKeyDir = "keys"
+	}
+
+	jwtKeyRotation := 30 * 24 * time.Hour
+	if jwtKeyRotationStr := os.Getenv("JWT_KEY_ROTATION_INTERVAL"); jwtKeyRotationStr != "" {
+		jwtKeyRotation, err = time.ParseDuration(jwtKeyRotationStr)
+		if err != nil {
+			log.Fatalf("Invalid JWT_KEY_ROTATION_INTERVAL format: %v", err)
+		}
 	}
 	accessTokenDurationStr := os.Getenv("ACCESS_TOKEN_DURATION")
 	if accessTokenDurationStr == "" {
@@ -42,6 +215,184 @@ func LoadConfig() (*Config, error) {
 		log.Fatalf("Invalid ACCESS_TOKEN_DURATION format: %v", err)
 	}
 
+	refreshTokenDuration := 7 * 24 * time.Hour
+	if refreshTokenDurationStr := os.Getenv("REFRESH_TOKEN_DURATION"); refreshTokenDurationStr != "" {
+		refreshTokenDuration, err = time.ParseDuration(refreshTokenDurationStr)
+		if err != nil {
+			log.Fatalf("Invalid REFRESH_TOKEN_DURATION format: %v", err)
+		}
+	}
+
+	appBaseURL := strings.TrimSuffix(os.Getenv("APP_BASE_URL"), "/")
+	if appBaseURL == "" {
+		appBaseURL = "http://localhost:8080"
+	}
+
+	mailSender := os.Getenv("MAIL_SENDER")
+	if mailSender == "" {
+		mailSender = MailSenderLog
+	}
+	mailFrom := os.Getenv("MAIL_FROM")
+	if mailFrom == "" {
+		mailFrom = "Plog <no-reply@localhost>"
+	}
+	mailDir := os.Getenv("MAIL_DIR")
+	if mailDir == "" {
+		mailDir = "mail"
+	}
+	smtpHost := os.Getenv("SMTP_HOST")
+	smtpPort := 587
+	if smtpPortStr := os.Getenv("SMTP_PORT"); smtpPortStr != "" {
+		smtpPort, err = strconv.Atoi(smtpPortStr)
+		if err != nil {
+			log.Fatalf("Invalid SMTP_PORT: %v", err)
+		}
+	}
+	switch mailSender {
+	case MailSenderLog, MailSenderFile:
+	case MailSenderSMTP:
+		if smtpHost == "" {
+			log.Fatal("SMTP_HOST environment variable is required when MAIL_SENDER=smtp")
+		}
+	default:
+		log.Fatalf("Invalid MAIL_SENDER: %s", mailSender)
+	}
+
+	passwordResetTTL := time.Hour
+	if passwordResetTTLStr := os.Getenv("PASSWORD_RESET_TOKEN_DURATION"); passwordResetTTLStr != "" {
+		passwordResetTTL, err = time.ParseDuration(passwordResetTTLStr)
+		if err != nil {
+			log.Fatalf("Invalid PASSWORD_RESET_TOKEN_DURATION format: %v", err)
+		}
+	}
+
+	emailRequired := parseBool("EMAIL_REQUIRED")
+	requireVerifiedEmail := parseBool("REQUIRE_VERIFIED_EMAIL")
+	if requireVerifiedEmail {
+		// Accounts without an address could never be allowed to post.
+		emailRequired = true
+	}
+
+	emailVerificationTTL := 48 * time.Hour
+	if emailVerificationTTLStr := os.Getenv("EMAIL_VERIFICATION_TOKEN_DURATION"); emailVerificationTTLStr != "" {
+		emailVerificationTTL, err = time.ParseDuration(emailVerificationTTLStr)
+		if err != nil {
+			log.Fatalf("Invalid EMAIL_VERIFICATION_TOKEN_DURATION format: %v", err)
+		}
+	}
+
+	totpIssuer := os.Getenv("TOTP_ISSUER")
+	if totpIssuer == "" {
+		totpIssuer = "Plog"
+	}
+
+	mfaChallengeTTL := 5 * time.Minute
+	if mfaChallengeTTLStr := os.Getenv("MFA_CHALLENGE_DURATION"); mfaChallengeTTLStr != "" {
+		mfaChallengeTTL, err = time.ParseDuration(mfaChallengeTTLStr)
+		if err != nil {
+			log.Fatalf("Invalid MFA_CHALLENGE_DURATION format: %v", err)
+		}
+	}
+
+	oidcProviders := loadOIDCProviders()
+
+	loginMaxFailures := 5
+	if loginMaxFailuresStr := os.Getenv("LOGIN_MAX_FAILURES"); loginMaxFailuresStr != "" {
+		loginMaxFailures, err = strconv.Atoi(loginMaxFailuresStr)
+		if err != nil {
+			log.Fatalf("Invalid LOGIN_MAX_FAILURES: %v", err)
+		}
+	}
+	loginMaxFailuresPerIP := 20
+	if loginMaxFailuresPerIPStr := os.Getenv("LOGIN_MAX_FAILURES_PER_IP"); loginMaxFailuresPerIPStr != "" {
+		loginMaxFailuresPerIP, err = strconv.Atoi(loginMaxFailuresPerIPStr)
+		if err != nil {
+			log.Fatalf("Invalid LOGIN_MAX_FAILURES_PER_IP: %v", err)
+		}
+	}
+	loginLockoutDuration := time.Minute
+	if loginLockoutDurationStr := os.Getenv("LOGIN_LOCKOUT_DURATION"); loginLockoutDurationStr != "" {
+		loginLockoutDuration, err = time.ParseDuration(loginLockoutDurationStr)
+		if err != nil {
+			log.Fatalf("Invalid LOGIN_LOCKOUT_DURATION format: %v", err)
+		}
+	}
+	loginMaxLockoutDuration := time.Hour
+	if loginMaxLockoutDurationStr := os.Getenv("LOGIN_MAX_LOCKOUT_DURATION"); loginMaxLockoutDurationStr != "" {
+		loginMaxLockoutDuration, err = time.ParseDuration(loginMaxLockoutDurationStr)
+		if err != nil {
+			log.Fatalf("Invalid LOGIN_MAX_LOCKOUT_DURATION format: %v", err)
+		}
+	}
+	if loginMaxLockoutDuration < loginLockoutDuration {
+		log.Fatal("LOGIN_MAX_LOCKOUT_DURATION must not be shorter than LOGIN_LOCKOUT_DURATION")
+	}
+
+	cookieAuth := parseBool("COOKIE_AUTH")
+	cookieSecure := true
+	if os.Getenv("COOKIE_SECURE") != "" {
+		cookieSecure = parseBool("COOKIE_SECURE")
+	}
+	cookieSameSite := strings.ToLower(os.Getenv("COOKIE_SAMESITE"))
+	if cookieSameSite == "" {
+		cookieSameSite = CookieSameSiteStrict
+	}
+	switch cookieSameSite {
+	case CookieSameSiteLax, CookieSameSiteStrict:
+	case CookieSameSiteNone:
+		if !cookieSecure {
+			log.Fatal("COOKIE_SAMESITE=none requires COOKIE_SECURE=true")
+		}
+	default:
+		log.Fatalf("Invalid COOKIE_SAMESITE: %s", cookieSameSite)
+	}
+
+	accountDeletionGracePeriod := 7 * 24 * time.Hour
+	if accountDeletionGracePeriodStr := os.Getenv("ACCOUNT_DELETION_GRACE_PERIOD"); accountDeletionGracePeriodStr != "" {
+		accountDeletionGracePeriod, err = time.ParseDuration(accountDeletionGracePeriodStr)
+		if err != nil {
+			log.Fatalf("Invalid ACCOUNT_DELETION_GRACE_PERIOD format: %v", err)
+		}
+	}
+	accountDeletionMode := os.Getenv("ACCOUNT_DELETION_MODE")
+	if accountDeletionMode == "" {
+		accountDeletionMode = AccountDeletionDelete
+	}
+	switch accountDeletionMode {
+	case AccountDeletionDelete, AccountDeletionAnonymize:
+	default:
+		log.Fatalf("Invalid ACCOUNT_DELETION_MODE: %s", accountDeletionMode)
+	}
+
+	htmlPolicy := strings.ToLower(os.Getenv("HTML_POLICY"))
+	if htmlPolicy == "" {
+		htmlPolicy = HTMLPolicyUGC
+	}
+	switch htmlPolicy {
+	case HTMLPolicyUGC, HTMLPolicyBasic:
+	default:
+		log.Fatalf("Invalid HTML_POLICY: %s", htmlPolicy)
+	}
+	var htmlAllowedElements []string
+	for _, element := range strings.Split(os.Getenv("HTML_ALLOWED_ELEMENTS"), ",") {
+		element = strings.ToLower(strings.TrimSpace(element))
+		if element == "" {
+			continue
+		}
+		if !htmlElementRegexp.MatchString(element) {
+			log.Fatalf("Invalid element in HTML_ALLOWED_ELEMENTS: %s", element)
+		}
+		htmlAllowedElements = append(htmlAllowedElements, element)
+	}
+
+	searchConfig := strings.ToLower(os.Getenv("SEARCH_CONFIG"))
+	if searchConfig == "" {
+		searchConfig = "simple"
+	}
+	if !searchConfigRegexp.MatchString(searchConfig) {
+		log.Fatalf("Invalid SEARCH_CONFIG: %s", searchConfig)
+	}
+
 	serverPort := os.Getenv("SERVER_PORT")
 	if serverPort == "" {
 		serverPort = "8080"
@@ -51,10 +402,111 @@ func LoadConfig() (*Config, error) {
 		log.Fatalf("Invalid SERVER_PORT: %v", err)
 	}
 
+	var trustedProxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			log.Fatalf("Invalid address in TRUSTED_PROXIES: %s", proxy)
+		}
+		trustedProxies = append(trustedProxies, proxy)
+	}
+
 	return &Config{
-		DatabaseURL:         dbURL,
-		JWTSecret:           jwtSecret,
-		ServerPort:          serverPort,
-		AccessTokenDuration: accessTokenDuration,
+		DatabaseURL:          dbURL,
+		PasswordHasher:       passwordHasher,
+		Argon2Memory:         argon2Memory,
+		Argon2Iterations:     argon2Iterations,
+		Argon2Parallelism:    argon2Parallelism,
+		BcryptCost:           bcryptCost,
+		TokenMaker:           tokenMaker,
+		JWTSecret:            jwtSecret,
+		PasetoSymmetricKey:   pasetoSymmetricKey,
+		PasetoSecretKey:      pasetoSecretKey,
+		JWTKeyDir:            jwtKeyDir,
+		JWTKeyRotation:       jwtKeyRotation,
+		ServerPort:           serverPort,
+		AccessTokenDuration:  accessTokenDuration,
+		RefreshTokenDuration: refreshTokenDuration,
+		AppBaseURL:           appBaseURL,
+		MailSender:           mailSender,
+		MailFrom:             mailFrom,
+		MailDir:              mailDir,
+		SMTPHost:             smtpHost,
+		SMTPPort:             smtpPort,
+		SMTPUsername:         os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:         os.Getenv("SMTP_PASSWORD"),
+		PasswordResetTTL:     passwordResetTTL,
+		EmailRequired:        emailRequired,
+		RequireVerifiedEmail: requireVerifiedEmail,
+		EmailVerificationTTL: emailVerificationTTL,
+		TOTPIssuer:           totpIssuer,
+		MFAChallengeTTL:      mfaChallengeTTL,
+		OIDCProviders:        oidcProviders,
+
+		LoginMaxFailures:        loginMaxFailures,
+		LoginMaxFailuresPerIP:   loginMaxFailuresPerIP,
+		LoginLockoutDuration:    loginLockoutDuration,
+		LoginMaxLockoutDuration: loginMaxLockoutDuration,
+
+		CookieAuth:     cookieAuth,
+		CookieSecure:   cookieSecure,
+		CookieDomain:   os.Getenv("COOKIE_DOMAIN"),
+		CookieSameSite: cookieSameSite,
+
+		AccountDeletionGracePeriod: accountDeletionGracePeriod,
+		AccountDeletionMode:        accountDeletionMode,
+
+		HTMLPolicy:          htmlPolicy,
+		HTMLAllowedElements: htmlAllowedElements,
+
+		SearchConfig: searchConfig,
+
+		TrustedProxies: trustedProxies,
 	}, nil
 }
+
+// loadOIDCProviders reads the providers listed in OIDC_PROVIDERS. Each name
+// is configured with OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID,
+// OIDC_<NAME>_CLIENT_SECRET and optionally OIDC_<NAME>_SCOPES.
+func loadOIDCProviders() []OIDCProvider {
+	var providers []OIDCProvider
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !oidcProviderNameRegexp.MatchString(name) {
+			log.Fatalf("Invalid OIDC provider name %q: use lowercase letters, digits and dashes", name)
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		provider := OIDCProvider{
+			Name:         name,
+			IssuerURL:    os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if provider.IssuerURL == "" || provider.ClientID == "" {
+			log.Fatalf("%sISSUER and %sCLIENT_ID environment variables are required for OIDC provider %s", prefix, prefix, name)
+		}
+		providers = append(providers, provider)
+	}
+	return providers
+}
+
+// parseBool reads an optional boolean environment variable, defaulting to false.
+func parseBool(name string) bool {
+	value := os.Getenv(name)
+	if value == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", name, err)
+	}
+	return b
+}
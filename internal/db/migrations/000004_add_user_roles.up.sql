@@ -0,0 +1,3 @@
+ALTER TABLE users
+  ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'author'
+  CHECK (role IN ('admin', 'editor', 'author', 'reader'));
@@ -0,0 +1,8 @@
+CREATE TABLE login_failures (
+  scope VARCHAR(10) NOT NULL CHECK (scope IN ('username', 'ip')),
+  key VARCHAR(255) NOT NULL,
+  failures INTEGER NOT NULL DEFAULT 0,
+  last_failure_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  locked_until TIMESTAMPTZ,
+  PRIMARY KEY (scope, key)
+);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_sessions_revoked_at;
+
+ALTER TABLE sessions
+  DROP COLUMN IF EXISTS revoked_at,
+  DROP COLUMN IF EXISTS last_seen_at,
+  DROP COLUMN IF EXISTS client_ip,
+  DROP COLUMN IF EXISTS user_agent;
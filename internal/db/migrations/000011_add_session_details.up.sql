@@ -0,0 +1,7 @@
+ALTER TABLE sessions
+  ADD COLUMN user_agent VARCHAR(512) NOT NULL DEFAULT '',
+  ADD COLUMN client_ip VARCHAR(64) NOT NULL DEFAULT '',
+  ADD COLUMN last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  ADD COLUMN revoked_at TIMESTAMPTZ;
+
+CREATE INDEX idx_sessions_revoked_at ON sessions(revoked_at);
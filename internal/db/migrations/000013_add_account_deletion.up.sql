@@ -0,0 +1,5 @@
+ALTER TABLE users
+  ADD COLUMN deletion_scheduled_at TIMESTAMPTZ,
+  ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX idx_users_deletion_scheduled_at ON users(deletion_scheduled_at);
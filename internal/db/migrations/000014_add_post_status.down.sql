@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_posts_scheduled_at;
+DROP INDEX IF EXISTS idx_posts_published_at;
+
+ALTER TABLE posts
+  DROP CONSTRAINT IF EXISTS posts_published_at_check,
+  DROP COLUMN IF EXISTS published_at,
+  DROP COLUMN IF EXISTS status;
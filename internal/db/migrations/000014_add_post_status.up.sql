@@ -0,0 +1,13 @@
+-- Existing posts were public, so they start out published.
+ALTER TABLE posts
+  ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'published'
+    CHECK (status IN ('draft', 'published', 'scheduled', 'archived')),
+  ADD COLUMN published_at TIMESTAMPTZ;
+
+UPDATE posts SET published_at = created_at;
+
+ALTER TABLE posts
+  ADD CONSTRAINT posts_published_at_check CHECK (status NOT IN ('published', 'scheduled') OR published_at IS NOT NULL);
+
+CREATE INDEX idx_posts_published_at ON posts(published_at DESC) WHERE status = 'published';
+CREATE INDEX idx_posts_scheduled_at ON posts(published_at) WHERE status = 'scheduled';
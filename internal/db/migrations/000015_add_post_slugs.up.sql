@@ -0,0 +1,43 @@
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+ALTER TABLE posts
+  ADD COLUMN slug VARCHAR(255),
+  ADD CONSTRAINT posts_slug_key UNIQUE (slug);
+
+-- Existing posts get a slug from their title, oldest first. Like new posts,
+-- a post whose slug is taken gets the first numeric suffix no post has, which
+-- also keeps clear of titles that end in a number ('Hello 2').
+--
+-- unaccent and the pattern only keep Latin letters and digits, so titles in
+-- other scripts, which slug.Make transliterates or keeps, get 'post' here.
+-- Such a post moves to the slug slug.Make gives once its title changes.
+DO $$
+DECLARE
+  post RECORD;
+  candidate VARCHAR(255);
+  n INTEGER;
+BEGIN
+  FOR post IN
+    SELECT id, COALESCE(NULLIF(left(trim(BOTH '-' FROM regexp_replace(lower(unaccent(title)), '[^a-z0-9]+', '-', 'g')), 80), ''), 'post') AS base
+    FROM posts
+    ORDER BY id
+  LOOP
+    candidate := post.base;
+    n := 2;
+    WHILE EXISTS (SELECT 1 FROM posts WHERE slug = candidate) LOOP
+      candidate := post.base || '-' || n;
+      n := n + 1;
+    END LOOP;
+    UPDATE posts SET slug = candidate WHERE id = post.id;
+  END LOOP;
+END $$;
+
+ALTER TABLE posts ALTER COLUMN slug SET NOT NULL;
+
+CREATE TABLE post_slugs (
+  slug VARCHAR(255) PRIMARY KEY,
+  post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_post_slugs_post_id ON post_slugs(post_id);
@@ -0,0 +1,4 @@
+ALTER TABLE posts
+  DROP COLUMN IF EXISTS rendered_with,
+  DROP COLUMN IF EXISTS content_html,
+  DROP COLUMN IF EXISTS format;
@@ -0,0 +1,9 @@
+-- Posts written before formats existed are plain text; new posts default to
+-- Markdown. content_html is filled in by the server.
+ALTER TABLE posts
+  ADD COLUMN format VARCHAR(10) NOT NULL DEFAULT 'plain'
+    CHECK (format IN ('markdown', 'plain', 'html')),
+  ADD COLUMN content_html TEXT,
+  ADD COLUMN rendered_with VARCHAR(64);
+
+ALTER TABLE posts ALTER COLUMN format SET DEFAULT 'markdown';
@@ -0,0 +1,18 @@
+ALTER TABLE posts ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE post_revisions (
+  post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+  revision INTEGER NOT NULL,
+  user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+  title VARCHAR(255) NOT NULL,
+  content TEXT NOT NULL,
+  format VARCHAR(10) NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (post_id, revision)
+);
+
+CREATE INDEX idx_post_revisions_user_id ON post_revisions(user_id);
+
+-- The history of existing posts starts with what they are now.
+INSERT INTO post_revisions (post_id, revision, user_id, title, content, format, created_at)
+SELECT id, 1, user_id, title, content, format, updated_at FROM posts;
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS posts_search_document ON posts;
+DROP FUNCTION IF EXISTS posts_search_document();
+DROP TABLE IF EXISTS post_search;
+DROP TABLE IF EXISTS search_settings;
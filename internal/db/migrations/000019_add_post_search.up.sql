@@ -0,0 +1,32 @@
+CREATE TABLE search_settings (
+  id BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+  config REGCONFIG NOT NULL
+);
+
+INSERT INTO search_settings (config) VALUES ('simple');
+
+CREATE TABLE post_search (
+  post_id INTEGER PRIMARY KEY REFERENCES posts(id) ON DELETE CASCADE,
+  document TSVECTOR NOT NULL
+);
+
+CREATE INDEX idx_post_search_document ON post_search USING GIN (document);
+
+CREATE FUNCTION posts_search_document() RETURNS trigger AS $$
+DECLARE
+  config REGCONFIG := (SELECT s.config FROM search_settings s);
+BEGIN
+  INSERT INTO post_search (post_id, document)
+  VALUES (NEW.id, setweight(to_tsvector(config, NEW.title), 'A') || setweight(to_tsvector(config, NEW.content), 'B'))
+  ON CONFLICT (post_id) DO UPDATE SET document = EXCLUDED.document;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER posts_search_document
+AFTER INSERT OR UPDATE OF title, content ON posts
+FOR EACH ROW EXECUTE FUNCTION posts_search_document();
+
+INSERT INTO post_search (post_id, document)
+SELECT id, setweight(to_tsvector('simple', title), 'A') || setweight(to_tsvector('simple', content), 'B')
+FROM posts;
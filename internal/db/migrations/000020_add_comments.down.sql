@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS comments;
+ALTER TABLE posts DROP COLUMN IF EXISTS comments_locked;
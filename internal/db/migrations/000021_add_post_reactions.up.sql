@@ -0,0 +1,9 @@
+CREATE TABLE post_reactions (
+  post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  kind VARCHAR(20) NOT NULL CHECK (kind IN ('like', 'love', 'insightful', 'funny', 'celebrate')),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (post_id, user_id, kind)
+);
+
+CREATE INDEX idx_post_reactions_user_id ON post_reactions(user_id, kind, created_at DESC);
@@ -13,6 +13,8 @@ import (
 	context "context"
 	reflect "reflect"
 
+	uuid "github.com/google/uuid"
+	pgtype "github.com/jackc/pgx/v5/pgtype"
 	sqlc "github.com/lshigami/Plog/internal/db/sqlc"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -41,6 +43,242 @@ func (m *MockQuerier) EXPECT() *MockQuerierMockRecorder {
 	return m.recorder
 }
 
+// AddPostReaction mocks base method.
+func (m *MockQuerier) AddPostReaction(ctx context.Context, arg sqlc.AddPostReactionParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPostReaction", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddPostReaction indicates an expected call of AddPostReaction.
+func (mr *MockQuerierMockRecorder) AddPostReaction(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPostReaction", reflect.TypeOf((*MockQuerier)(nil).AddPostReaction), ctx, arg)
+}
+
+// AnonymizeDueUsers mocks base method.
+func (m *MockQuerier) AnonymizeDueUsers(ctx context.Context) ([]sqlc.AnonymizeDueUsersRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnonymizeDueUsers", ctx)
+	ret0, _ := ret[0].([]sqlc.AnonymizeDueUsersRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AnonymizeDueUsers indicates an expected call of AnonymizeDueUsers.
+func (mr *MockQuerierMockRecorder) AnonymizeDueUsers(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnonymizeDueUsers", reflect.TypeOf((*MockQuerier)(nil).AnonymizeDueUsers), ctx)
+}
+
+// AttemptMFAChallenge mocks base method.
+func (m *MockQuerier) AttemptMFAChallenge(ctx context.Context, arg sqlc.AttemptMFAChallengeParams) (sqlc.MfaChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AttemptMFAChallenge", ctx, arg)
+	ret0, _ := ret[0].(sqlc.MfaChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AttemptMFAChallenge indicates an expected call of AttemptMFAChallenge.
+func (mr *MockQuerierMockRecorder) AttemptMFAChallenge(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttemptMFAChallenge", reflect.TypeOf((*MockQuerier)(nil).AttemptMFAChallenge), ctx, arg)
+}
+
+// CancelUserDeletion mocks base method.
+func (m *MockQuerier) CancelUserDeletion(ctx context.Context, id int32) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelUserDeletion", ctx, id)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelUserDeletion indicates an expected call of CancelUserDeletion.
+func (mr *MockQuerierMockRecorder) CancelUserDeletion(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelUserDeletion", reflect.TypeOf((*MockQuerier)(nil).CancelUserDeletion), ctx, id)
+}
+
+// ClearLoginFailures mocks base method.
+func (m *MockQuerier) ClearLoginFailures(ctx context.Context, arg sqlc.ClearLoginFailuresParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearLoginFailures", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearLoginFailures indicates an expected call of ClearLoginFailures.
+func (mr *MockQuerierMockRecorder) ClearLoginFailures(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearLoginFailures", reflect.TypeOf((*MockQuerier)(nil).ClearLoginFailures), ctx, arg)
+}
+
+// ConsumeEmailVerificationToken mocks base method.
+func (m *MockQuerier) ConsumeEmailVerificationToken(ctx context.Context, tokenHash string) (sqlc.EmailVerificationToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeEmailVerificationToken", ctx, tokenHash)
+	ret0, _ := ret[0].(sqlc.EmailVerificationToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeEmailVerificationToken indicates an expected call of ConsumeEmailVerificationToken.
+func (mr *MockQuerierMockRecorder) ConsumeEmailVerificationToken(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeEmailVerificationToken", reflect.TypeOf((*MockQuerier)(nil).ConsumeEmailVerificationToken), ctx, tokenHash)
+}
+
+// ConsumeOIDCAuthRequest mocks base method.
+func (m *MockQuerier) ConsumeOIDCAuthRequest(ctx context.Context, stateHash string) (sqlc.OidcAuthRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeOIDCAuthRequest", ctx, stateHash)
+	ret0, _ := ret[0].(sqlc.OidcAuthRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeOIDCAuthRequest indicates an expected call of ConsumeOIDCAuthRequest.
+func (mr *MockQuerierMockRecorder) ConsumeOIDCAuthRequest(ctx, stateHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeOIDCAuthRequest", reflect.TypeOf((*MockQuerier)(nil).ConsumeOIDCAuthRequest), ctx, stateHash)
+}
+
+// ConsumePasswordResetToken mocks base method.
+func (m *MockQuerier) ConsumePasswordResetToken(ctx context.Context, tokenHash string) (sqlc.PasswordResetToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumePasswordResetToken", ctx, tokenHash)
+	ret0, _ := ret[0].(sqlc.PasswordResetToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumePasswordResetToken indicates an expected call of ConsumePasswordResetToken.
+func (mr *MockQuerierMockRecorder) ConsumePasswordResetToken(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumePasswordResetToken", reflect.TypeOf((*MockQuerier)(nil).ConsumePasswordResetToken), ctx, tokenHash)
+}
+
+// CreateAuditEvent mocks base method.
+func (m *MockQuerier) CreateAuditEvent(ctx context.Context, arg sqlc.CreateAuditEventParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEvent", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAuditEvent indicates an expected call of CreateAuditEvent.
+func (mr *MockQuerierMockRecorder) CreateAuditEvent(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEvent", reflect.TypeOf((*MockQuerier)(nil).CreateAuditEvent), ctx, arg)
+}
+
+// CreateComment mocks base method.
+func (m *MockQuerier) CreateComment(ctx context.Context, arg sqlc.CreateCommentParams) (sqlc.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateComment", ctx, arg)
+	ret0, _ := ret[0].(sqlc.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateComment indicates an expected call of CreateComment.
+func (mr *MockQuerierMockRecorder) CreateComment(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateComment", reflect.TypeOf((*MockQuerier)(nil).CreateComment), ctx, arg)
+}
+
+// CreateEmailVerificationToken mocks base method.
+func (m *MockQuerier) CreateEmailVerificationToken(ctx context.Context, arg sqlc.CreateEmailVerificationTokenParams) (sqlc.EmailVerificationToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEmailVerificationToken", ctx, arg)
+	ret0, _ := ret[0].(sqlc.EmailVerificationToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateEmailVerificationToken indicates an expected call of CreateEmailVerificationToken.
+func (mr *MockQuerierMockRecorder) CreateEmailVerificationToken(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEmailVerificationToken", reflect.TypeOf((*MockQuerier)(nil).CreateEmailVerificationToken), ctx, arg)
+}
+
+// CreateIdentity mocks base method.
+func (m *MockQuerier) CreateIdentity(ctx context.Context, arg sqlc.CreateIdentityParams) (sqlc.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIdentity", ctx, arg)
+	ret0, _ := ret[0].(sqlc.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIdentity indicates an expected call of CreateIdentity.
+func (mr *MockQuerierMockRecorder) CreateIdentity(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIdentity", reflect.TypeOf((*MockQuerier)(nil).CreateIdentity), ctx, arg)
+}
+
+// CreateMFAChallenge mocks base method.
+func (m *MockQuerier) CreateMFAChallenge(ctx context.Context, arg sqlc.CreateMFAChallengeParams) (sqlc.MfaChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMFAChallenge", ctx, arg)
+	ret0, _ := ret[0].(sqlc.MfaChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMFAChallenge indicates an expected call of CreateMFAChallenge.
+func (mr *MockQuerierMockRecorder) CreateMFAChallenge(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMFAChallenge", reflect.TypeOf((*MockQuerier)(nil).CreateMFAChallenge), ctx, arg)
+}
+
+// CreateOIDCAuthRequest mocks base method.
+func (m *MockQuerier) CreateOIDCAuthRequest(ctx context.Context, arg sqlc.CreateOIDCAuthRequestParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOIDCAuthRequest", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOIDCAuthRequest indicates an expected call of CreateOIDCAuthRequest.
+func (mr *MockQuerierMockRecorder) CreateOIDCAuthRequest(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOIDCAuthRequest", reflect.TypeOf((*MockQuerier)(nil).CreateOIDCAuthRequest), ctx, arg)
+}
+
+// CreatePasswordResetToken mocks base method.
+func (m *MockQuerier) CreatePasswordResetToken(ctx context.Context, arg sqlc.CreatePasswordResetTokenParams) (sqlc.PasswordResetToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePasswordResetToken", ctx, arg)
+	ret0, _ := ret[0].(sqlc.PasswordResetToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePasswordResetToken indicates an expected call of CreatePasswordResetToken.
+func (mr *MockQuerierMockRecorder) CreatePasswordResetToken(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePasswordResetToken", reflect.TypeOf((*MockQuerier)(nil).CreatePasswordResetToken), ctx, arg)
+}
+
+// CreatePersonalAccessToken mocks base method.
+func (m *MockQuerier) CreatePersonalAccessToken(ctx context.Context, arg sqlc.CreatePersonalAccessTokenParams) (sqlc.PersonalAccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePersonalAccessToken", ctx, arg)
+	ret0, _ := ret[0].(sqlc.PersonalAccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePersonalAccessToken indicates an expected call of CreatePersonalAccessToken.
+func (mr *MockQuerierMockRecorder) CreatePersonalAccessToken(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePersonalAccessToken", reflect.TypeOf((*MockQuerier)(nil).CreatePersonalAccessToken), ctx, arg)
+}
+
 // CreatePost mocks base method.
 func (m *MockQuerier) CreatePost(ctx context.Context, arg sqlc.CreatePostParams) (sqlc.Post, error) {
 	m.ctrl.T.Helper()
@@ -56,6 +294,35 @@ func (mr *MockQuerierMockRecorder) CreatePost(ctx, arg any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePost", reflect.TypeOf((*MockQuerier)(nil).CreatePost), ctx, arg)
 }
 
+// CreateRecoveryCodes mocks base method.
+func (m *MockQuerier) CreateRecoveryCodes(ctx context.Context, arg sqlc.CreateRecoveryCodesParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRecoveryCodes", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRecoveryCodes indicates an expected call of CreateRecoveryCodes.
+func (mr *MockQuerierMockRecorder) CreateRecoveryCodes(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRecoveryCodes", reflect.TypeOf((*MockQuerier)(nil).CreateRecoveryCodes), ctx, arg)
+}
+
+// CreateSession mocks base method.
+func (m *MockQuerier) CreateSession(ctx context.Context, arg sqlc.CreateSessionParams) (sqlc.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", ctx, arg)
+	ret0, _ := ret[0].(sqlc.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockQuerierMockRecorder) CreateSession(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockQuerier)(nil).CreateSession), ctx, arg)
+}
+
 // CreateUser mocks base method.
 func (m *MockQuerier) CreateUser(ctx context.Context, arg sqlc.CreateUserParams) (sqlc.User, error) {
 	m.ctrl.T.Helper()
@@ -71,91 +338,1038 @@ func (mr *MockQuerierMockRecorder) CreateUser(ctx, arg any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockQuerier)(nil).CreateUser), ctx, arg)
 }
 
-// DeletePost mocks base method.
-func (m *MockQuerier) DeletePost(ctx context.Context, arg sqlc.DeletePostParams) error {
+// DeleteComment mocks base method.
+func (m *MockQuerier) DeleteComment(ctx context.Context, id int32) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeletePost", ctx, arg)
+	ret := m.ctrl.Call(m, "DeleteComment", ctx, id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// DeleteComment indicates an expected call of DeleteComment.
+func (mr *MockQuerierMockRecorder) DeleteComment(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteComment", reflect.TypeOf((*MockQuerier)(nil).DeleteComment), ctx, id)
+}
+
+// DeleteDueUsers mocks base method.
+func (m *MockQuerier) DeleteDueUsers(ctx context.Context, revokedBefore pgtype.Timestamptz) ([]sqlc.DeleteDueUsersRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDueUsers", ctx, revokedBefore)
+	ret0, _ := ret[0].([]sqlc.DeleteDueUsersRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteDueUsers indicates an expected call of DeleteDueUsers.
+func (mr *MockQuerierMockRecorder) DeleteDueUsers(ctx, revokedBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDueUsers", reflect.TypeOf((*MockQuerier)(nil).DeleteDueUsers), ctx, revokedBefore)
+}
+
+// DeleteExpiredMFAChallenges mocks base method.
+func (m *MockQuerier) DeleteExpiredMFAChallenges(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredMFAChallenges", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredMFAChallenges indicates an expected call of DeleteExpiredMFAChallenges.
+func (mr *MockQuerierMockRecorder) DeleteExpiredMFAChallenges(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredMFAChallenges", reflect.TypeOf((*MockQuerier)(nil).DeleteExpiredMFAChallenges), ctx)
+}
+
+// DeleteExpiredOIDCAuthRequests mocks base method.
+func (m *MockQuerier) DeleteExpiredOIDCAuthRequests(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredOIDCAuthRequests", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredOIDCAuthRequests indicates an expected call of DeleteExpiredOIDCAuthRequests.
+func (mr *MockQuerierMockRecorder) DeleteExpiredOIDCAuthRequests(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredOIDCAuthRequests", reflect.TypeOf((*MockQuerier)(nil).DeleteExpiredOIDCAuthRequests), ctx)
+}
+
+// DeleteExpiredRevokedTokens mocks base method.
+func (m *MockQuerier) DeleteExpiredRevokedTokens(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredRevokedTokens", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredRevokedTokens indicates an expected call of DeleteExpiredRevokedTokens.
+func (mr *MockQuerierMockRecorder) DeleteExpiredRevokedTokens(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredRevokedTokens", reflect.TypeOf((*MockQuerier)(nil).DeleteExpiredRevokedTokens), ctx)
+}
+
+// DeleteMFAChallenge mocks base method.
+func (m *MockQuerier) DeleteMFAChallenge(ctx context.Context, id int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMFAChallenge", ctx, id)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteMFAChallenge indicates an expected call of DeleteMFAChallenge.
+func (mr *MockQuerierMockRecorder) DeleteMFAChallenge(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMFAChallenge", reflect.TypeOf((*MockQuerier)(nil).DeleteMFAChallenge), ctx, id)
+}
+
+// DeletePost mocks base method.
+func (m *MockQuerier) DeletePost(ctx context.Context, arg sqlc.DeletePostParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePost", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // DeletePost indicates an expected call of DeletePost.
 func (mr *MockQuerierMockRecorder) DeletePost(ctx, arg any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePost", reflect.TypeOf((*MockQuerier)(nil).DeletePost), ctx, arg)
 }
 
-// GetPostByID mocks base method.
-func (m *MockQuerier) GetPostByID(ctx context.Context, id int32) (sqlc.GetPostByIDRow, error) {
+// DeleteRecoveryCodes mocks base method.
+func (m *MockQuerier) DeleteRecoveryCodes(ctx context.Context, userID int32) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPostByID", ctx, id)
-	ret0, _ := ret[0].(sqlc.GetPostByIDRow)
+	ret := m.ctrl.Call(m, "DeleteRecoveryCodes", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRecoveryCodes indicates an expected call of DeleteRecoveryCodes.
+func (mr *MockQuerierMockRecorder) DeleteRecoveryCodes(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecoveryCodes", reflect.TypeOf((*MockQuerier)(nil).DeleteRecoveryCodes), ctx, userID)
+}
+
+// DeleteStaleLoginFailures mocks base method.
+func (m *MockQuerier) DeleteStaleLoginFailures(ctx context.Context, lastFailureAt pgtype.Timestamptz) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteStaleLoginFailures", ctx, lastFailureAt)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetPostByID indicates an expected call of GetPostByID.
-func (mr *MockQuerierMockRecorder) GetPostByID(ctx, id any) *gomock.Call {
+// DeleteStaleLoginFailures indicates an expected call of DeleteStaleLoginFailures.
+func (mr *MockQuerierMockRecorder) DeleteStaleLoginFailures(ctx, lastFailureAt any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostByID", reflect.TypeOf((*MockQuerier)(nil).GetPostByID), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteStaleLoginFailures", reflect.TypeOf((*MockQuerier)(nil).DeleteStaleLoginFailures), ctx, lastFailureAt)
 }
 
-// GetUserByID mocks base method.
-func (m *MockQuerier) GetUserByID(ctx context.Context, id int32) (sqlc.User, error) {
+// DisableUserTOTP mocks base method.
+func (m *MockQuerier) DisableUserTOTP(ctx context.Context, id int32) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByID", ctx, id)
-	ret0, _ := ret[0].(sqlc.User)
+	ret := m.ctrl.Call(m, "DisableUserTOTP", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableUserTOTP indicates an expected call of DisableUserTOTP.
+func (mr *MockQuerierMockRecorder) DisableUserTOTP(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableUserTOTP", reflect.TypeOf((*MockQuerier)(nil).DisableUserTOTP), ctx, id)
+}
+
+// EnableUserTOTP mocks base method.
+func (m *MockQuerier) EnableUserTOTP(ctx context.Context, arg sqlc.EnableUserTOTPParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableUserTOTP", ctx, arg)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserByID indicates an expected call of GetUserByID.
-func (mr *MockQuerierMockRecorder) GetUserByID(ctx, id any) *gomock.Call {
+// EnableUserTOTP indicates an expected call of EnableUserTOTP.
+func (mr *MockQuerierMockRecorder) EnableUserTOTP(ctx, arg any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockQuerier)(nil).GetUserByID), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableUserTOTP", reflect.TypeOf((*MockQuerier)(nil).EnableUserTOTP), ctx, arg)
 }
 
-// GetUserByUsername mocks base method.
-func (m *MockQuerier) GetUserByUsername(ctx context.Context, username string) (sqlc.User, error) {
+// GetComment mocks base method.
+func (m *MockQuerier) GetComment(ctx context.Context, id int32) (sqlc.Comment, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByUsername", ctx, username)
-	ret0, _ := ret[0].(sqlc.User)
+	ret := m.ctrl.Call(m, "GetComment", ctx, id)
+	ret0, _ := ret[0].(sqlc.Comment)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserByUsername indicates an expected call of GetUserByUsername.
-func (mr *MockQuerierMockRecorder) GetUserByUsername(ctx, username any) *gomock.Call {
+// GetComment indicates an expected call of GetComment.
+func (mr *MockQuerierMockRecorder) GetComment(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockQuerier)(nil).GetUserByUsername), ctx, username)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetComment", reflect.TypeOf((*MockQuerier)(nil).GetComment), ctx, id)
 }
 
-// ListPosts mocks base method.
-func (m *MockQuerier) ListPosts(ctx context.Context, arg sqlc.ListPostsParams) ([]sqlc.ListPostsRow, error) {
+// GetIdentity mocks base method.
+func (m *MockQuerier) GetIdentity(ctx context.Context, arg sqlc.GetIdentityParams) (sqlc.Identity, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListPosts", ctx, arg)
-	ret0, _ := ret[0].([]sqlc.ListPostsRow)
+	ret := m.ctrl.Call(m, "GetIdentity", ctx, arg)
+	ret0, _ := ret[0].(sqlc.Identity)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListPosts indicates an expected call of ListPosts.
-func (mr *MockQuerierMockRecorder) ListPosts(ctx, arg any) *gomock.Call {
+// GetIdentity indicates an expected call of GetIdentity.
+func (mr *MockQuerierMockRecorder) GetIdentity(ctx, arg any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPosts", reflect.TypeOf((*MockQuerier)(nil).ListPosts), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdentity", reflect.TypeOf((*MockQuerier)(nil).GetIdentity), ctx, arg)
 }
 
-// UpdatePost mocks base method.
-func (m *MockQuerier) UpdatePost(ctx context.Context, arg sqlc.UpdatePostParams) (sqlc.Post, error) {
+// GetLoginLockouts mocks base method.
+func (m *MockQuerier) GetLoginLockouts(ctx context.Context, arg sqlc.GetLoginLockoutsParams) ([]sqlc.GetLoginLockoutsRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdatePost", ctx, arg)
-	ret0, _ := ret[0].(sqlc.Post)
+	ret := m.ctrl.Call(m, "GetLoginLockouts", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.GetLoginLockoutsRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UpdatePost indicates an expected call of UpdatePost.
-func (mr *MockQuerierMockRecorder) UpdatePost(ctx, arg any) *gomock.Call {
+// GetLoginLockouts indicates an expected call of GetLoginLockouts.
+func (mr *MockQuerierMockRecorder) GetLoginLockouts(ctx, arg any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePost", reflect.TypeOf((*MockQuerier)(nil).UpdatePost), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginLockouts", reflect.TypeOf((*MockQuerier)(nil).GetLoginLockouts), ctx, arg)
+}
+
+// GetPostByID mocks base method.
+func (m *MockQuerier) GetPostByID(ctx context.Context, id int32) (sqlc.GetPostByIDRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostByID", ctx, id)
+	ret0, _ := ret[0].(sqlc.GetPostByIDRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostByID indicates an expected call of GetPostByID.
+func (mr *MockQuerierMockRecorder) GetPostByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostByID", reflect.TypeOf((*MockQuerier)(nil).GetPostByID), ctx, id)
+}
+
+// GetPostBySlug mocks base method.
+func (m *MockQuerier) GetPostBySlug(ctx context.Context, slug string) (sqlc.GetPostBySlugRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostBySlug", ctx, slug)
+	ret0, _ := ret[0].(sqlc.GetPostBySlugRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostBySlug indicates an expected call of GetPostBySlug.
+func (mr *MockQuerierMockRecorder) GetPostBySlug(ctx, slug any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostBySlug", reflect.TypeOf((*MockQuerier)(nil).GetPostBySlug), ctx, slug)
+}
+
+// GetPostReactions mocks base method.
+func (m *MockQuerier) GetPostReactions(ctx context.Context, arg sqlc.GetPostReactionsParams) (sqlc.GetPostReactionsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostReactions", ctx, arg)
+	ret0, _ := ret[0].(sqlc.GetPostReactionsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostReactions indicates an expected call of GetPostReactions.
+func (mr *MockQuerierMockRecorder) GetPostReactions(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostReactions", reflect.TypeOf((*MockQuerier)(nil).GetPostReactions), ctx, arg)
+}
+
+// GetPostRevision mocks base method.
+func (m *MockQuerier) GetPostRevision(ctx context.Context, arg sqlc.GetPostRevisionParams) (sqlc.GetPostRevisionRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostRevision", ctx, arg)
+	ret0, _ := ret[0].(sqlc.GetPostRevisionRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostRevision indicates an expected call of GetPostRevision.
+func (mr *MockQuerierMockRecorder) GetPostRevision(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostRevision", reflect.TypeOf((*MockQuerier)(nil).GetPostRevision), ctx, arg)
+}
+
+// GetRenamedPostSlug mocks base method.
+func (m *MockQuerier) GetRenamedPostSlug(ctx context.Context, slug string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRenamedPostSlug", ctx, slug)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRenamedPostSlug indicates an expected call of GetRenamedPostSlug.
+func (mr *MockQuerierMockRecorder) GetRenamedPostSlug(ctx, slug any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRenamedPostSlug", reflect.TypeOf((*MockQuerier)(nil).GetRenamedPostSlug), ctx, slug)
+}
+
+// GetSession mocks base method.
+func (m *MockQuerier) GetSession(ctx context.Context, id uuid.UUID) (sqlc.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", ctx, id)
+	ret0, _ := ret[0].(sqlc.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockQuerierMockRecorder) GetSession(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockQuerier)(nil).GetSession), ctx, id)
+}
+
+// GetUserByEmail mocks base method.
+func (m *MockQuerier) GetUserByEmail(ctx context.Context, email pgtype.Text) (sqlc.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", ctx, email)
+	ret0, _ := ret[0].(sqlc.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockQuerierMockRecorder) GetUserByEmail(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockQuerier)(nil).GetUserByEmail), ctx, email)
+}
+
+// GetUserByID mocks base method.
+func (m *MockQuerier) GetUserByID(ctx context.Context, id int32) (sqlc.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", ctx, id)
+	ret0, _ := ret[0].(sqlc.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockQuerierMockRecorder) GetUserByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockQuerier)(nil).GetUserByID), ctx, id)
+}
+
+// GetUserByUsername mocks base method.
+func (m *MockQuerier) GetUserByUsername(ctx context.Context, username string) (sqlc.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByUsername", ctx, username)
+	ret0, _ := ret[0].(sqlc.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByUsername indicates an expected call of GetUserByUsername.
+func (mr *MockQuerierMockRecorder) GetUserByUsername(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockQuerier)(nil).GetUserByUsername), ctx, username)
+}
+
+// InvalidatePasswordResetTokens mocks base method.
+func (m *MockQuerier) InvalidatePasswordResetTokens(ctx context.Context, userID int32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InvalidatePasswordResetTokens", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InvalidatePasswordResetTokens indicates an expected call of InvalidatePasswordResetTokens.
+func (mr *MockQuerierMockRecorder) InvalidatePasswordResetTokens(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidatePasswordResetTokens", reflect.TypeOf((*MockQuerier)(nil).InvalidatePasswordResetTokens), ctx, userID)
+}
+
+// ListAuditEvents mocks base method.
+func (m *MockQuerier) ListAuditEvents(ctx context.Context, arg sqlc.ListAuditEventsParams) ([]sqlc.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditEvents", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockQuerierMockRecorder) ListAuditEvents(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockQuerier)(nil).ListAuditEvents), ctx, arg)
+}
+
+// ListComments mocks base method.
+func (m *MockQuerier) ListComments(ctx context.Context, arg sqlc.ListCommentsParams) ([]sqlc.ListCommentsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListComments", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.ListCommentsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListComments indicates an expected call of ListComments.
+func (mr *MockQuerierMockRecorder) ListComments(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListComments", reflect.TypeOf((*MockQuerier)(nil).ListComments), ctx, arg)
+}
+
+// ListPersonalAccessTokens mocks base method.
+func (m *MockQuerier) ListPersonalAccessTokens(ctx context.Context, userID int32) ([]sqlc.PersonalAccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPersonalAccessTokens", ctx, userID)
+	ret0, _ := ret[0].([]sqlc.PersonalAccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPersonalAccessTokens indicates an expected call of ListPersonalAccessTokens.
+func (mr *MockQuerierMockRecorder) ListPersonalAccessTokens(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPersonalAccessTokens", reflect.TypeOf((*MockQuerier)(nil).ListPersonalAccessTokens), ctx, userID)
+}
+
+// ListPostRevisions mocks base method.
+func (m *MockQuerier) ListPostRevisions(ctx context.Context, arg sqlc.ListPostRevisionsParams) ([]sqlc.ListPostRevisionsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPostRevisions", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.ListPostRevisionsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPostRevisions indicates an expected call of ListPostRevisions.
+func (mr *MockQuerierMockRecorder) ListPostRevisions(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPostRevisions", reflect.TypeOf((*MockQuerier)(nil).ListPostRevisions), ctx, arg)
+}
+
+// ListPosts mocks base method.
+func (m *MockQuerier) ListPosts(ctx context.Context, arg sqlc.ListPostsParams) ([]sqlc.ListPostsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPosts", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.ListPostsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPosts indicates an expected call of ListPosts.
+func (mr *MockQuerierMockRecorder) ListPosts(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPosts", reflect.TypeOf((*MockQuerier)(nil).ListPosts), ctx, arg)
+}
+
+// ListPostsByAuthor mocks base method.
+func (m *MockQuerier) ListPostsByAuthor(ctx context.Context, arg sqlc.ListPostsByAuthorParams) ([]sqlc.ListPostsByAuthorRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPostsByAuthor", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.ListPostsByAuthorRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPostsByAuthor indicates an expected call of ListPostsByAuthor.
+func (mr *MockQuerierMockRecorder) ListPostsByAuthor(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPostsByAuthor", reflect.TypeOf((*MockQuerier)(nil).ListPostsByAuthor), ctx, arg)
+}
+
+// ListPostsToRender mocks base method.
+func (m *MockQuerier) ListPostsToRender(ctx context.Context, arg sqlc.ListPostsToRenderParams) ([]sqlc.ListPostsToRenderRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPostsToRender", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.ListPostsToRenderRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPostsToRender indicates an expected call of ListPostsToRender.
+func (mr *MockQuerierMockRecorder) ListPostsToRender(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPostsToRender", reflect.TypeOf((*MockQuerier)(nil).ListPostsToRender), ctx, arg)
+}
+
+// ListReactedPosts mocks base method.
+func (m *MockQuerier) ListReactedPosts(ctx context.Context, arg sqlc.ListReactedPostsParams) ([]sqlc.ListReactedPostsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReactedPosts", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.ListReactedPostsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReactedPosts indicates an expected call of ListReactedPosts.
+func (mr *MockQuerierMockRecorder) ListReactedPosts(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReactedPosts", reflect.TypeOf((*MockQuerier)(nil).ListReactedPosts), ctx, arg)
+}
+
+// ListRevokedSessions mocks base method.
+func (m *MockQuerier) ListRevokedSessions(ctx context.Context, revokedAt pgtype.Timestamptz) ([]sqlc.ListRevokedSessionsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRevokedSessions", ctx, revokedAt)
+	ret0, _ := ret[0].([]sqlc.ListRevokedSessionsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRevokedSessions indicates an expected call of ListRevokedSessions.
+func (mr *MockQuerierMockRecorder) ListRevokedSessions(ctx, revokedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRevokedSessions", reflect.TypeOf((*MockQuerier)(nil).ListRevokedSessions), ctx, revokedAt)
+}
+
+// ListRevokedTokens mocks base method.
+func (m *MockQuerier) ListRevokedTokens(ctx context.Context, revokedAt pgtype.Timestamptz) ([]sqlc.RevokedToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRevokedTokens", ctx, revokedAt)
+	ret0, _ := ret[0].([]sqlc.RevokedToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRevokedTokens indicates an expected call of ListRevokedTokens.
+func (mr *MockQuerierMockRecorder) ListRevokedTokens(ctx, revokedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRevokedTokens", reflect.TypeOf((*MockQuerier)(nil).ListRevokedTokens), ctx, revokedAt)
+}
+
+// ListTags mocks base method.
+func (m *MockQuerier) ListTags(ctx context.Context, arg sqlc.ListTagsParams) ([]sqlc.ListTagsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTags", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.ListTagsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTags indicates an expected call of ListTags.
+func (mr *MockQuerierMockRecorder) ListTags(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTags", reflect.TypeOf((*MockQuerier)(nil).ListTags), ctx, arg)
+}
+
+// ListTakenPostSlugs mocks base method.
+func (m *MockQuerier) ListTakenPostSlugs(ctx context.Context, arg sqlc.ListTakenPostSlugsParams) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTakenPostSlugs", ctx, arg)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTakenPostSlugs indicates an expected call of ListTakenPostSlugs.
+func (mr *MockQuerierMockRecorder) ListTakenPostSlugs(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTakenPostSlugs", reflect.TypeOf((*MockQuerier)(nil).ListTakenPostSlugs), ctx, arg)
+}
+
+// ListUserComments mocks base method.
+func (m *MockQuerier) ListUserComments(ctx context.Context, userID pgtype.Int4) ([]sqlc.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserComments", ctx, userID)
+	ret0, _ := ret[0].([]sqlc.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserComments indicates an expected call of ListUserComments.
+func (mr *MockQuerierMockRecorder) ListUserComments(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserComments", reflect.TypeOf((*MockQuerier)(nil).ListUserComments), ctx, userID)
+}
+
+// ListUserPosts mocks base method.
+func (m *MockQuerier) ListUserPosts(ctx context.Context, userID int32) ([]sqlc.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserPosts", ctx, userID)
+	ret0, _ := ret[0].([]sqlc.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserPosts indicates an expected call of ListUserPosts.
+func (mr *MockQuerierMockRecorder) ListUserPosts(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserPosts", reflect.TypeOf((*MockQuerier)(nil).ListUserPosts), ctx, userID)
+}
+
+// ListUserReactions mocks base method.
+func (m *MockQuerier) ListUserReactions(ctx context.Context, userID int32) ([]sqlc.PostReaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserReactions", ctx, userID)
+	ret0, _ := ret[0].([]sqlc.PostReaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserReactions indicates an expected call of ListUserReactions.
+func (mr *MockQuerierMockRecorder) ListUserReactions(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserReactions", reflect.TypeOf((*MockQuerier)(nil).ListUserReactions), ctx, userID)
+}
+
+// ListUserSessions mocks base method.
+func (m *MockQuerier) ListUserSessions(ctx context.Context, userID int32) ([]sqlc.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserSessions", ctx, userID)
+	ret0, _ := ret[0].([]sqlc.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserSessions indicates an expected call of ListUserSessions.
+func (mr *MockQuerierMockRecorder) ListUserSessions(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserSessions", reflect.TypeOf((*MockQuerier)(nil).ListUserSessions), ctx, userID)
+}
+
+// LockLogin mocks base method.
+func (m *MockQuerier) LockLogin(ctx context.Context, arg sqlc.LockLoginParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockLogin", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockLogin indicates an expected call of LockLogin.
+func (mr *MockQuerierMockRecorder) LockLogin(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockLogin", reflect.TypeOf((*MockQuerier)(nil).LockLogin), ctx, arg)
+}
+
+// MarkUserEmailVerified mocks base method.
+func (m *MockQuerier) MarkUserEmailVerified(ctx context.Context, arg sqlc.MarkUserEmailVerifiedParams) (sqlc.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkUserEmailVerified", ctx, arg)
+	ret0, _ := ret[0].(sqlc.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkUserEmailVerified indicates an expected call of MarkUserEmailVerified.
+func (mr *MockQuerierMockRecorder) MarkUserEmailVerified(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkUserEmailVerified", reflect.TypeOf((*MockQuerier)(nil).MarkUserEmailVerified), ctx, arg)
+}
+
+// PublishDuePosts mocks base method.
+func (m *MockQuerier) PublishDuePosts(ctx context.Context) ([]sqlc.PublishDuePostsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishDuePosts", ctx)
+	ret0, _ := ret[0].([]sqlc.PublishDuePostsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishDuePosts indicates an expected call of PublishDuePosts.
+func (mr *MockQuerierMockRecorder) PublishDuePosts(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishDuePosts", reflect.TypeOf((*MockQuerier)(nil).PublishDuePosts), ctx)
+}
+
+// RecordLoginFailure mocks base method.
+func (m *MockQuerier) RecordLoginFailure(ctx context.Context, arg sqlc.RecordLoginFailureParams) (int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginFailure", ctx, arg)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordLoginFailure indicates an expected call of RecordLoginFailure.
+func (mr *MockQuerierMockRecorder) RecordLoginFailure(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockQuerier)(nil).RecordLoginFailure), ctx, arg)
+}
+
+// RehashUserPassword mocks base method.
+func (m *MockQuerier) RehashUserPassword(ctx context.Context, arg sqlc.RehashUserPasswordParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RehashUserPassword", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RehashUserPassword indicates an expected call of RehashUserPassword.
+func (mr *MockQuerierMockRecorder) RehashUserPassword(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RehashUserPassword", reflect.TypeOf((*MockQuerier)(nil).RehashUserPassword), ctx, arg)
+}
+
+// RemovePostReaction mocks base method.
+func (m *MockQuerier) RemovePostReaction(ctx context.Context, arg sqlc.RemovePostReactionParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemovePostReaction", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemovePostReaction indicates an expected call of RemovePostReaction.
+func (mr *MockQuerierMockRecorder) RemovePostReaction(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemovePostReaction", reflect.TypeOf((*MockQuerier)(nil).RemovePostReaction), ctx, arg)
+}
+
+// RevokeDueUserSessions mocks base method.
+func (m *MockQuerier) RevokeDueUserSessions(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeDueUserSessions", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeDueUserSessions indicates an expected call of RevokeDueUserSessions.
+func (mr *MockQuerierMockRecorder) RevokeDueUserSessions(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeDueUserSessions", reflect.TypeOf((*MockQuerier)(nil).RevokeDueUserSessions), ctx)
+}
+
+// RevokeOtherUserSessions mocks base method.
+func (m *MockQuerier) RevokeOtherUserSessions(ctx context.Context, arg sqlc.RevokeOtherUserSessionsParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeOtherUserSessions", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeOtherUserSessions indicates an expected call of RevokeOtherUserSessions.
+func (mr *MockQuerierMockRecorder) RevokeOtherUserSessions(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeOtherUserSessions", reflect.TypeOf((*MockQuerier)(nil).RevokeOtherUserSessions), ctx, arg)
+}
+
+// RevokePersonalAccessToken mocks base method.
+func (m *MockQuerier) RevokePersonalAccessToken(ctx context.Context, arg sqlc.RevokePersonalAccessTokenParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokePersonalAccessToken", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokePersonalAccessToken indicates an expected call of RevokePersonalAccessToken.
+func (mr *MockQuerierMockRecorder) RevokePersonalAccessToken(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokePersonalAccessToken", reflect.TypeOf((*MockQuerier)(nil).RevokePersonalAccessToken), ctx, arg)
+}
+
+// RevokeSession mocks base method.
+func (m *MockQuerier) RevokeSession(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockQuerierMockRecorder) RevokeSession(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockQuerier)(nil).RevokeSession), ctx, id)
+}
+
+// RevokeToken mocks base method.
+func (m *MockQuerier) RevokeToken(ctx context.Context, arg sqlc.RevokeTokenParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeToken", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockQuerierMockRecorder) RevokeToken(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockQuerier)(nil).RevokeToken), ctx, arg)
+}
+
+// RevokeUserPersonalAccessTokens mocks base method.
+func (m *MockQuerier) RevokeUserPersonalAccessTokens(ctx context.Context, userID int32) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserPersonalAccessTokens", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeUserPersonalAccessTokens indicates an expected call of RevokeUserPersonalAccessTokens.
+func (mr *MockQuerierMockRecorder) RevokeUserPersonalAccessTokens(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserPersonalAccessTokens", reflect.TypeOf((*MockQuerier)(nil).RevokeUserPersonalAccessTokens), ctx, userID)
+}
+
+// RevokeUserSession mocks base method.
+func (m *MockQuerier) RevokeUserSession(ctx context.Context, arg sqlc.RevokeUserSessionParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserSession", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeUserSession indicates an expected call of RevokeUserSession.
+func (mr *MockQuerierMockRecorder) RevokeUserSession(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserSession", reflect.TypeOf((*MockQuerier)(nil).RevokeUserSession), ctx, arg)
+}
+
+// RevokeUserSessions mocks base method.
+func (m *MockQuerier) RevokeUserSessions(ctx context.Context, userID int32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserSessions", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUserSessions indicates an expected call of RevokeUserSessions.
+func (mr *MockQuerierMockRecorder) RevokeUserSessions(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserSessions", reflect.TypeOf((*MockQuerier)(nil).RevokeUserSessions), ctx, userID)
+}
+
+// RotateSessionToken mocks base method.
+func (m *MockQuerier) RotateSessionToken(ctx context.Context, arg sqlc.RotateSessionTokenParams) (sqlc.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateSessionToken", ctx, arg)
+	ret0, _ := ret[0].(sqlc.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateSessionToken indicates an expected call of RotateSessionToken.
+func (mr *MockQuerierMockRecorder) RotateSessionToken(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSessionToken", reflect.TypeOf((*MockQuerier)(nil).RotateSessionToken), ctx, arg)
+}
+
+// ScheduleUserDeletion mocks base method.
+func (m *MockQuerier) ScheduleUserDeletion(ctx context.Context, arg sqlc.ScheduleUserDeletionParams) (sqlc.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleUserDeletion", ctx, arg)
+	ret0, _ := ret[0].(sqlc.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScheduleUserDeletion indicates an expected call of ScheduleUserDeletion.
+func (mr *MockQuerierMockRecorder) ScheduleUserDeletion(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleUserDeletion", reflect.TypeOf((*MockQuerier)(nil).ScheduleUserDeletion), ctx, arg)
+}
+
+// SearchPosts mocks base method.
+func (m *MockQuerier) SearchPosts(ctx context.Context, arg sqlc.SearchPostsParams) ([]sqlc.SearchPostsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchPosts", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.SearchPostsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchPosts indicates an expected call of SearchPosts.
+func (mr *MockQuerierMockRecorder) SearchPosts(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchPosts", reflect.TypeOf((*MockQuerier)(nil).SearchPosts), ctx, arg)
+}
+
+// SetPostCommentsLocked mocks base method.
+func (m *MockQuerier) SetPostCommentsLocked(ctx context.Context, arg sqlc.SetPostCommentsLockedParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPostCommentsLocked", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPostCommentsLocked indicates an expected call of SetPostCommentsLocked.
+func (mr *MockQuerierMockRecorder) SetPostCommentsLocked(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPostCommentsLocked", reflect.TypeOf((*MockQuerier)(nil).SetPostCommentsLocked), ctx, arg)
+}
+
+// SetUserTOTPSecret mocks base method.
+func (m *MockQuerier) SetUserTOTPSecret(ctx context.Context, arg sqlc.SetUserTOTPSecretParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserTOTPSecret", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserTOTPSecret indicates an expected call of SetUserTOTPSecret.
+func (mr *MockQuerierMockRecorder) SetUserTOTPSecret(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserTOTPSecret", reflect.TypeOf((*MockQuerier)(nil).SetUserTOTPSecret), ctx, arg)
+}
+
+// TouchIdentity mocks base method.
+func (m *MockQuerier) TouchIdentity(ctx context.Context, arg sqlc.TouchIdentityParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchIdentity", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchIdentity indicates an expected call of TouchIdentity.
+func (mr *MockQuerierMockRecorder) TouchIdentity(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchIdentity", reflect.TypeOf((*MockQuerier)(nil).TouchIdentity), ctx, arg)
+}
+
+// UpdateComment mocks base method.
+func (m *MockQuerier) UpdateComment(ctx context.Context, arg sqlc.UpdateCommentParams) (sqlc.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateComment", ctx, arg)
+	ret0, _ := ret[0].(sqlc.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateComment indicates an expected call of UpdateComment.
+func (mr *MockQuerierMockRecorder) UpdateComment(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateComment", reflect.TypeOf((*MockQuerier)(nil).UpdateComment), ctx, arg)
+}
+
+// UpdatePost mocks base method.
+func (m *MockQuerier) UpdatePost(ctx context.Context, arg sqlc.UpdatePostParams) (sqlc.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePost", ctx, arg)
+	ret0, _ := ret[0].(sqlc.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePost indicates an expected call of UpdatePost.
+func (mr *MockQuerierMockRecorder) UpdatePost(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePost", reflect.TypeOf((*MockQuerier)(nil).UpdatePost), ctx, arg)
+}
+
+// UpdatePostContentHTML mocks base method.
+func (m *MockQuerier) UpdatePostContentHTML(ctx context.Context, arg sqlc.UpdatePostContentHTMLParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePostContentHTML", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePostContentHTML indicates an expected call of UpdatePostContentHTML.
+func (mr *MockQuerierMockRecorder) UpdatePostContentHTML(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePostContentHTML", reflect.TypeOf((*MockQuerier)(nil).UpdatePostContentHTML), ctx, arg)
+}
+
+// UpdateSearchConfig mocks base method.
+func (m *MockQuerier) UpdateSearchConfig(ctx context.Context, config string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSearchConfig", ctx, config)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateSearchConfig indicates an expected call of UpdateSearchConfig.
+func (mr *MockQuerierMockRecorder) UpdateSearchConfig(ctx, config any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSearchConfig", reflect.TypeOf((*MockQuerier)(nil).UpdateSearchConfig), ctx, config)
+}
+
+// UpdateUserEmail mocks base method.
+func (m *MockQuerier) UpdateUserEmail(ctx context.Context, arg sqlc.UpdateUserEmailParams) (sqlc.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserEmail", ctx, arg)
+	ret0, _ := ret[0].(sqlc.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserEmail indicates an expected call of UpdateUserEmail.
+func (mr *MockQuerierMockRecorder) UpdateUserEmail(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserEmail", reflect.TypeOf((*MockQuerier)(nil).UpdateUserEmail), ctx, arg)
+}
+
+// UpdateUserPassword mocks base method.
+func (m *MockQuerier) UpdateUserPassword(ctx context.Context, arg sqlc.UpdateUserPasswordParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserPassword", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUserPassword indicates an expected call of UpdateUserPassword.
+func (mr *MockQuerierMockRecorder) UpdateUserPassword(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserPassword", reflect.TypeOf((*MockQuerier)(nil).UpdateUserPassword), ctx, arg)
+}
+
+// UpdateUserRole mocks base method.
+func (m *MockQuerier) UpdateUserRole(ctx context.Context, arg sqlc.UpdateUserRoleParams) (sqlc.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserRole", ctx, arg)
+	ret0, _ := ret[0].(sqlc.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserRole indicates an expected call of UpdateUserRole.
+func (mr *MockQuerierMockRecorder) UpdateUserRole(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserRole", reflect.TypeOf((*MockQuerier)(nil).UpdateUserRole), ctx, arg)
+}
+
+// UsePersonalAccessToken mocks base method.
+func (m *MockQuerier) UsePersonalAccessToken(ctx context.Context, tokenHash string) (sqlc.UsePersonalAccessTokenRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UsePersonalAccessToken", ctx, tokenHash)
+	ret0, _ := ret[0].(sqlc.UsePersonalAccessTokenRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UsePersonalAccessToken indicates an expected call of UsePersonalAccessToken.
+func (mr *MockQuerierMockRecorder) UsePersonalAccessToken(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UsePersonalAccessToken", reflect.TypeOf((*MockQuerier)(nil).UsePersonalAccessToken), ctx, tokenHash)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockQuerier) UseRecoveryCode(ctx context.Context, arg sqlc.UseRecoveryCodeParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockQuerierMockRecorder) UseRecoveryCode(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockQuerier)(nil).UseRecoveryCode), ctx, arg)
+}
+
+// UseTOTPStep mocks base method.
+func (m *MockQuerier) UseTOTPStep(ctx context.Context, arg sqlc.UseTOTPStepParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTOTPStep", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseTOTPStep indicates an expected call of UseTOTPStep.
+func (mr *MockQuerierMockRecorder) UseTOTPStep(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTOTPStep", reflect.TypeOf((*MockQuerier)(nil).UseTOTPStep), ctx, arg)
 }
@@ -1,8 +1,8 @@
 -- internal/db/query.sql
 
 -- name: CreateUser :one
-INSERT INTO users (username, password_hash)
-VALUES ($1, $2)
+INSERT INTO users (username, password_hash, email)
+VALUES ($1, $2, $3)
 RETURNING *;
 
 -- name: GetUserByID :one
@@ -13,30 +13,700 @@ WHERE id = $1 LIMIT 1;
 SELECT * FROM users
 WHERE username = $1 LIMIT 1;
 
--- name: CreatePost :one
-INSERT INTO posts (user_id, title, content)
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 LIMIT 1;
+
+-- name: UpdateUserEmail :one
+UPDATE users
+SET email = $2, email_verified_at = NULL, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password_hash = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: RehashUserPassword :exec
+UPDATE users
+SET password_hash = sqlc.arg(new_password_hash)
+WHERE id = sqlc.arg(id) AND password_hash = sqlc.arg(password_hash);
+
+-- name: MarkUserEmailVerified :one
+UPDATE users
+SET email_verified_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND email = $2
+RETURNING *;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetUserTOTPSecret :execrows
+UPDATE users
+SET totp_secret = $2, updated_at = NOW()
+WHERE id = $1 AND totp_enabled_at IS NULL;
+
+-- name: EnableUserTOTP :execrows
+UPDATE users
+SET totp_enabled_at = NOW(), totp_last_step = $2, updated_at = NOW()
+WHERE id = $1 AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL;
+
+-- name: DisableUserTOTP :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0, updated_at = NOW()
+WHERE id = $1;
+
+-- name: UseTOTPStep :execrows
+UPDATE users
+SET totp_last_step = $2
+WHERE id = $1 AND totp_last_step < $2;
+
+-- name: CreateRecoveryCodes :exec
+INSERT INTO recovery_codes (user_id, code_hash)
+SELECT sqlc.arg(user_id)::int, unnest(sqlc.arg(code_hashes)::text[]);
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1;
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: CreateMFAChallenge :one
+INSERT INTO mfa_challenges (user_id, token_hash, expires_at)
 VALUES ($1, $2, $3)
 RETURNING *;
 
+-- name: AttemptMFAChallenge :one
+UPDATE mfa_challenges
+SET attempts = attempts + 1
+WHERE token_hash = sqlc.arg(token_hash)
+  AND expires_at > NOW()
+  AND attempts < sqlc.arg(max_attempts)
+RETURNING *;
+
+-- name: DeleteMFAChallenge :execrows
+DELETE FROM mfa_challenges
+WHERE id = $1;
+
+-- name: DeleteExpiredMFAChallenges :execrows
+DELETE FROM mfa_challenges
+WHERE expires_at <= NOW();
+
+-- name: GetIdentity :one
+SELECT * FROM identities
+WHERE provider = $1 AND subject = $2 LIMIT 1;
+
+-- name: CreateIdentity :one
+INSERT INTO identities (user_id, provider, subject, email)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: TouchIdentity :exec
+UPDATE identities
+SET email = $2, last_login_at = NOW()
+WHERE id = $1;
+
+-- name: CreateOIDCAuthRequest :exec
+INSERT INTO oidc_auth_requests (state_hash, provider, nonce, code_verifier, expires_at)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: ConsumeOIDCAuthRequest :one
+DELETE FROM oidc_auth_requests
+WHERE state_hash = $1 AND expires_at > NOW()
+RETURNING *;
+
+-- name: DeleteExpiredOIDCAuthRequests :execrows
+DELETE FROM oidc_auth_requests
+WHERE expires_at <= NOW();
+
+-- name: CreatePersonalAccessToken :one
+INSERT INTO personal_access_tokens (user_id, name, token_hash, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListPersonalAccessTokens :many
+SELECT * FROM personal_access_tokens
+WHERE user_id = $1 AND revoked_at IS NULL
+ORDER BY created_at DESC;
+
+-- name: RevokePersonalAccessToken :execrows
+UPDATE personal_access_tokens
+SET revoked_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: RevokeUserPersonalAccessTokens :execrows
+UPDATE personal_access_tokens
+SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: UsePersonalAccessToken :one
+UPDATE personal_access_tokens AS t
+SET last_used_at = NOW()
+FROM users AS u
+WHERE t.token_hash = $1
+  AND t.user_id = u.id
+  AND t.revoked_at IS NULL
+  AND (t.expires_at IS NULL OR t.expires_at > NOW())
+RETURNING t.id, t.user_id, t.scopes, t.expires_at, u.username, u.role;
+
+-- name: GetLoginLockouts :many
+SELECT scope, locked_until FROM login_failures
+WHERE ((scope = 'username' AND key = sqlc.arg(username)) OR (scope = 'ip' AND key = sqlc.arg(ip)))
+  AND locked_until > NOW();
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_failures (scope, key, failures, last_failure_at)
+VALUES (sqlc.arg(scope), sqlc.arg(key), 1, NOW())
+ON CONFLICT (scope, key) DO UPDATE
+SET failures = CASE
+    WHEN login_failures.last_failure_at < sqlc.arg(reset_before) THEN 1
+    ELSE login_failures.failures + 1
+  END,
+  last_failure_at = NOW()
+RETURNING failures;
+
+-- name: LockLogin :exec
+UPDATE login_failures
+SET locked_until = $3
+WHERE scope = $1 AND key = $2;
+
+-- name: ClearLoginFailures :exec
+DELETE FROM login_failures
+WHERE scope = $1 AND key = $2;
+
+-- name: DeleteStaleLoginFailures :execrows
+DELETE FROM login_failures
+WHERE last_failure_at < $1
+  AND (locked_until IS NULL OR locked_until < NOW());
+
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (event_type, outcome, user_id, actor_id, username, ip, user_agent, details)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE (sqlc.narg(user_id)::int IS NULL OR user_id = sqlc.narg(user_id) OR actor_id = sqlc.narg(user_id))
+  AND (sqlc.narg(event_type)::text IS NULL OR event_type = sqlc.narg(event_type))
+  AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ScheduleUserDeletion :one
+UPDATE users
+SET deletion_scheduled_at = $2, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: CancelUserDeletion :execrows
+UPDATE users
+SET deletion_scheduled_at = NULL, updated_at = NOW()
+WHERE id = $1 AND deletion_scheduled_at IS NOT NULL;
+
+-- name: RevokeDueUserSessions :execrows
+-- Revokes the sessions of the accounts whose deletion grace period has ended,
+-- ahead of DeleteDueUsers.
+UPDATE sessions
+SET is_revoked = true, revoked_at = NOW()
+WHERE is_revoked = false AND user_id IN (
+  SELECT id FROM users WHERE deletion_scheduled_at <= NOW()
+);
+
+-- name: DeleteDueUsers :many
+-- Posts go with the account. Comments are emptied like deleted ones instead,
+-- so that replies to them by others are kept. An account is only deleted once
+-- all its sessions were revoked before revoked_before: deleting it also
+-- deletes the sessions, after which no instance could learn that their
+-- access tokens are revoked.
+WITH due AS (
+  DELETE FROM users u
+  WHERE u.deletion_scheduled_at <= NOW() AND NOT EXISTS (
+    SELECT 1 FROM sessions s
+    WHERE s.user_id = u.id AND (s.is_revoked = false OR s.revoked_at > sqlc.arg(revoked_before))
+  )
+  RETURNING u.id, u.username
+), emptied_comments AS (
+  UPDATE comments SET content = '', deleted_at = NOW()
+  WHERE user_id IN (SELECT id FROM due) AND deleted_at IS NULL
+)
+SELECT id, username FROM due;
+
+-- name: AnonymizeDueUsers :many
+-- Scrubs the profile and removes every credential in one statement, so an
+-- anonymized account can never be signed in to again. Posts are kept.
+WITH due AS (
+  SELECT id, username FROM users
+  WHERE deletion_scheduled_at <= NOW()
+  FOR UPDATE SKIP LOCKED
+), anonymized AS (
+  UPDATE users u
+  SET username = 'deleted-' || u.id, password_hash = '!', email = NULL, email_verified_at = NULL,
+    totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0, role = 'reader',
+    deletion_scheduled_at = NULL, deleted_at = NOW(), updated_at = NOW()
+  FROM due
+  WHERE u.id = due.id
+  RETURNING u.id, due.username
+), revoked_sessions AS (
+  UPDATE sessions SET is_revoked = true, revoked_at = NOW()
+  WHERE user_id IN (SELECT id FROM due) AND is_revoked = false
+), deleted_identities AS (
+  DELETE FROM identities WHERE user_id IN (SELECT id FROM due)
+), deleted_tokens AS (
+  DELETE FROM personal_access_tokens WHERE user_id IN (SELECT id FROM due)
+), deleted_recovery_codes AS (
+  DELETE FROM recovery_codes WHERE user_id IN (SELECT id FROM due)
+), deleted_challenges AS (
+  DELETE FROM mfa_challenges WHERE user_id IN (SELECT id FROM due)
+), deleted_verifications AS (
+  DELETE FROM email_verification_tokens WHERE user_id IN (SELECT id FROM due)
+), deleted_resets AS (
+  DELETE FROM password_reset_tokens WHERE user_id IN (SELECT id FROM due)
+)
+SELECT id, username FROM anonymized;
+
+-- name: CreateSession :one
+INSERT INTO sessions (id, user_id, refresh_token_hash, expires_at, user_agent, client_ip)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetSession :one
+SELECT * FROM sessions
+WHERE id = $1 LIMIT 1;
+
+-- name: ListUserSessions :many
+SELECT * FROM sessions
+WHERE user_id = $1 AND is_revoked = false AND expires_at > NOW()
+ORDER BY last_seen_at DESC;
+
+-- name: RotateSessionToken :one
+UPDATE sessions
+SET refresh_token_hash = sqlc.arg(new_refresh_token_hash),
+  user_agent = sqlc.arg(user_agent),
+  client_ip = sqlc.arg(client_ip),
+  last_seen_at = NOW()
+WHERE id = sqlc.arg(id)
+  AND refresh_token_hash = sqlc.arg(refresh_token_hash)
+  AND is_revoked = false
+RETURNING *;
+
+-- name: RevokeSession :exec
+UPDATE sessions
+SET is_revoked = true, revoked_at = NOW()
+WHERE id = $1 AND is_revoked = false;
+
+-- name: RevokeUserSession :execrows
+UPDATE sessions
+SET is_revoked = true, revoked_at = NOW()
+WHERE id = $1 AND user_id = $2 AND is_revoked = false;
+
+-- name: RevokeUserSessions :exec
+UPDATE sessions
+SET is_revoked = true, revoked_at = NOW()
+WHERE user_id = $1 AND is_revoked = false;
+
+-- name: RevokeOtherUserSessions :execrows
+UPDATE sessions
+SET is_revoked = true, revoked_at = NOW()
+WHERE user_id = $1 AND id <> $2 AND is_revoked = false;
+
+-- name: ListRevokedSessions :many
+SELECT id, revoked_at FROM sessions
+WHERE revoked_at > $1;
+
+-- name: CreatePasswordResetToken :one
+INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ConsumePasswordResetToken :one
+UPDATE password_reset_tokens
+SET used_at = NOW()
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > NOW()
+RETURNING *;
+
+-- name: InvalidatePasswordResetTokens :exec
+UPDATE password_reset_tokens
+SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: CreateEmailVerificationToken :one
+INSERT INTO email_verification_tokens (user_id, email, token_hash, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ConsumeEmailVerificationToken :one
+UPDATE email_verification_tokens
+SET used_at = NOW()
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > NOW()
+RETURNING *;
+
+-- name: RevokeToken :exec
+INSERT INTO revoked_tokens (jti, expires_at)
+VALUES ($1, $2)
+ON CONFLICT (jti) DO NOTHING;
+
+-- name: ListRevokedTokens :many
+SELECT * FROM revoked_tokens
+WHERE revoked_at > $1 AND expires_at > NOW();
+
+-- name: DeleteExpiredRevokedTokens :execrows
+DELETE FROM revoked_tokens
+WHERE expires_at <= NOW();
+
+-- name: CreatePost :one
+-- The post starts its history as revision 1. Its tags are created in the
+-- same statement, so a post is never saved without them; see UpdatePost.
+WITH created AS (
+  INSERT INTO posts (user_id, title, content, status, published_at, slug, format, content_html, rendered_with)
+  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+  RETURNING *
+), history AS (
+  INSERT INTO post_revisions (post_id, revision, user_id, title, content, format)
+  SELECT id, revision, user_id, title, content, format FROM created
+), input AS (
+  SELECT unnest(sqlc.arg(tag_slugs)::text[]) AS slug, unnest(sqlc.arg(tag_names)::text[]) AS name
+), wanted AS (
+  INSERT INTO tags (slug, name)
+  SELECT slug, name FROM input
+  ON CONFLICT (slug) DO UPDATE SET name = tags.name
+  RETURNING id
+), tagged AS (
+  INSERT INTO post_tags (post_id, tag_id)
+  SELECT created.id, wanted.id FROM created, wanted
+)
+SELECT * FROM created;
+
 -- name: GetPostByID :one
-SELECT p.*, u.username as author_username
+SELECT p.*, u.username as author_username,
+  ARRAY(
+    SELECT t.slug FROM post_tags pt JOIN tags t ON pt.tag_id = t.id
+    WHERE pt.post_id = p.id ORDER BY t.slug
+  )::text[] AS tags,
+  (
+    SELECT COALESCE(jsonb_object_agg(r.kind, r.count), '{}')
+    FROM (SELECT kind, COUNT(*) AS count FROM post_reactions WHERE post_id = p.id GROUP BY kind) r
+  )::jsonb AS reactions
 FROM posts p
 JOIN users u ON p.user_id = u.id
 WHERE p.id = $1 LIMIT 1;
 
+-- name: GetPostBySlug :one
+SELECT p.*, u.username as author_username,
+  ARRAY(
+    SELECT t.slug FROM post_tags pt JOIN tags t ON pt.tag_id = t.id
+    WHERE pt.post_id = p.id ORDER BY t.slug
+  )::text[] AS tags,
+  (
+    SELECT COALESCE(jsonb_object_agg(r.kind, r.count), '{}')
+    FROM (SELECT kind, COUNT(*) AS count FROM post_reactions WHERE post_id = p.id GROUP BY kind) r
+  )::jsonb AS reactions
+FROM posts p
+JOIN users u ON p.user_id = u.id
+WHERE p.slug = $1 LIMIT 1;
+
+-- name: GetRenamedPostSlug :one
+-- Returns the current slug of the published post that used to have slug.
+SELECT p.slug
+FROM post_slugs s
+JOIN posts p ON s.post_id = p.id
+WHERE s.slug = $1 AND p.status = 'published';
+
+-- name: ListTakenPostSlugs :many
+-- Returns the slugs equal to or starting with slug that other posts have or
+-- had.
+SELECT slug FROM posts
+WHERE (slug = sqlc.arg(slug) OR slug LIKE sqlc.arg(slug) || '-%') AND id <> sqlc.arg(post_id)
+UNION
+SELECT slug FROM post_slugs
+WHERE (slug = sqlc.arg(slug) OR slug LIKE sqlc.arg(slug) || '-%') AND post_id <> sqlc.arg(post_id);
+
 -- name: ListPosts :many
-SELECT p.*, u.username as author_username
+SELECT p.*, u.username as author_username,
+  ARRAY(
+    SELECT t.slug FROM post_tags pt JOIN tags t ON pt.tag_id = t.id
+    WHERE pt.post_id = p.id ORDER BY t.slug
+  )::text[] AS tags,
+  (SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.deleted_at IS NULL) AS comment_count,
+  (
+    SELECT COALESCE(jsonb_object_agg(r.kind, r.count), '{}')
+    FROM (SELECT kind, COUNT(*) AS count FROM post_reactions WHERE post_id = p.id GROUP BY kind) r
+  )::jsonb AS reactions
+FROM posts p
+JOIN users u ON p.user_id = u.id
+WHERE p.status = 'published'
+  AND (cardinality(sqlc.arg(tags)::text[]) = 0 OR (
+    SELECT COUNT(*) FROM post_tags pt JOIN tags t ON pt.tag_id = t.id
+    WHERE pt.post_id = p.id AND t.slug = ANY(sqlc.arg(tags)::text[])
+  ) >= CASE WHEN sqlc.arg(match_all)::bool THEN cardinality(sqlc.arg(tags)::text[]) ELSE 1 END)
+ORDER BY p.published_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: ListPostsByAuthor :many
+SELECT p.*, u.username as author_username,
+  ARRAY(
+    SELECT t.slug FROM post_tags pt JOIN tags t ON pt.tag_id = t.id
+    WHERE pt.post_id = p.id ORDER BY t.slug
+  )::text[] AS tags,
+  (
+    SELECT COALESCE(jsonb_object_agg(r.kind, r.count), '{}')
+    FROM (SELECT kind, COUNT(*) AS count FROM post_reactions WHERE post_id = p.id GROUP BY kind) r
+  )::jsonb AS reactions
 FROM posts p
 JOIN users u ON p.user_id = u.id
-ORDER BY p.created_at DESC
-LIMIT $1 OFFSET $2; -- For pagination
+WHERE p.user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(status)::text IS NULL OR p.status = sqlc.narg(status))
+ORDER BY p.updated_at DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListUserPosts :many
+SELECT * FROM posts
+WHERE user_id = $1
+ORDER BY created_at;
 
 -- name: UpdatePost :one
-UPDATE posts
-SET title = $2, content = $3, updated_at = NOW()
-WHERE id = $1 AND user_id = $4 -- Ensure user owns the post
+-- A replaced slug is kept in post_slugs, so links to it keep working. The
+-- new version is stored as the next revision, saved by editor_id. When
+-- set_tags is true, the tags of the post are replaced in the same statement,
+-- creating the ones that do not exist yet. The no-op update makes ON
+-- CONFLICT return existing tags too, even one created by a concurrent
+-- request.
+WITH history AS (
+  INSERT INTO post_slugs (slug, post_id)
+  SELECT slug, id FROM posts
+  WHERE id = $1 AND slug <> $6
+  ON CONFLICT (slug) DO NOTHING
+), updated AS (
+  UPDATE posts
+  SET title = $2, content = $3, status = $4, published_at = $5, slug = $6,
+    format = $7, content_html = $8, rendered_with = $9,
+    revision = revision + 1, updated_at = NOW()
+  WHERE id = $1
+  RETURNING *
+), revisions AS (
+  INSERT INTO post_revisions (post_id, revision, user_id, title, content, format)
+  SELECT id, revision, sqlc.arg(editor_id)::int, title, content, format FROM updated
+), input AS (
+  SELECT unnest(sqlc.arg(tag_slugs)::text[]) AS slug, unnest(sqlc.arg(tag_names)::text[]) AS name
+  WHERE sqlc.arg(set_tags)::bool
+), wanted AS (
+  INSERT INTO tags (slug, name)
+  SELECT slug, name FROM input
+  ON CONFLICT (slug) DO UPDATE SET name = tags.name
+  RETURNING id
+), removed AS (
+  DELETE FROM post_tags
+  WHERE sqlc.arg(set_tags)::bool AND post_id IN (SELECT id FROM updated)
+    AND tag_id NOT IN (SELECT id FROM wanted)
+), tagged AS (
+  INSERT INTO post_tags (post_id, tag_id)
+  SELECT updated.id, wanted.id FROM updated, wanted
+  ON CONFLICT DO NOTHING
+)
+SELECT * FROM updated;
+
+-- name: ListTags :many
+-- Counts published posts only; tags without any are left out.
+SELECT t.slug, t.name, COUNT(*) AS post_count
+FROM tags t
+JOIN post_tags pt ON pt.tag_id = t.id
+JOIN posts p ON pt.post_id = p.id
+WHERE p.status = 'published'
+GROUP BY t.id
+ORDER BY post_count DESC, t.slug
+LIMIT $1 OFFSET $2;
+
+-- name: SearchPosts :many
+-- Finds published posts matching a query in websearch_to_tsquery syntax, best
+-- matches first. Matches in the headlines are wrapped in U+E000 and U+E001,
+-- which the caller turns into markup after escaping the text. config must be
+-- the configuration the documents are built with.
+SELECT p.id, p.title, p.slug, p.published_at, u.username AS author_username,
+  ts_rank(s.document, q.query) AS rank,
+  ts_headline(q.config, p.title, q.query, format('HighlightAll=true, StartSel=%s, StopSel=%s', chr(57344), chr(57345)))::text AS title_headline,
+  ts_headline(q.config, p.content, q.query, format('MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=" … ", StartSel=%s, StopSel=%s', chr(57344), chr(57345)))::text AS snippet
+FROM post_search s
+JOIN posts p ON s.post_id = p.id
+JOIN users u ON p.user_id = u.id
+CROSS JOIN (
+  SELECT sqlc.arg(config)::regconfig AS config, websearch_to_tsquery(sqlc.arg(config)::regconfig, sqlc.arg(query)) AS query
+) AS q
+WHERE s.document @@ q.query AND p.status = 'published'
+ORDER BY rank DESC, p.published_at DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: UpdateSearchConfig :execrows
+-- Sets the text search configuration of the post_search documents and, if it
+-- changed, rebuilds them. Returns the number of documents rebuilt.
+WITH changed AS (
+  UPDATE search_settings SET config = sqlc.arg(config)::regconfig
+  WHERE config <> sqlc.arg(config)::regconfig
+  RETURNING config
+)
+UPDATE post_search s
+SET document = setweight(to_tsvector(changed.config, p.title), 'A') || setweight(to_tsvector(changed.config, p.content), 'B')
+FROM posts p, changed
+WHERE s.post_id = p.id;
+
+-- name: ListPostRevisions :many
+SELECT r.revision, r.user_id, u.username AS author_username, r.title, r.created_at
+FROM post_revisions r
+LEFT JOIN users u ON r.user_id = u.id
+WHERE r.post_id = $1
+ORDER BY r.revision DESC
+LIMIT $2 OFFSET $3;
+
+-- name: GetPostRevision :one
+SELECT r.post_id, r.revision, r.user_id, r.title, r.content, r.format, r.created_at, u.username AS author_username
+FROM post_revisions r
+LEFT JOIN users u ON r.user_id = u.id
+WHERE r.post_id = $1 AND r.revision = $2;
+
+-- name: SetPostCommentsLocked :exec
+UPDATE posts SET comments_locked = $2
+WHERE id = $1;
+
+-- name: CreateComment :one
+INSERT INTO comments (post_id, parent_id, user_id, content)
+VALUES ($1, $2, $3, $4)
 RETURNING *;
 
--- name: DeletePost :exec
+-- name: GetComment :one
+SELECT * FROM comments
+WHERE id = $1;
+
+-- name: UpdateComment :one
+UPDATE comments
+SET content = $2, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: DeleteComment :exec
+-- Removes a comment without replies. A comment with replies is emptied and
+-- marked deleted instead, so that its replies keep their place.
+WITH removed AS (
+  DELETE FROM comments
+  WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM comments r WHERE r.parent_id = $1)
+  RETURNING id
+)
+UPDATE comments
+SET content = '', deleted_at = NOW()
+WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM removed);
+
+-- name: ListComments :many
+-- Returns a page of the top-level comments of a post, oldest first, each
+-- followed by all its replies in thread order with their depth. Deleted
+-- comments keep their place without their author.
+WITH RECURSIVE thread AS (
+  (
+    SELECT id, parent_id, user_id, content, created_at, updated_at, deleted_at, 0 AS depth, ARRAY[id] AS path
+    FROM comments
+    WHERE post_id = $1 AND parent_id IS NULL
+    ORDER BY id
+    LIMIT $2 OFFSET $3
+  )
+  UNION ALL
+  SELECT c.id, c.parent_id, c.user_id, c.content, c.created_at, c.updated_at, c.deleted_at, t.depth + 1, t.path || c.id
+  FROM comments c
+  JOIN thread t ON c.parent_id = t.id
+)
+SELECT t.id, t.parent_id, CASE WHEN t.deleted_at IS NULL THEN t.user_id END AS user_id,
+  u.username AS author_username, t.content, t.created_at, t.updated_at, t.deleted_at, t.depth::int AS depth
+FROM thread t
+LEFT JOIN users u ON t.user_id = u.id AND t.deleted_at IS NULL
+ORDER BY t.path;
+
+-- name: ListUserComments :many
+SELECT * FROM comments
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: AddPostReaction :exec
+INSERT INTO post_reactions (post_id, user_id, kind)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: RemovePostReaction :exec
+DELETE FROM post_reactions
+WHERE post_id = $1 AND user_id = $2 AND kind = $3;
+
+-- name: ListUserReactions :many
+SELECT * FROM post_reactions
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: GetPostReactions :one
+-- Returns the number of reactions of each kind to a post, and the kinds the
+-- user reacted with.
+SELECT
+  (
+    SELECT COALESCE(jsonb_object_agg(r.kind, r.count), '{}')
+    FROM (SELECT kind, COUNT(*) AS count FROM post_reactions WHERE post_id = sqlc.arg(post_id) GROUP BY kind) r
+  )::jsonb AS reactions,
+  ARRAY(
+    SELECT kind FROM post_reactions
+    WHERE post_id = sqlc.arg(post_id) AND user_id = sqlc.arg(user_id) ORDER BY kind
+  )::text[] AS mine;
+
+-- name: ListReactedPosts :many
+-- Returns the published posts a user reacted to with a kind, most recent
+-- reaction first.
+SELECT p.*, u.username as author_username,
+  ARRAY(
+    SELECT t.slug FROM post_tags pt JOIN tags t ON pt.tag_id = t.id
+    WHERE pt.post_id = p.id ORDER BY t.slug
+  )::text[] AS tags,
+  (
+    SELECT COALESCE(jsonb_object_agg(r.kind, r.count), '{}')
+    FROM (SELECT kind, COUNT(*) AS count FROM post_reactions WHERE post_id = p.id GROUP BY kind) r
+  )::jsonb AS reactions,
+  pr.created_at AS reacted_at
+FROM post_reactions pr
+JOIN posts p ON pr.post_id = p.id
+JOIN users u ON p.user_id = u.id
+WHERE pr.user_id = $1 AND pr.kind = $2 AND p.status = 'published'
+ORDER BY pr.created_at DESC
+LIMIT $3 OFFSET $4;
+
+-- name: ListPostsToRender :many
+-- Returns posts whose content_html was made with other renderer settings, or
+-- not at all, in ID order from after_id.
+SELECT id, format, content, updated_at FROM posts
+WHERE rendered_with IS DISTINCT FROM sqlc.arg(rendered_with) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: UpdatePostContentHTML :exec
+-- Leaves a post alone that was edited after it was listed; the edit rendered
+-- it already.
+UPDATE posts
+SET content_html = $2, rendered_with = $3
+WHERE id = $1 AND updated_at = $4;
+
+-- name: PublishDuePosts :many
+-- Each due post is claimed with SKIP LOCKED and its status checked again
+-- when it is updated, so instances running this concurrently publish every
+-- post exactly once.
+UPDATE posts
+SET status = 'published', updated_at = NOW()
+WHERE status = 'scheduled' AND id IN (
+  SELECT id FROM posts
+  WHERE status = 'scheduled' AND published_at <= NOW()
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, user_id;
+
+-- name: DeletePost :execrows
+-- Only deletes the post of another user when any_owner is set.
 DELETE FROM posts
-WHERE id = $1 AND user_id = $2; -- Ensure user owns the post
\ No newline at end of file
+WHERE id = sqlc.arg(id) AND (user_id = sqlc.arg(user_id) OR sqlc.arg(any_owner)::bool);
\ No newline at end of file
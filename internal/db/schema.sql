@@ -4,16 +4,307 @@ CREATE TABLE users (
   username VARCHAR(50) UNIQUE NOT NULL,
   password_hash VARCHAR(255) NOT NULL,
   created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  role VARCHAR(20) NOT NULL DEFAULT 'author' CHECK (role IN ('admin', 'editor', 'author', 'reader')),
+  email VARCHAR(255) UNIQUE,
+  email_verified_at TIMESTAMPTZ,
+  -- TOTP two-factor authentication. The secret is set at enrollment and only
+  -- enforced once totp_enabled_at is set; totp_last_step is the last accepted
+  -- time step, so a code cannot be used twice.
+  totp_secret VARCHAR(64),
+  totp_enabled_at TIMESTAMPTZ,
+  totp_last_step BIGINT NOT NULL DEFAULT 0,
+  -- Self-service deletion: the account is deleted or anonymized once
+  -- deletion_scheduled_at has passed, unless the user cancels before.
+  -- deleted_at marks anonymized accounts.
+  deletion_scheduled_at TIMESTAMPTZ,
+  deleted_at TIMESTAMPTZ
 );
 
+CREATE INDEX idx_users_deletion_scheduled_at ON users(deletion_scheduled_at);
+
 CREATE TABLE posts (
   id SERIAL PRIMARY KEY,
   user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
   title VARCHAR(255) NOT NULL,
   content TEXT NOT NULL, 
   created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  -- Only published posts are public. A scheduled post is published by the
+  -- server once published_at has passed.
+  status VARCHAR(20) NOT NULL DEFAULT 'published'
+    CHECK (status IN ('draft', 'published', 'scheduled', 'archived')),
+  published_at TIMESTAMPTZ,
+  slug VARCHAR(255) NOT NULL UNIQUE,
+  -- content_html is content rendered to sanitized HTML, cached until the post
+  -- changes or the renderer settings fingerprinted in rendered_with do.
+  format VARCHAR(10) NOT NULL DEFAULT 'markdown'
+    CHECK (format IN ('markdown', 'plain', 'html')),
+  content_html TEXT,
+  rendered_with VARCHAR(64),
+  -- The number of the post's latest revision in post_revisions.
+  revision INTEGER NOT NULL DEFAULT 1,
+  -- Whether new comments are refused.
+  comments_locked BOOLEAN NOT NULL DEFAULT FALSE,
+  CONSTRAINT posts_published_at_check CHECK (status NOT IN ('published', 'scheduled') OR published_at IS NOT NULL)
+);
+
+CREATE INDEX idx_posts_user_id ON posts(user_id);
+CREATE INDEX idx_posts_published_at ON posts(published_at DESC) WHERE status = 'published';
+CREATE INDEX idx_posts_scheduled_at ON posts(published_at) WHERE status = 'scheduled';
+
+-- Slugs a post had before it was renamed, so old links can be redirected.
+-- A slug is never given to another post, not even after a rename.
+CREATE TABLE post_slugs (
+  slug VARCHAR(255) PRIMARY KEY,
+  post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_post_slugs_post_id ON post_slugs(post_id);
+
+-- Every saved version of a post's title and content, numbered from 1 per
+-- post. user_id is who saved it.
+CREATE TABLE post_revisions (
+  post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+  revision INTEGER NOT NULL,
+  user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+  title VARCHAR(255) NOT NULL,
+  content TEXT NOT NULL,
+  format VARCHAR(10) NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (post_id, revision)
+);
+
+CREATE INDEX idx_post_revisions_user_id ON post_revisions(user_id);
+
+-- A tag is identified by its slug, so "Go" and "go" are the same tag; name is
+-- how it was first written.
+CREATE TABLE tags (
+  id SERIAL PRIMARY KEY,
+  slug VARCHAR(80) NOT NULL UNIQUE,
+  name VARCHAR(50) NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE post_tags (
+  post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+  tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+  PRIMARY KEY (post_id, tag_id)
+);
+CREATE INDEX idx_post_tags_tag_id ON post_tags(tag_id);
+
+-- The text search configuration the documents in post_search are built
+-- with, a single row. The server sets it from SEARCH_CONFIG at startup and
+-- rebuilds the documents when it changes.
+CREATE TABLE search_settings (
+  id BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+  config REGCONFIG NOT NULL
+);
+
+-- The full-text search document of each post, kept up to date by the
+-- trigger below. The title weighs more than the content. It is a table of
+-- its own so that queries selecting posts, and the Post model, do not carry
+-- it.
+CREATE TABLE post_search (
+  post_id INTEGER PRIMARY KEY REFERENCES posts(id) ON DELETE CASCADE,
+  document TSVECTOR NOT NULL
+);
+CREATE INDEX idx_post_search_document ON post_search USING GIN (document);
+
+CREATE FUNCTION posts_search_document() RETURNS trigger AS $$
+DECLARE
+  config REGCONFIG := (SELECT s.config FROM search_settings s);
+BEGIN
+  INSERT INTO post_search (post_id, document)
+  VALUES (NEW.id, setweight(to_tsvector(config, NEW.title), 'A') || setweight(to_tsvector(config, NEW.content), 'B'))
+  ON CONFLICT (post_id) DO UPDATE SET document = EXCLUDED.document;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER posts_search_document
+AFTER INSERT OR UPDATE OF title, content ON posts
+FOR EACH ROW EXECUTE FUNCTION posts_search_document();
+
+-- Comments on posts. A reply has the comment it answers as parent_id. A
+-- comment that has replies is not removed when it is deleted, only emptied
+-- and marked with deleted_at, so that the thread stays whole.
+CREATE TABLE comments (
+  id SERIAL PRIMARY KEY,
+  post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+  parent_id INTEGER REFERENCES comments(id) ON DELETE CASCADE,
+  user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+  content TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  deleted_at TIMESTAMPTZ
+);
+CREATE INDEX idx_comments_post_id ON comments(post_id);
+CREATE INDEX idx_comments_parent_id ON comments(parent_id);
+CREATE INDEX idx_comments_user_id ON comments(user_id);
+
+-- Reactions of users to posts, at most one of each kind per user and post.
+CREATE TABLE post_reactions (
+  post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  kind VARCHAR(20) NOT NULL CHECK (kind IN ('like', 'love', 'insightful', 'funny', 'celebrate')),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (post_id, user_id, kind)
+);
+CREATE INDEX idx_post_reactions_user_id ON post_reactions(user_id, kind, created_at DESC);
+
+-- A session is one refresh token family: every rotation replaces
+-- refresh_token_hash, so presenting an older token means it was replayed.
+CREATE TABLE sessions (
+  id UUID PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  refresh_token_hash VARCHAR(64) NOT NULL,
+  is_revoked BOOLEAN NOT NULL DEFAULT false,
+  expires_at TIMESTAMPTZ NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  -- The device that logged in, updated with last_seen_at whenever the
+  -- refresh token is used.
+  user_agent VARCHAR(512) NOT NULL DEFAULT '',
+  client_ip VARCHAR(64) NOT NULL DEFAULT '',
+  last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  -- Access tokens carry their session ID, so they are rejected from
+  -- revoked_at on as well.
+  revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_sessions_user_id ON sessions(user_id);
+CREATE INDEX idx_sessions_revoked_at ON sessions(revoked_at);
+
+-- Access tokens revoked before their expiry. Rows can be deleted once
+-- expires_at has passed because the token would be rejected anyway.
+CREATE TABLE revoked_tokens (
+  jti UUID PRIMARY KEY,
+  expires_at TIMESTAMPTZ NOT NULL,
+  revoked_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+
+-- Single-use password reset tokens. Only the SHA-256 of the token is stored;
+-- used_at is set when the token is redeemed.
+CREATE TABLE password_reset_tokens (
+  id BIGSERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  token_hash VARCHAR(64) UNIQUE NOT NULL,
+  expires_at TIMESTAMPTZ NOT NULL,
+  used_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens(user_id);
+
+-- Single-use email verification tokens. email is the address the token was
+-- sent to, so a token cannot verify an address the user switched to later.
+CREATE TABLE email_verification_tokens (
+  id BIGSERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  email VARCHAR(255) NOT NULL,
+  token_hash VARCHAR(64) UNIQUE NOT NULL,
+  expires_at TIMESTAMPTZ NOT NULL,
+  used_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_email_verification_tokens_user_id ON email_verification_tokens(user_id);
+
+-- Single-use 2FA recovery codes, stored as SHA-256 hashes.
+CREATE TABLE recovery_codes (
+  id BIGSERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  code_hash VARCHAR(64) NOT NULL,
+  used_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes(user_id);
+
+-- A password login waiting for its second factor. The client holds the token
+-- whose hash is token_hash; every attempt increments attempts.
+CREATE TABLE mfa_challenges (
+  id BIGSERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  token_hash VARCHAR(64) UNIQUE NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  expires_at TIMESTAMPTZ NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Accounts at external OpenID Connect providers, identified by the issuer's
+-- subject, that sign in as a Plog user.
+CREATE TABLE identities (
+  id BIGSERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  provider VARCHAR(50) NOT NULL,
+  subject VARCHAR(255) NOT NULL,
+  email VARCHAR(255),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  last_login_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE (provider, subject)
+);
+
+CREATE INDEX idx_identities_user_id ON identities(user_id);
+
+-- OIDC logins in progress, keyed by the hash of the state parameter. Rows
+-- are deleted when the provider redirects back.
+CREATE TABLE oidc_auth_requests (
+  state_hash VARCHAR(64) PRIMARY KEY,
+  provider VARCHAR(50) NOT NULL,
+  nonce VARCHAR(64) NOT NULL,
+  code_verifier VARCHAR(128) NOT NULL,
+  expires_at TIMESTAMPTZ NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Long-lived API tokens created by users for scripts. Only the SHA-256 of a
+-- token is stored; scopes limit which endpoints it can call.
+CREATE TABLE personal_access_tokens (
+  id BIGSERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name VARCHAR(100) NOT NULL,
+  token_hash VARCHAR(64) NOT NULL UNIQUE,
+  scopes TEXT[] NOT NULL,
+  expires_at TIMESTAMPTZ,
+  last_used_at TIMESTAMPTZ,
+  revoked_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_personal_access_tokens_user_id ON personal_access_tokens(user_id);
+
+-- Failed password logins per username and per client IP, used to slow down
+-- and temporarily lock out password guessing across all instances.
+CREATE TABLE login_failures (
+  scope VARCHAR(10) NOT NULL CHECK (scope IN ('username', 'ip')),
+  key VARCHAR(255) NOT NULL,
+  failures INTEGER NOT NULL DEFAULT 0,
+  last_failure_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  locked_until TIMESTAMPTZ,
+  PRIMARY KEY (scope, key)
+);
+
+-- Security-relevant events such as logins and credential changes. user_id is
+-- the account the event is about and actor_id the authenticated user who
+-- caused it, if any. username keeps the name given at the time, also for
+-- failed logins to unknown or since deleted accounts.
+CREATE TABLE audit_events (
+  id BIGSERIAL PRIMARY KEY,
+  event_type VARCHAR(50) NOT NULL,
+  outcome VARCHAR(10) NOT NULL CHECK (outcome IN ('success', 'failure')),
+  user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+  actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+  username VARCHAR(255) NOT NULL DEFAULT '',
+  ip VARCHAR(64) NOT NULL DEFAULT '',
+  user_agent VARCHAR(512) NOT NULL DEFAULT '',
+  details TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
-CREATE INDEX idx_posts_user_id ON posts(user_id);
\ No newline at end of file
+CREATE INDEX idx_audit_events_created_at ON audit_events(created_at);
+CREATE INDEX idx_audit_events_user_id ON audit_events(user_id, created_at);
+CREATE INDEX idx_audit_events_actor_id ON audit_events(actor_id, created_at);
+CREATE INDEX idx_audit_events_event_type ON audit_events(event_type, created_at);
@@ -5,22 +5,202 @@
 package sqlc
 
 import (
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-type Post struct {
+type AuditEvent struct {
+	ID        int64              `json:"id"`
+	EventType string             `json:"event_type"`
+	Outcome   string             `json:"outcome"`
+	UserID    pgtype.Int4        `json:"user_id"`
+	ActorID   pgtype.Int4        `json:"actor_id"`
+	Username  string             `json:"username"`
+	Ip        string             `json:"ip"`
+	UserAgent string             `json:"user_agent"`
+	Details   string             `json:"details"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type Comment struct {
 	ID        int32              `json:"id"`
+	PostID    int32              `json:"post_id"`
+	ParentID  pgtype.Int4        `json:"parent_id"`
+	UserID    pgtype.Int4        `json:"user_id"`
+	Content   string             `json:"content"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt pgtype.Timestamptz `json:"deleted_at"`
+}
+
+type EmailVerificationToken struct {
+	ID        int64              `json:"id"`
+	UserID    int32              `json:"user_id"`
+	Email     string             `json:"email"`
+	TokenHash string             `json:"token_hash"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type Identity struct {
+	ID          int64              `json:"id"`
+	UserID      int32              `json:"user_id"`
+	Provider    string             `json:"provider"`
+	Subject     string             `json:"subject"`
+	Email       pgtype.Text        `json:"email"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	LastLoginAt pgtype.Timestamptz `json:"last_login_at"`
+}
+
+type LoginFailure struct {
+	Scope         string             `json:"scope"`
+	Key           string             `json:"key"`
+	Failures      int32              `json:"failures"`
+	LastFailureAt pgtype.Timestamptz `json:"last_failure_at"`
+	LockedUntil   pgtype.Timestamptz `json:"locked_until"`
+}
+
+type MfaChallenge struct {
+	ID        int64              `json:"id"`
 	UserID    int32              `json:"user_id"`
+	TokenHash string             `json:"token_hash"`
+	Attempts  int32              `json:"attempts"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type OidcAuthRequest struct {
+	StateHash    string             `json:"state_hash"`
+	Provider     string             `json:"provider"`
+	Nonce        string             `json:"nonce"`
+	CodeVerifier string             `json:"code_verifier"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
+type PasswordResetToken struct {
+	ID        int64              `json:"id"`
+	UserID    int32              `json:"user_id"`
+	TokenHash string             `json:"token_hash"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type PersonalAccessToken struct {
+	ID         int64              `json:"id"`
+	UserID     int32              `json:"user_id"`
+	Name       string             `json:"name"`
+	TokenHash  string             `json:"token_hash"`
+	Scopes     []string           `json:"scopes"`
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
+	RevokedAt  pgtype.Timestamptz `json:"revoked_at"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+type Post struct {
+	ID             int32              `json:"id"`
+	UserID         int32              `json:"user_id"`
+	Title          string             `json:"title"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	Status         string             `json:"status"`
+	PublishedAt    pgtype.Timestamptz `json:"published_at"`
+	Slug           string             `json:"slug"`
+	Format         string             `json:"format"`
+	ContentHtml    pgtype.Text        `json:"content_html"`
+	RenderedWith   pgtype.Text        `json:"rendered_with"`
+	Revision       int32              `json:"revision"`
+	CommentsLocked bool               `json:"comments_locked"`
+}
+
+type PostReaction struct {
+	PostID    int32              `json:"post_id"`
+	UserID    int32              `json:"user_id"`
+	Kind      string             `json:"kind"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type PostRevision struct {
+	PostID    int32              `json:"post_id"`
+	Revision  int32              `json:"revision"`
+	UserID    pgtype.Int4        `json:"user_id"`
 	Title     string             `json:"title"`
 	Content   string             `json:"content"`
+	Format    string             `json:"format"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type PostSearch struct {
+	PostID   int32       `json:"post_id"`
+	Document interface{} `json:"document"`
+}
+
+type PostSlug struct {
+	Slug      string             `json:"slug"`
+	PostID    int32              `json:"post_id"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type PostTag struct {
+	PostID int32 `json:"post_id"`
+	TagID  int32 `json:"tag_id"`
+}
+
+type RecoveryCode struct {
+	ID        int64              `json:"id"`
+	UserID    int32              `json:"user_id"`
+	CodeHash  string             `json:"code_hash"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type RevokedToken struct {
+	Jti       uuid.UUID          `json:"jti"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	RevokedAt pgtype.Timestamptz `json:"revoked_at"`
+}
+
+type SearchSetting struct {
+	ID     bool        `json:"id"`
+	Config interface{} `json:"config"`
+}
+
+type Session struct {
+	ID               uuid.UUID          `json:"id"`
+	UserID           int32              `json:"user_id"`
+	RefreshTokenHash string             `json:"refresh_token_hash"`
+	IsRevoked        bool               `json:"is_revoked"`
+	ExpiresAt        pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	UserAgent        string             `json:"user_agent"`
+	ClientIp         string             `json:"client_ip"`
+	LastSeenAt       pgtype.Timestamptz `json:"last_seen_at"`
+	RevokedAt        pgtype.Timestamptz `json:"revoked_at"`
+}
+
+type Tag struct {
+	ID        int32              `json:"id"`
+	Slug      string             `json:"slug"`
+	Name      string             `json:"name"`
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
 }
 
 type User struct {
-	ID           int32              `json:"id"`
-	Username     string             `json:"username"`
-	PasswordHash string             `json:"password_hash"`
-	CreatedAt    pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+	ID                  int32              `json:"id"`
+	Username            string             `json:"username"`
+	PasswordHash        string             `json:"password_hash"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
+	Role                string             `json:"role"`
+	Email               pgtype.Text        `json:"email"`
+	EmailVerifiedAt     pgtype.Timestamptz `json:"email_verified_at"`
+	TotpSecret          pgtype.Text        `json:"totp_secret"`
+	TotpEnabledAt       pgtype.Timestamptz `json:"totp_enabled_at"`
+	TotpLastStep        int64              `json:"totp_last_step"`
+	DeletionScheduledAt pgtype.Timestamptz `json:"deletion_scheduled_at"`
+	DeletedAt           pgtype.Timestamptz `json:"deleted_at"`
 }
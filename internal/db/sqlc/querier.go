@@ -6,19 +6,147 @@ package sqlc
 
 import (
 	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	AddPostReaction(ctx context.Context, arg AddPostReactionParams) error
+	// Scrubs the profile and removes every credential in one statement, so an
+	// anonymized account can never be signed in to again. Posts are kept.
+	AnonymizeDueUsers(ctx context.Context) ([]AnonymizeDueUsersRow, error)
+	AttemptMFAChallenge(ctx context.Context, arg AttemptMFAChallengeParams) (MfaChallenge, error)
+	CancelUserDeletion(ctx context.Context, id int32) (int64, error)
+	ClearLoginFailures(ctx context.Context, arg ClearLoginFailuresParams) error
+	ConsumeEmailVerificationToken(ctx context.Context, tokenHash string) (EmailVerificationToken, error)
+	ConsumeOIDCAuthRequest(ctx context.Context, stateHash string) (OidcAuthRequest, error)
+	ConsumePasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error)
+	CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) error
+	CreateComment(ctx context.Context, arg CreateCommentParams) (Comment, error)
+	CreateEmailVerificationToken(ctx context.Context, arg CreateEmailVerificationTokenParams) (EmailVerificationToken, error)
+	CreateIdentity(ctx context.Context, arg CreateIdentityParams) (Identity, error)
+	CreateMFAChallenge(ctx context.Context, arg CreateMFAChallengeParams) (MfaChallenge, error)
+	CreateOIDCAuthRequest(ctx context.Context, arg CreateOIDCAuthRequestParams) error
+	CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error)
+	CreatePersonalAccessToken(ctx context.Context, arg CreatePersonalAccessTokenParams) (PersonalAccessToken, error)
+	// The post starts its history as revision 1. Its tags are created in the
+	// same statement, so a post is never saved without them; see UpdatePost.
 	CreatePost(ctx context.Context, arg CreatePostParams) (Post, error)
+	CreateRecoveryCodes(ctx context.Context, arg CreateRecoveryCodesParams) error
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	// internal/db/query.sql
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
-	DeletePost(ctx context.Context, arg DeletePostParams) error
+	// Removes a comment without replies. A comment with replies is emptied and
+	// marked deleted instead, so that its replies keep their place.
+	DeleteComment(ctx context.Context, id int32) error
+	// Posts go with the account. Comments are emptied like deleted ones instead,
+	// so that replies to them by others are kept. An account is only deleted once
+	// all its sessions were revoked before revoked_before: deleting it also
+	// deletes the sessions, after which no instance could learn that their
+	// access tokens are revoked.
+	DeleteDueUsers(ctx context.Context, revokedBefore pgtype.Timestamptz) ([]DeleteDueUsersRow, error)
+	DeleteExpiredMFAChallenges(ctx context.Context) (int64, error)
+	DeleteExpiredOIDCAuthRequests(ctx context.Context) (int64, error)
+	DeleteExpiredRevokedTokens(ctx context.Context) (int64, error)
+	DeleteMFAChallenge(ctx context.Context, id int64) (int64, error)
+	// Only deletes the post of another user when any_owner is set.
+	DeletePost(ctx context.Context, arg DeletePostParams) (int64, error)
+	DeleteRecoveryCodes(ctx context.Context, userID int32) error
+	DeleteStaleLoginFailures(ctx context.Context, lastFailureAt pgtype.Timestamptz) (int64, error)
+	DisableUserTOTP(ctx context.Context, id int32) error
+	EnableUserTOTP(ctx context.Context, arg EnableUserTOTPParams) (int64, error)
+	GetComment(ctx context.Context, id int32) (Comment, error)
+	GetIdentity(ctx context.Context, arg GetIdentityParams) (Identity, error)
+	GetLoginLockouts(ctx context.Context, arg GetLoginLockoutsParams) ([]GetLoginLockoutsRow, error)
 	GetPostByID(ctx context.Context, id int32) (GetPostByIDRow, error)
+	GetPostBySlug(ctx context.Context, slug string) (GetPostBySlugRow, error)
+	// Returns the number of reactions of each kind to a post, and the kinds the
+	// user reacted with.
+	GetPostReactions(ctx context.Context, arg GetPostReactionsParams) (GetPostReactionsRow, error)
+	GetPostRevision(ctx context.Context, arg GetPostRevisionParams) (GetPostRevisionRow, error)
+	// Returns the current slug of the published post that used to have slug.
+	GetRenamedPostSlug(ctx context.Context, slug string) (string, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetUserByEmail(ctx context.Context, email pgtype.Text) (User, error)
 	GetUserByID(ctx context.Context, id int32) (User, error)
 	GetUserByUsername(ctx context.Context, username string) (User, error)
+	InvalidatePasswordResetTokens(ctx context.Context, userID int32) error
+	ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error)
+	// Returns a page of the top-level comments of a post, oldest first, each
+	// followed by all its replies in thread order with their depth. Deleted
+	// comments keep their place without their author.
+	ListComments(ctx context.Context, arg ListCommentsParams) ([]ListCommentsRow, error)
+	ListPersonalAccessTokens(ctx context.Context, userID int32) ([]PersonalAccessToken, error)
+	ListPostRevisions(ctx context.Context, arg ListPostRevisionsParams) ([]ListPostRevisionsRow, error)
 	ListPosts(ctx context.Context, arg ListPostsParams) ([]ListPostsRow, error)
-	// For pagination
+	ListPostsByAuthor(ctx context.Context, arg ListPostsByAuthorParams) ([]ListPostsByAuthorRow, error)
+	// Returns posts whose content_html was made with other renderer settings, or
+	// not at all, in ID order from after_id.
+	ListPostsToRender(ctx context.Context, arg ListPostsToRenderParams) ([]ListPostsToRenderRow, error)
+	// Returns the published posts a user reacted to with a kind, most recent
+	// reaction first.
+	ListReactedPosts(ctx context.Context, arg ListReactedPostsParams) ([]ListReactedPostsRow, error)
+	ListRevokedSessions(ctx context.Context, revokedAt pgtype.Timestamptz) ([]ListRevokedSessionsRow, error)
+	ListRevokedTokens(ctx context.Context, revokedAt pgtype.Timestamptz) ([]RevokedToken, error)
+	// Counts published posts only; tags without any are left out.
+	ListTags(ctx context.Context, arg ListTagsParams) ([]ListTagsRow, error)
+	// Returns the slugs equal to or starting with slug that other posts have or
+	// had.
+	ListTakenPostSlugs(ctx context.Context, arg ListTakenPostSlugsParams) ([]string, error)
+	ListUserComments(ctx context.Context, userID pgtype.Int4) ([]Comment, error)
+	ListUserPosts(ctx context.Context, userID int32) ([]Post, error)
+	ListUserReactions(ctx context.Context, userID int32) ([]PostReaction, error)
+	ListUserSessions(ctx context.Context, userID int32) ([]Session, error)
+	LockLogin(ctx context.Context, arg LockLoginParams) error
+	MarkUserEmailVerified(ctx context.Context, arg MarkUserEmailVerifiedParams) (User, error)
+	// Each due post is claimed with SKIP LOCKED and its status checked again
+	// when it is updated, so instances running this concurrently publish every
+	// post exactly once.
+	PublishDuePosts(ctx context.Context) ([]PublishDuePostsRow, error)
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (int32, error)
+	RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) error
+	RemovePostReaction(ctx context.Context, arg RemovePostReactionParams) error
+	// Revokes the sessions of the accounts whose deletion grace period has ended,
+	// ahead of DeleteDueUsers.
+	RevokeDueUserSessions(ctx context.Context) (int64, error)
+	RevokeOtherUserSessions(ctx context.Context, arg RevokeOtherUserSessionsParams) (int64, error)
+	RevokePersonalAccessToken(ctx context.Context, arg RevokePersonalAccessTokenParams) (int64, error)
+	RevokeSession(ctx context.Context, id uuid.UUID) error
+	RevokeToken(ctx context.Context, arg RevokeTokenParams) error
+	RevokeUserPersonalAccessTokens(ctx context.Context, userID int32) (int64, error)
+	RevokeUserSession(ctx context.Context, arg RevokeUserSessionParams) (int64, error)
+	RevokeUserSessions(ctx context.Context, userID int32) error
+	RotateSessionToken(ctx context.Context, arg RotateSessionTokenParams) (Session, error)
+	ScheduleUserDeletion(ctx context.Context, arg ScheduleUserDeletionParams) (User, error)
+	// Finds published posts matching a query in websearch_to_tsquery syntax, best
+	// matches first. Matches in the headlines are wrapped in U+E000 and U+E001,
+	// which the caller turns into markup after escaping the text. config must be
+	// the configuration the documents are built with.
+	SearchPosts(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error)
+	SetPostCommentsLocked(ctx context.Context, arg SetPostCommentsLockedParams) error
+	SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (int64, error)
+	TouchIdentity(ctx context.Context, arg TouchIdentityParams) error
+	UpdateComment(ctx context.Context, arg UpdateCommentParams) (Comment, error)
+	// A replaced slug is kept in post_slugs, so links to it keep working. The
+	// new version is stored as the next revision, saved by editor_id. When
+	// set_tags is true, the tags of the post are replaced in the same statement,
+	// creating the ones that do not exist yet. The no-op update makes ON
+	// CONFLICT return existing tags too, even one created by a concurrent
+	// request.
 	UpdatePost(ctx context.Context, arg UpdatePostParams) (Post, error)
+	// Leaves a post alone that was edited after it was listed; the edit rendered
+	// it already.
+	UpdatePostContentHTML(ctx context.Context, arg UpdatePostContentHTMLParams) error
+	// Sets the text search configuration of the post_search documents and, if it
+	// changed, rebuilds them. Returns the number of documents rebuilt.
+	UpdateSearchConfig(ctx context.Context, config string) (int64, error)
+	UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) (User, error)
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
+	UsePersonalAccessToken(ctx context.Context, tokenHash string) (UsePersonalAccessTokenRow, error)
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error)
+	UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
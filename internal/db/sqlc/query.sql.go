@@ -7,134 +7,2349 @@ package sqlc
 
 import (
 	"context"
+	"encoding/json"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addPostReaction = `-- name: AddPostReaction :exec
+INSERT INTO post_reactions (post_id, user_id, kind)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING
+`
+
+type AddPostReactionParams struct {
+	PostID int32  `json:"post_id"`
+	UserID int32  `json:"user_id"`
+	Kind   string `json:"kind"`
+}
+
+func (q *Queries) AddPostReaction(ctx context.Context, arg AddPostReactionParams) error {
+	_, err := q.db.Exec(ctx, addPostReaction, arg.PostID, arg.UserID, arg.Kind)
+	return err
+}
+
+const anonymizeDueUsers = `-- name: AnonymizeDueUsers :many
+WITH due AS (
+  SELECT id, username FROM users
+  WHERE deletion_scheduled_at <= NOW()
+  FOR UPDATE SKIP LOCKED
+), anonymized AS (
+  UPDATE users u
+  SET username = 'deleted-' || u.id, password_hash = '!', email = NULL, email_verified_at = NULL,
+    totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0, role = 'reader',
+    deletion_scheduled_at = NULL, deleted_at = NOW(), updated_at = NOW()
+  FROM due
+  WHERE u.id = due.id
+  RETURNING u.id, due.username
+), revoked_sessions AS (
+  UPDATE sessions SET is_revoked = true, revoked_at = NOW()
+  WHERE user_id IN (SELECT id FROM due) AND is_revoked = false
+), deleted_identities AS (
+  DELETE FROM identities WHERE user_id IN (SELECT id FROM due)
+), deleted_tokens AS (
+  DELETE FROM personal_access_tokens WHERE user_id IN (SELECT id FROM due)
+), deleted_recovery_codes AS (
+  DELETE FROM recovery_codes WHERE user_id IN (SELECT id FROM due)
+), deleted_challenges AS (
+  DELETE FROM mfa_challenges WHERE user_id IN (SELECT id FROM due)
+), deleted_verifications AS (
+  DELETE FROM email_verification_tokens WHERE user_id IN (SELECT id FROM due)
+), deleted_resets AS (
+  DELETE FROM password_reset_tokens WHERE user_id IN (SELECT id FROM due)
+)
+SELECT id, username FROM anonymized
+`
+
+type AnonymizeDueUsersRow struct {
+	ID       int32  `json:"id"`
+	Username string `json:"username"`
+}
+
+// Scrubs the profile and removes every credential in one statement, so an
+// anonymized account can never be signed in to again. Posts are kept.
+func (q *Queries) AnonymizeDueUsers(ctx context.Context) ([]AnonymizeDueUsersRow, error) {
+	rows, err := q.db.Query(ctx, anonymizeDueUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AnonymizeDueUsersRow{}
+	for rows.Next() {
+		var i AnonymizeDueUsersRow
+		if err := rows.Scan(&i.ID, &i.Username); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const attemptMFAChallenge = `-- name: AttemptMFAChallenge :one
+UPDATE mfa_challenges
+SET attempts = attempts + 1
+WHERE token_hash = $1
+  AND expires_at > NOW()
+  AND attempts < $2
+RETURNING id, user_id, token_hash, attempts, expires_at, created_at
+`
+
+type AttemptMFAChallengeParams struct {
+	TokenHash   string `json:"token_hash"`
+	MaxAttempts int32  `json:"max_attempts"`
+}
+
+func (q *Queries) AttemptMFAChallenge(ctx context.Context, arg AttemptMFAChallengeParams) (MfaChallenge, error) {
+	row := q.db.QueryRow(ctx, attemptMFAChallenge, arg.TokenHash, arg.MaxAttempts)
+	var i MfaChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.Attempts,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const cancelUserDeletion = `-- name: CancelUserDeletion :execrows
+UPDATE users
+SET deletion_scheduled_at = NULL, updated_at = NOW()
+WHERE id = $1 AND deletion_scheduled_at IS NOT NULL
+`
+
+func (q *Queries) CancelUserDeletion(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, cancelUserDeletion, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const clearLoginFailures = `-- name: ClearLoginFailures :exec
+DELETE FROM login_failures
+WHERE scope = $1 AND key = $2
+`
+
+type ClearLoginFailuresParams struct {
+	Scope string `json:"scope"`
+	Key   string `json:"key"`
+}
+
+func (q *Queries) ClearLoginFailures(ctx context.Context, arg ClearLoginFailuresParams) error {
+	_, err := q.db.Exec(ctx, clearLoginFailures, arg.Scope, arg.Key)
+	return err
+}
+
+const consumeEmailVerificationToken = `-- name: ConsumeEmailVerificationToken :one
+UPDATE email_verification_tokens
+SET used_at = NOW()
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > NOW()
+RETURNING id, user_id, email, token_hash, expires_at, used_at, created_at
+`
+
+func (q *Queries) ConsumeEmailVerificationToken(ctx context.Context, tokenHash string) (EmailVerificationToken, error) {
+	row := q.db.QueryRow(ctx, consumeEmailVerificationToken, tokenHash)
+	var i EmailVerificationToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Email,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const consumeOIDCAuthRequest = `-- name: ConsumeOIDCAuthRequest :one
+DELETE FROM oidc_auth_requests
+WHERE state_hash = $1 AND expires_at > NOW()
+RETURNING state_hash, provider, nonce, code_verifier, expires_at, created_at
+`
+
+func (q *Queries) ConsumeOIDCAuthRequest(ctx context.Context, stateHash string) (OidcAuthRequest, error) {
+	row := q.db.QueryRow(ctx, consumeOIDCAuthRequest, stateHash)
+	var i OidcAuthRequest
+	err := row.Scan(
+		&i.StateHash,
+		&i.Provider,
+		&i.Nonce,
+		&i.CodeVerifier,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const consumePasswordResetToken = `-- name: ConsumePasswordResetToken :one
+UPDATE password_reset_tokens
+SET used_at = NOW()
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > NOW()
+RETURNING id, user_id, token_hash, expires_at, used_at, created_at
+`
+
+func (q *Queries) ConsumePasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error) {
+	row := q.db.QueryRow(ctx, consumePasswordResetToken, tokenHash)
+	var i PasswordResetToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createAuditEvent = `-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (event_type, outcome, user_id, actor_id, username, ip, user_agent, details)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+`
+
+type CreateAuditEventParams struct {
+	EventType string      `json:"event_type"`
+	Outcome   string      `json:"outcome"`
+	UserID    pgtype.Int4 `json:"user_id"`
+	ActorID   pgtype.Int4 `json:"actor_id"`
+	Username  string      `json:"username"`
+	Ip        string      `json:"ip"`
+	UserAgent string      `json:"user_agent"`
+	Details   string      `json:"details"`
+}
+
+func (q *Queries) CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) error {
+	_, err := q.db.Exec(ctx, createAuditEvent,
+		arg.EventType,
+		arg.Outcome,
+		arg.UserID,
+		arg.ActorID,
+		arg.Username,
+		arg.Ip,
+		arg.UserAgent,
+		arg.Details,
+	)
+	return err
+}
+
+const createComment = `-- name: CreateComment :one
+INSERT INTO comments (post_id, parent_id, user_id, content)
+VALUES ($1, $2, $3, $4)
+RETURNING id, post_id, parent_id, user_id, content, created_at, updated_at, deleted_at
+`
+
+type CreateCommentParams struct {
+	PostID   int32       `json:"post_id"`
+	ParentID pgtype.Int4 `json:"parent_id"`
+	UserID   pgtype.Int4 `json:"user_id"`
+	Content  string      `json:"content"`
+}
+
+func (q *Queries) CreateComment(ctx context.Context, arg CreateCommentParams) (Comment, error) {
+	row := q.db.QueryRow(ctx, createComment,
+		arg.PostID,
+		arg.ParentID,
+		arg.UserID,
+		arg.Content,
+	)
+	var i Comment
+	err := row.Scan(
+		&i.ID,
+		&i.PostID,
+		&i.ParentID,
+		&i.UserID,
+		&i.Content,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const createEmailVerificationToken = `-- name: CreateEmailVerificationToken :one
+INSERT INTO email_verification_tokens (user_id, email, token_hash, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, email, token_hash, expires_at, used_at, created_at
+`
+
+type CreateEmailVerificationTokenParams struct {
+	UserID    int32              `json:"user_id"`
+	Email     string             `json:"email"`
+	TokenHash string             `json:"token_hash"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+}
+
+func (q *Queries) CreateEmailVerificationToken(ctx context.Context, arg CreateEmailVerificationTokenParams) (EmailVerificationToken, error) {
+	row := q.db.QueryRow(ctx, createEmailVerificationToken,
+		arg.UserID,
+		arg.Email,
+		arg.TokenHash,
+		arg.ExpiresAt,
+	)
+	var i EmailVerificationToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Email,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createIdentity = `-- name: CreateIdentity :one
+INSERT INTO identities (user_id, provider, subject, email)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, provider, subject, email, created_at, last_login_at
+`
+
+type CreateIdentityParams struct {
+	UserID   int32       `json:"user_id"`
+	Provider string      `json:"provider"`
+	Subject  string      `json:"subject"`
+	Email    pgtype.Text `json:"email"`
+}
+
+func (q *Queries) CreateIdentity(ctx context.Context, arg CreateIdentityParams) (Identity, error) {
+	row := q.db.QueryRow(ctx, createIdentity,
+		arg.UserID,
+		arg.Provider,
+		arg.Subject,
+		arg.Email,
+	)
+	var i Identity
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Provider,
+		&i.Subject,
+		&i.Email,
+		&i.CreatedAt,
+		&i.LastLoginAt,
+	)
+	return i, err
+}
+
+const createMFAChallenge = `-- name: CreateMFAChallenge :one
+INSERT INTO mfa_challenges (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING id, user_id, token_hash, attempts, expires_at, created_at
+`
+
+type CreateMFAChallengeParams struct {
+	UserID    int32              `json:"user_id"`
+	TokenHash string             `json:"token_hash"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+}
+
+func (q *Queries) CreateMFAChallenge(ctx context.Context, arg CreateMFAChallengeParams) (MfaChallenge, error) {
+	row := q.db.QueryRow(ctx, createMFAChallenge, arg.UserID, arg.TokenHash, arg.ExpiresAt)
+	var i MfaChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.Attempts,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createOIDCAuthRequest = `-- name: CreateOIDCAuthRequest :exec
+INSERT INTO oidc_auth_requests (state_hash, provider, nonce, code_verifier, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreateOIDCAuthRequestParams struct {
+	StateHash    string             `json:"state_hash"`
+	Provider     string             `json:"provider"`
+	Nonce        string             `json:"nonce"`
+	CodeVerifier string             `json:"code_verifier"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+}
+
+func (q *Queries) CreateOIDCAuthRequest(ctx context.Context, arg CreateOIDCAuthRequestParams) error {
+	_, err := q.db.Exec(ctx, createOIDCAuthRequest,
+		arg.StateHash,
+		arg.Provider,
+		arg.Nonce,
+		arg.CodeVerifier,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const createPasswordResetToken = `-- name: CreatePasswordResetToken :one
+INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING id, user_id, token_hash, expires_at, used_at, created_at
+`
+
+type CreatePasswordResetTokenParams struct {
+	UserID    int32              `json:"user_id"`
+	TokenHash string             `json:"token_hash"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+}
+
+func (q *Queries) CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error) {
+	row := q.db.QueryRow(ctx, createPasswordResetToken, arg.UserID, arg.TokenHash, arg.ExpiresAt)
+	var i PasswordResetToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createPersonalAccessToken = `-- name: CreatePersonalAccessToken :one
+INSERT INTO personal_access_tokens (user_id, name, token_hash, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_id, name, token_hash, scopes, expires_at, last_used_at, revoked_at, created_at
+`
+
+type CreatePersonalAccessTokenParams struct {
+	UserID    int32              `json:"user_id"`
+	Name      string             `json:"name"`
+	TokenHash string             `json:"token_hash"`
+	Scopes    []string           `json:"scopes"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+}
+
+func (q *Queries) CreatePersonalAccessToken(ctx context.Context, arg CreatePersonalAccessTokenParams) (PersonalAccessToken, error) {
+	row := q.db.QueryRow(ctx, createPersonalAccessToken,
+		arg.UserID,
+		arg.Name,
+		arg.TokenHash,
+		arg.Scopes,
+		arg.ExpiresAt,
+	)
+	var i PersonalAccessToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.TokenHash,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const createPost = `-- name: CreatePost :one
-INSERT INTO posts (user_id, title, content)
+WITH created AS (
+  INSERT INTO posts (user_id, title, content, status, published_at, slug, format, content_html, rendered_with)
+  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+  RETURNING id, user_id, title, content, created_at, updated_at, status, published_at, slug, format, content_html, rendered_with, revision, comments_locked
+), history AS (
+  INSERT INTO post_revisions (post_id, revision, user_id, title, content, format)
+  SELECT id, revision, user_id, title, content, format FROM created
+), input AS (
+  SELECT unnest($10::text[]) AS slug, unnest($11::text[]) AS name
+), wanted AS (
+  INSERT INTO tags (slug, name)
+  SELECT slug, name FROM input
+  ON CONFLICT (slug) DO UPDATE SET name = tags.name
+  RETURNING id
+), tagged AS (
+  INSERT INTO post_tags (post_id, tag_id)
+  SELECT created.id, wanted.id FROM created, wanted
+)
+SELECT id, user_id, title, content, created_at, updated_at, status, published_at, slug, format, content_html, rendered_with, revision, comments_locked FROM created
+`
+
+type CreatePostParams struct {
+	UserID       int32              `json:"user_id"`
+	Title        string             `json:"title"`
+	Content      string             `json:"content"`
+	Status       string             `json:"status"`
+	PublishedAt  pgtype.Timestamptz `json:"published_at"`
+	Slug         string             `json:"slug"`
+	Format       string             `json:"format"`
+	ContentHtml  pgtype.Text        `json:"content_html"`
+	RenderedWith pgtype.Text        `json:"rendered_with"`
+	TagSlugs     []string           `json:"tag_slugs"`
+	TagNames     []string           `json:"tag_names"`
+}
+
+// The post starts its history as revision 1. Its tags are created in the
+// same statement, so a post is never saved without them; see UpdatePost.
+func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
+	row := q.db.QueryRow(ctx, createPost,
+		arg.UserID,
+		arg.Title,
+		arg.Content,
+		arg.Status,
+		arg.PublishedAt,
+		arg.Slug,
+		arg.Format,
+		arg.ContentHtml,
+		arg.RenderedWith,
+		arg.TagSlugs,
+		arg.TagNames,
+	)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.Content,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.PublishedAt,
+		&i.Slug,
+		&i.Format,
+		&i.ContentHtml,
+		&i.RenderedWith,
+		&i.Revision,
+		&i.CommentsLocked,
+	)
+	return i, err
+}
+
+const createRecoveryCodes = `-- name: CreateRecoveryCodes :exec
+INSERT INTO recovery_codes (user_id, code_hash)
+SELECT $1::int, unnest($2::text[])
+`
+
+type CreateRecoveryCodesParams struct {
+	UserID     int32    `json:"user_id"`
+	CodeHashes []string `json:"code_hashes"`
+}
+
+func (q *Queries) CreateRecoveryCodes(ctx context.Context, arg CreateRecoveryCodesParams) error {
+	_, err := q.db.Exec(ctx, createRecoveryCodes, arg.UserID, arg.CodeHashes)
+	return err
+}
+
+const createSession = `-- name: CreateSession :one
+INSERT INTO sessions (id, user_id, refresh_token_hash, expires_at, user_agent, client_ip)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, refresh_token_hash, is_revoked, expires_at, created_at, user_agent, client_ip, last_seen_at, revoked_at
+`
+
+type CreateSessionParams struct {
+	ID               uuid.UUID          `json:"id"`
+	UserID           int32              `json:"user_id"`
+	RefreshTokenHash string             `json:"refresh_token_hash"`
+	ExpiresAt        pgtype.Timestamptz `json:"expires_at"`
+	UserAgent        string             `json:"user_agent"`
+	ClientIp         string             `json:"client_ip"`
+}
+
+func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
+	row := q.db.QueryRow(ctx, createSession,
+		arg.ID,
+		arg.UserID,
+		arg.RefreshTokenHash,
+		arg.ExpiresAt,
+		arg.UserAgent,
+		arg.ClientIp,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.RefreshTokenHash,
+		&i.IsRevoked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UserAgent,
+		&i.ClientIp,
+		&i.LastSeenAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const createUser = `-- name: CreateUser :one
+
+INSERT INTO users (username, password_hash, email)
 VALUES ($1, $2, $3)
-RETURNING id, user_id, title, content, created_at, updated_at
+RETURNING id, username, password_hash, created_at, updated_at, role, email, email_verified_at, totp_secret, totp_enabled_at, totp_last_step, deletion_scheduled_at, deleted_at
+`
+
+type CreateUserParams struct {
+	Username     string      `json:"username"`
+	PasswordHash string      `json:"password_hash"`
+	Email        pgtype.Text `json:"email"`
+}
+
+// internal/db/query.sql
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, createUser, arg.Username, arg.PasswordHash, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.Email,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.DeletionScheduledAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const deleteComment = `-- name: DeleteComment :exec
+WITH removed AS (
+  DELETE FROM comments
+  WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM comments r WHERE r.parent_id = $1)
+  RETURNING id
+)
+UPDATE comments
+SET content = '', deleted_at = NOW()
+WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM removed)
+`
+
+// Removes a comment without replies. A comment with replies is emptied and
+// marked deleted instead, so that its replies keep their place.
+func (q *Queries) DeleteComment(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, deleteComment, id)
+	return err
+}
+
+const deleteDueUsers = `-- name: DeleteDueUsers :many
+WITH due AS (
+  DELETE FROM users u
+  WHERE u.deletion_scheduled_at <= NOW() AND NOT EXISTS (
+    SELECT 1 FROM sessions s
+    WHERE s.user_id = u.id AND (s.is_revoked = false OR s.revoked_at > $1)
+  )
+  RETURNING u.id, u.username
+), emptied_comments AS (
+  UPDATE comments SET content = '', deleted_at = NOW()
+  WHERE user_id IN (SELECT id FROM due) AND deleted_at IS NULL
+)
+SELECT id, username FROM due
+`
+
+type DeleteDueUsersRow struct {
+	ID       int32  `json:"id"`
+	Username string `json:"username"`
+}
+
+// Posts go with the account. Comments are emptied like deleted ones instead,
+// so that replies to them by others are kept. An account is only deleted once
+// all its sessions were revoked before revoked_before: deleting it also
+// deletes the sessions, after which no instance could learn that their
+// access tokens are revoked.
+func (q *Queries) DeleteDueUsers(ctx context.Context, revokedBefore pgtype.Timestamptz) ([]DeleteDueUsersRow, error) {
+	rows, err := q.db.Query(ctx, deleteDueUsers, revokedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []DeleteDueUsersRow{}
+	for rows.Next() {
+		var i DeleteDueUsersRow
+		if err := rows.Scan(&i.ID, &i.Username); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteExpiredMFAChallenges = `-- name: DeleteExpiredMFAChallenges :execrows
+DELETE FROM mfa_challenges
+WHERE expires_at <= NOW()
+`
+
+func (q *Queries) DeleteExpiredMFAChallenges(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredMFAChallenges)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteExpiredOIDCAuthRequests = `-- name: DeleteExpiredOIDCAuthRequests :execrows
+DELETE FROM oidc_auth_requests
+WHERE expires_at <= NOW()
+`
+
+func (q *Queries) DeleteExpiredOIDCAuthRequests(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredOIDCAuthRequests)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteExpiredRevokedTokens = `-- name: DeleteExpiredRevokedTokens :execrows
+DELETE FROM revoked_tokens
+WHERE expires_at <= NOW()
+`
+
+func (q *Queries) DeleteExpiredRevokedTokens(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredRevokedTokens)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteMFAChallenge = `-- name: DeleteMFAChallenge :execrows
+DELETE FROM mfa_challenges
+WHERE id = $1
+`
+
+func (q *Queries) DeleteMFAChallenge(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteMFAChallenge, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deletePost = `-- name: DeletePost :execrows
+DELETE FROM posts
+WHERE id = $1 AND (user_id = $2 OR $3::bool)
+`
+
+type DeletePostParams struct {
+	ID       int32 `json:"id"`
+	UserID   int32 `json:"user_id"`
+	AnyOwner bool  `json:"any_owner"`
+}
+
+// Only deletes the post of another user when any_owner is set.
+func (q *Queries) DeletePost(ctx context.Context, arg DeletePostParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePost, arg.ID, arg.UserID, arg.AnyOwner)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteRecoveryCodes = `-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteRecoveryCodes(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, deleteRecoveryCodes, userID)
+	return err
+}
+
+const deleteStaleLoginFailures = `-- name: DeleteStaleLoginFailures :execrows
+DELETE FROM login_failures
+WHERE last_failure_at < $1
+  AND (locked_until IS NULL OR locked_until < NOW())
+`
+
+func (q *Queries) DeleteStaleLoginFailures(ctx context.Context, lastFailureAt pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteStaleLoginFailures, lastFailureAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const disableUserTOTP = `-- name: DisableUserTOTP :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0, updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) DisableUserTOTP(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, disableUserTOTP, id)
+	return err
+}
+
+const enableUserTOTP = `-- name: EnableUserTOTP :execrows
+UPDATE users
+SET totp_enabled_at = NOW(), totp_last_step = $2, updated_at = NOW()
+WHERE id = $1 AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL
+`
+
+type EnableUserTOTPParams struct {
+	ID           int32 `json:"id"`
+	TotpLastStep int64 `json:"totp_last_step"`
+}
+
+func (q *Queries) EnableUserTOTP(ctx context.Context, arg EnableUserTOTPParams) (int64, error) {
+	result, err := q.db.Exec(ctx, enableUserTOTP, arg.ID, arg.TotpLastStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getComment = `-- name: GetComment :one
+SELECT id, post_id, parent_id, user_id, content, created_at, updated_at, deleted_at FROM comments
+WHERE id = $1
+`
+
+func (q *Queries) GetComment(ctx context.Context, id int32) (Comment, error) {
+	row := q.db.QueryRow(ctx, getComment, id)
+	var i Comment
+	err := row.Scan(
+		&i.ID,
+		&i.PostID,
+		&i.ParentID,
+		&i.UserID,
+		&i.Content,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getIdentity = `-- name: GetIdentity :one
+SELECT id, user_id, provider, subject, email, created_at, last_login_at FROM identities
+WHERE provider = $1 AND subject = $2 LIMIT 1
+`
+
+type GetIdentityParams struct {
+	Provider string `json:"provider"`
+	Subject  string `json:"subject"`
+}
+
+func (q *Queries) GetIdentity(ctx context.Context, arg GetIdentityParams) (Identity, error) {
+	row := q.db.QueryRow(ctx, getIdentity, arg.Provider, arg.Subject)
+	var i Identity
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Provider,
+		&i.Subject,
+		&i.Email,
+		&i.CreatedAt,
+		&i.LastLoginAt,
+	)
+	return i, err
+}
+
+const getLoginLockouts = `-- name: GetLoginLockouts :many
+SELECT scope, locked_until FROM login_failures
+WHERE ((scope = 'username' AND key = $1) OR (scope = 'ip' AND key = $2))
+  AND locked_until > NOW()
+`
+
+type GetLoginLockoutsParams struct {
+	Username string `json:"username"`
+	Ip       string `json:"ip"`
+}
+
+type GetLoginLockoutsRow struct {
+	Scope       string             `json:"scope"`
+	LockedUntil pgtype.Timestamptz `json:"locked_until"`
+}
+
+func (q *Queries) GetLoginLockouts(ctx context.Context, arg GetLoginLockoutsParams) ([]GetLoginLockoutsRow, error) {
+	rows, err := q.db.Query(ctx, getLoginLockouts, arg.Username, arg.Ip)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetLoginLockoutsRow{}
+	for rows.Next() {
+		var i GetLoginLockoutsRow
+		if err := rows.Scan(&i.Scope, &i.LockedUntil); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostByID = `-- name: GetPostByID :one
+SELECT p.id, p.user_id, p.title, p.content, p.created_at, p.updated_at, p.status, p.published_at, p.slug, p.format, p.content_html, p.rendered_with, p.revision, p.comments_locked, u.username as author_username,
+  ARRAY(
+    SELECT t.slug FROM post_tags pt JOIN tags t ON pt.tag_id = t.id
+    WHERE pt.post_id = p.id ORDER BY t.slug
+  )::text[] AS tags,
+  (
+    SELECT COALESCE(jsonb_object_agg(r.kind, r.count), '{}')
+    FROM (SELECT kind, COUNT(*) AS count FROM post_reactions WHERE post_id = p.id GROUP BY kind) r
+  )::jsonb AS reactions
+FROM posts p
+JOIN users u ON p.user_id = u.id
+WHERE p.id = $1 LIMIT 1
+`
+
+type GetPostByIDRow struct {
+	ID             int32              `json:"id"`
+	UserID         int32              `json:"user_id"`
+	Title          string             `json:"title"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	Status         string             `json:"status"`
+	PublishedAt    pgtype.Timestamptz `json:"published_at"`
+	Slug           string             `json:"slug"`
+	Format         string             `json:"format"`
+	ContentHtml    pgtype.Text        `json:"content_html"`
+	RenderedWith   pgtype.Text        `json:"rendered_with"`
+	Revision       int32              `json:"revision"`
+	CommentsLocked bool               `json:"comments_locked"`
+	AuthorUsername string             `json:"author_username"`
+	Tags           []string           `json:"tags"`
+	Reactions      json.RawMessage    `json:"reactions"`
+}
+
+func (q *Queries) GetPostByID(ctx context.Context, id int32) (GetPostByIDRow, error) {
+	row := q.db.QueryRow(ctx, getPostByID, id)
+	var i GetPostByIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.Content,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.PublishedAt,
+		&i.Slug,
+		&i.Format,
+		&i.ContentHtml,
+		&i.RenderedWith,
+		&i.Revision,
+		&i.CommentsLocked,
+		&i.AuthorUsername,
+		&i.Tags,
+		&i.Reactions,
+	)
+	return i, err
+}
+
+const getPostBySlug = `-- name: GetPostBySlug :one
+SELECT p.id, p.user_id, p.title, p.content, p.created_at, p.updated_at, p.status, p.published_at, p.slug, p.format, p.content_html, p.rendered_with, p.revision, p.comments_locked, u.username as author_username,
+  ARRAY(
+    SELECT t.slug FROM post_tags pt JOIN tags t ON pt.tag_id = t.id
+    WHERE pt.post_id = p.id ORDER BY t.slug
+  )::text[] AS tags,
+  (
+    SELECT COALESCE(jsonb_object_agg(r.kind, r.count), '{}')
+    FROM (SELECT kind, COUNT(*) AS count FROM post_reactions WHERE post_id = p.id GROUP BY kind) r
+  )::jsonb AS reactions
+FROM posts p
+JOIN users u ON p.user_id = u.id
+WHERE p.slug = $1 LIMIT 1
+`
+
+type GetPostBySlugRow struct {
+	ID             int32              `json:"id"`
+	UserID         int32              `json:"user_id"`
+	Title          string             `json:"title"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	Status         string             `json:"status"`
+	PublishedAt    pgtype.Timestamptz `json:"published_at"`
+	Slug           string             `json:"slug"`
+	Format         string             `json:"format"`
+	ContentHtml    pgtype.Text        `json:"content_html"`
+	RenderedWith   pgtype.Text        `json:"rendered_with"`
+	Revision       int32              `json:"revision"`
+	CommentsLocked bool               `json:"comments_locked"`
+	AuthorUsername string             `json:"author_username"`
+	Tags           []string           `json:"tags"`
+	Reactions      json.RawMessage    `json:"reactions"`
+}
+
+func (q *Queries) GetPostBySlug(ctx context.Context, slug string) (GetPostBySlugRow, error) {
+	row := q.db.QueryRow(ctx, getPostBySlug, slug)
+	var i GetPostBySlugRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.Content,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.PublishedAt,
+		&i.Slug,
+		&i.Format,
+		&i.ContentHtml,
+		&i.RenderedWith,
+		&i.Revision,
+		&i.CommentsLocked,
+		&i.AuthorUsername,
+		&i.Tags,
+		&i.Reactions,
+	)
+	return i, err
+}
+
+const getPostReactions = `-- name: GetPostReactions :one
+SELECT
+  (
+    SELECT COALESCE(jsonb_object_agg(r.kind, r.count), '{}')
+    FROM (SELECT kind, COUNT(*) AS count FROM post_reactions WHERE post_id = $1 GROUP BY kind) r
+  )::jsonb AS reactions,
+  ARRAY(
+    SELECT kind FROM post_reactions
+    WHERE post_id = $1 AND user_id = $2 ORDER BY kind
+  )::text[] AS mine
+`
+
+type GetPostReactionsParams struct {
+	PostID int32 `json:"post_id"`
+	UserID int32 `json:"user_id"`
+}
+
+type GetPostReactionsRow struct {
+	Reactions json.RawMessage `json:"reactions"`
+	Mine      []string        `json:"mine"`
+}
+
+// Returns the number of reactions of each kind to a post, and the kinds the
+// user reacted with.
+func (q *Queries) GetPostReactions(ctx context.Context, arg GetPostReactionsParams) (GetPostReactionsRow, error) {
+	row := q.db.QueryRow(ctx, getPostReactions, arg.PostID, arg.UserID)
+	var i GetPostReactionsRow
+	err := row.Scan(&i.Reactions, &i.Mine)
+	return i, err
+}
+
+const getPostRevision = `-- name: GetPostRevision :one
+SELECT r.post_id, r.revision, r.user_id, r.title, r.content, r.format, r.created_at, u.username AS author_username
+FROM post_revisions r
+LEFT JOIN users u ON r.user_id = u.id
+WHERE r.post_id = $1 AND r.revision = $2
+`
+
+type GetPostRevisionParams struct {
+	PostID   int32 `json:"post_id"`
+	Revision int32 `json:"revision"`
+}
+
+type GetPostRevisionRow struct {
+	PostID         int32              `json:"post_id"`
+	Revision       int32              `json:"revision"`
+	UserID         pgtype.Int4        `json:"user_id"`
+	Title          string             `json:"title"`
+	Content        string             `json:"content"`
+	Format         string             `json:"format"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	AuthorUsername pgtype.Text        `json:"author_username"`
+}
+
+func (q *Queries) GetPostRevision(ctx context.Context, arg GetPostRevisionParams) (GetPostRevisionRow, error) {
+	row := q.db.QueryRow(ctx, getPostRevision, arg.PostID, arg.Revision)
+	var i GetPostRevisionRow
+	err := row.Scan(
+		&i.PostID,
+		&i.Revision,
+		&i.UserID,
+		&i.Title,
+		&i.Content,
+		&i.Format,
+		&i.CreatedAt,
+		&i.AuthorUsername,
+	)
+	return i, err
+}
+
+const getRenamedPostSlug = `-- name: GetRenamedPostSlug :one
+SELECT p.slug
+FROM post_slugs s
+JOIN posts p ON s.post_id = p.id
+WHERE s.slug = $1 AND p.status = 'published'
+`
+
+// Returns the current slug of the published post that used to have slug.
+func (q *Queries) GetRenamedPostSlug(ctx context.Context, slug string) (string, error) {
+	row := q.db.QueryRow(ctx, getRenamedPostSlug, slug)
+	err := row.Scan(&slug)
+	return slug, err
+}
+
+const getSession = `-- name: GetSession :one
+SELECT id, user_id, refresh_token_hash, is_revoked, expires_at, created_at, user_agent, client_ip, last_seen_at, revoked_at FROM sessions
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetSession(ctx context.Context, id uuid.UUID) (Session, error) {
+	row := q.db.QueryRow(ctx, getSession, id)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.RefreshTokenHash,
+		&i.IsRevoked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UserAgent,
+		&i.ClientIp,
+		&i.LastSeenAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, username, password_hash, created_at, updated_at, role, email, email_verified_at, totp_secret, totp_enabled_at, totp_last_step, deletion_scheduled_at, deleted_at FROM users
+WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email pgtype.Text) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.Email,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.DeletionScheduledAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, username, password_hash, created_at, updated_at, role, email, email_verified_at, totp_secret, totp_enabled_at, totp_last_step, deletion_scheduled_at, deleted_at FROM users
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id int32) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.Email,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.DeletionScheduledAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getUserByUsername = `-- name: GetUserByUsername :one
+SELECT id, username, password_hash, created_at, updated_at, role, email, email_verified_at, totp_secret, totp_enabled_at, totp_last_step, deletion_scheduled_at, deleted_at FROM users
+WHERE username = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByUsername, username)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.Email,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.DeletionScheduledAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const invalidatePasswordResetTokens = `-- name: InvalidatePasswordResetTokens :exec
+UPDATE password_reset_tokens
+SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) InvalidatePasswordResetTokens(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, invalidatePasswordResetTokens, userID)
+	return err
+}
+
+const listAuditEvents = `-- name: ListAuditEvents :many
+SELECT id, event_type, outcome, user_id, actor_id, username, ip, user_agent, details, created_at FROM audit_events
+WHERE ($1::int IS NULL OR user_id = $1 OR actor_id = $1)
+  AND ($2::text IS NULL OR event_type = $2)
+  AND ($3::timestamptz IS NULL OR created_at >= $3)
+  AND ($4::timestamptz IS NULL OR created_at < $4)
+ORDER BY created_at DESC, id DESC
+LIMIT $5 OFFSET $6
+`
+
+type ListAuditEventsParams struct {
+	UserID    pgtype.Int4        `json:"user_id"`
+	EventType pgtype.Text        `json:"event_type"`
+	Since     pgtype.Timestamptz `json:"since"`
+	Until     pgtype.Timestamptz `json:"until"`
+	Limit     int32              `json:"limit"`
+	Offset    int32              `json:"offset"`
+}
+
+func (q *Queries) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	rows, err := q.db.Query(ctx, listAuditEvents,
+		arg.UserID,
+		arg.EventType,
+		arg.Since,
+		arg.Until,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditEvent{}
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventType,
+			&i.Outcome,
+			&i.UserID,
+			&i.ActorID,
+			&i.Username,
+			&i.Ip,
+			&i.UserAgent,
+			&i.Details,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listComments = `-- name: ListComments :many
+WITH RECURSIVE thread AS (
+  (
+    SELECT id, parent_id, user_id, content, created_at, updated_at, deleted_at, 0 AS depth, ARRAY[id] AS path
+    FROM comments
+    WHERE post_id = $1 AND parent_id IS NULL
+    ORDER BY id
+    LIMIT $2 OFFSET $3
+  )
+  UNION ALL
+  SELECT c.id, c.parent_id, c.user_id, c.content, c.created_at, c.updated_at, c.deleted_at, t.depth + 1, t.path || c.id
+  FROM comments c
+  JOIN thread t ON c.parent_id = t.id
+)
+SELECT t.id, t.parent_id, CASE WHEN t.deleted_at IS NULL THEN t.user_id END AS user_id,
+  u.username AS author_username, t.content, t.created_at, t.updated_at, t.deleted_at, t.depth::int AS depth
+FROM thread t
+LEFT JOIN users u ON t.user_id = u.id AND t.deleted_at IS NULL
+ORDER BY t.path
+`
+
+type ListCommentsParams struct {
+	PostID int32 `json:"post_id"`
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+type ListCommentsRow struct {
+	ID             int32              `json:"id"`
+	ParentID       pgtype.Int4        `json:"parent_id"`
+	UserID         pgtype.Int4        `json:"user_id"`
+	AuthorUsername pgtype.Text        `json:"author_username"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt      pgtype.Timestamptz `json:"deleted_at"`
+	Depth          int32              `json:"depth"`
+}
+
+// Returns a page of the top-level comments of a post, oldest first, each
+// followed by all its replies in thread order with their depth. Deleted
+// comments keep their place without their author.
+func (q *Queries) ListComments(ctx context.Context, arg ListCommentsParams) ([]ListCommentsRow, error) {
+	rows, err := q.db.Query(ctx, listComments, arg.PostID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListCommentsRow{}
+	for rows.Next() {
+		var i ListCommentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ParentID,
+			&i.UserID,
+			&i.AuthorUsername,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Depth,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPersonalAccessTokens = `-- name: ListPersonalAccessTokens :many
+SELECT id, user_id, name, token_hash, scopes, expires_at, last_used_at, revoked_at, created_at FROM personal_access_tokens
+WHERE user_id = $1 AND revoked_at IS NULL
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListPersonalAccessTokens(ctx context.Context, userID int32) ([]PersonalAccessToken, error) {
+	rows, err := q.db.Query(ctx, listPersonalAccessTokens, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PersonalAccessToken{}
+	for rows.Next() {
+		var i PersonalAccessToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.TokenHash,
+			&i.Scopes,
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostRevisions = `-- name: ListPostRevisions :many
+SELECT r.revision, r.user_id, u.username AS author_username, r.title, r.created_at
+FROM post_revisions r
+LEFT JOIN users u ON r.user_id = u.id
+WHERE r.post_id = $1
+ORDER BY r.revision DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListPostRevisionsParams struct {
+	PostID int32 `json:"post_id"`
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+type ListPostRevisionsRow struct {
+	Revision       int32              `json:"revision"`
+	UserID         pgtype.Int4        `json:"user_id"`
+	AuthorUsername pgtype.Text        `json:"author_username"`
+	Title          string             `json:"title"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+}
+
+func (q *Queries) ListPostRevisions(ctx context.Context, arg ListPostRevisionsParams) ([]ListPostRevisionsRow, error) {
+	rows, err := q.db.Query(ctx, listPostRevisions, arg.PostID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListPostRevisionsRow{}
+	for rows.Next() {
+		var i ListPostRevisionsRow
+		if err := rows.Scan(
+			&i.Revision,
+			&i.UserID,
+			&i.AuthorUsername,
+			&i.Title,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPosts = `-- name: ListPosts :many
+SELECT p.id, p.user_id, p.title, p.content, p.created_at, p.updated_at, p.status, p.published_at, p.slug, p.format, p.content_html, p.rendered_with, p.revision, p.comments_locked, u.username as author_username,
+  ARRAY(
+    SELECT t.slug FROM post_tags pt JOIN tags t ON pt.tag_id = t.id
+    WHERE pt.post_id = p.id ORDER BY t.slug
+  )::text[] AS tags,
+  (SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.deleted_at IS NULL) AS comment_count,
+  (
+    SELECT COALESCE(jsonb_object_agg(r.kind, r.count), '{}')
+    FROM (SELECT kind, COUNT(*) AS count FROM post_reactions WHERE post_id = p.id GROUP BY kind) r
+  )::jsonb AS reactions
+FROM posts p
+JOIN users u ON p.user_id = u.id
+WHERE p.status = 'published'
+  AND (cardinality($3::text[]) = 0 OR (
+    SELECT COUNT(*) FROM post_tags pt JOIN tags t ON pt.tag_id = t.id
+    WHERE pt.post_id = p.id AND t.slug = ANY($3::text[])
+  ) >= CASE WHEN $4::bool THEN cardinality($3::text[]) ELSE 1 END)
+ORDER BY p.published_at DESC
+LIMIT $1 OFFSET $2
+`
+
+type ListPostsParams struct {
+	Limit    int32    `json:"limit"`
+	Offset   int32    `json:"offset"`
+	Tags     []string `json:"tags"`
+	MatchAll bool     `json:"match_all"`
+}
+
+type ListPostsRow struct {
+	ID             int32              `json:"id"`
+	UserID         int32              `json:"user_id"`
+	Title          string             `json:"title"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	Status         string             `json:"status"`
+	PublishedAt    pgtype.Timestamptz `json:"published_at"`
+	Slug           string             `json:"slug"`
+	Format         string             `json:"format"`
+	ContentHtml    pgtype.Text        `json:"content_html"`
+	RenderedWith   pgtype.Text        `json:"rendered_with"`
+	Revision       int32              `json:"revision"`
+	CommentsLocked bool               `json:"comments_locked"`
+	AuthorUsername string             `json:"author_username"`
+	Tags           []string           `json:"tags"`
+	CommentCount   int64              `json:"comment_count"`
+	Reactions      json.RawMessage    `json:"reactions"`
+}
+
+func (q *Queries) ListPosts(ctx context.Context, arg ListPostsParams) ([]ListPostsRow, error) {
+	rows, err := q.db.Query(ctx, listPosts,
+		arg.Limit,
+		arg.Offset,
+		arg.Tags,
+		arg.MatchAll,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListPostsRow{}
+	for rows.Next() {
+		var i ListPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.PublishedAt,
+			&i.Slug,
+			&i.Format,
+			&i.ContentHtml,
+			&i.RenderedWith,
+			&i.Revision,
+			&i.CommentsLocked,
+			&i.AuthorUsername,
+			&i.Tags,
+			&i.CommentCount,
+			&i.Reactions,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostsByAuthor = `-- name: ListPostsByAuthor :many
+SELECT p.id, p.user_id, p.title, p.content, p.created_at, p.updated_at, p.status, p.published_at, p.slug, p.format, p.content_html, p.rendered_with, p.revision, p.comments_locked, u.username as author_username,
+  ARRAY(
+    SELECT t.slug FROM post_tags pt JOIN tags t ON pt.tag_id = t.id
+    WHERE pt.post_id = p.id ORDER BY t.slug
+  )::text[] AS tags,
+  (
+    SELECT COALESCE(jsonb_object_agg(r.kind, r.count), '{}')
+    FROM (SELECT kind, COUNT(*) AS count FROM post_reactions WHERE post_id = p.id GROUP BY kind) r
+  )::jsonb AS reactions
+FROM posts p
+JOIN users u ON p.user_id = u.id
+WHERE p.user_id = $1
+  AND ($2::text IS NULL OR p.status = $2)
+ORDER BY p.updated_at DESC
+LIMIT $3 OFFSET $4
+`
+
+type ListPostsByAuthorParams struct {
+	UserID int32       `json:"user_id"`
+	Status pgtype.Text `json:"status"`
+	Limit  int32       `json:"limit"`
+	Offset int32       `json:"offset"`
+}
+
+type ListPostsByAuthorRow struct {
+	ID             int32              `json:"id"`
+	UserID         int32              `json:"user_id"`
+	Title          string             `json:"title"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	Status         string             `json:"status"`
+	PublishedAt    pgtype.Timestamptz `json:"published_at"`
+	Slug           string             `json:"slug"`
+	Format         string             `json:"format"`
+	ContentHtml    pgtype.Text        `json:"content_html"`
+	RenderedWith   pgtype.Text        `json:"rendered_with"`
+	Revision       int32              `json:"revision"`
+	CommentsLocked bool               `json:"comments_locked"`
+	AuthorUsername string             `json:"author_username"`
+	Tags           []string           `json:"tags"`
+	Reactions      json.RawMessage    `json:"reactions"`
+}
+
+func (q *Queries) ListPostsByAuthor(ctx context.Context, arg ListPostsByAuthorParams) ([]ListPostsByAuthorRow, error) {
+	rows, err := q.db.Query(ctx, listPostsByAuthor,
+		arg.UserID,
+		arg.Status,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListPostsByAuthorRow{}
+	for rows.Next() {
+		var i ListPostsByAuthorRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.PublishedAt,
+			&i.Slug,
+			&i.Format,
+			&i.ContentHtml,
+			&i.RenderedWith,
+			&i.Revision,
+			&i.CommentsLocked,
+			&i.AuthorUsername,
+			&i.Tags,
+			&i.Reactions,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostsToRender = `-- name: ListPostsToRender :many
+SELECT id, format, content, updated_at FROM posts
+WHERE rendered_with IS DISTINCT FROM $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListPostsToRenderParams struct {
+	RenderedWith pgtype.Text `json:"rendered_with"`
+	AfterID      int32       `json:"after_id"`
+	Limit        int32       `json:"limit"`
+}
+
+type ListPostsToRenderRow struct {
+	ID        int32              `json:"id"`
+	Format    string             `json:"format"`
+	Content   string             `json:"content"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+// Returns posts whose content_html was made with other renderer settings, or
+// not at all, in ID order from after_id.
+func (q *Queries) ListPostsToRender(ctx context.Context, arg ListPostsToRenderParams) ([]ListPostsToRenderRow, error) {
+	rows, err := q.db.Query(ctx, listPostsToRender, arg.RenderedWith, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListPostsToRenderRow{}
+	for rows.Next() {
+		var i ListPostsToRenderRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Format,
+			&i.Content,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReactedPosts = `-- name: ListReactedPosts :many
+SELECT p.id, p.user_id, p.title, p.content, p.created_at, p.updated_at, p.status, p.published_at, p.slug, p.format, p.content_html, p.rendered_with, p.revision, p.comments_locked, u.username as author_username,
+  ARRAY(
+    SELECT t.slug FROM post_tags pt JOIN tags t ON pt.tag_id = t.id
+    WHERE pt.post_id = p.id ORDER BY t.slug
+  )::text[] AS tags,
+  (
+    SELECT COALESCE(jsonb_object_agg(r.kind, r.count), '{}')
+    FROM (SELECT kind, COUNT(*) AS count FROM post_reactions WHERE post_id = p.id GROUP BY kind) r
+  )::jsonb AS reactions,
+  pr.created_at AS reacted_at
+FROM post_reactions pr
+JOIN posts p ON pr.post_id = p.id
+JOIN users u ON p.user_id = u.id
+WHERE pr.user_id = $1 AND pr.kind = $2 AND p.status = 'published'
+ORDER BY pr.created_at DESC
+LIMIT $3 OFFSET $4
+`
+
+type ListReactedPostsParams struct {
+	UserID int32  `json:"user_id"`
+	Kind   string `json:"kind"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
+}
+
+type ListReactedPostsRow struct {
+	ID             int32              `json:"id"`
+	UserID         int32              `json:"user_id"`
+	Title          string             `json:"title"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	Status         string             `json:"status"`
+	PublishedAt    pgtype.Timestamptz `json:"published_at"`
+	Slug           string             `json:"slug"`
+	Format         string             `json:"format"`
+	ContentHtml    pgtype.Text        `json:"content_html"`
+	RenderedWith   pgtype.Text        `json:"rendered_with"`
+	Revision       int32              `json:"revision"`
+	CommentsLocked bool               `json:"comments_locked"`
+	AuthorUsername string             `json:"author_username"`
+	Tags           []string           `json:"tags"`
+	Reactions      json.RawMessage    `json:"reactions"`
+	ReactedAt      pgtype.Timestamptz `json:"reacted_at"`
+}
+
+// Returns the published posts a user reacted to with a kind, most recent
+// reaction first.
+func (q *Queries) ListReactedPosts(ctx context.Context, arg ListReactedPostsParams) ([]ListReactedPostsRow, error) {
+	rows, err := q.db.Query(ctx, listReactedPosts,
+		arg.UserID,
+		arg.Kind,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListReactedPostsRow{}
+	for rows.Next() {
+		var i ListReactedPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.PublishedAt,
+			&i.Slug,
+			&i.Format,
+			&i.ContentHtml,
+			&i.RenderedWith,
+			&i.Revision,
+			&i.CommentsLocked,
+			&i.AuthorUsername,
+			&i.Tags,
+			&i.Reactions,
+			&i.ReactedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRevokedSessions = `-- name: ListRevokedSessions :many
+SELECT id, revoked_at FROM sessions
+WHERE revoked_at > $1
+`
+
+type ListRevokedSessionsRow struct {
+	ID        uuid.UUID          `json:"id"`
+	RevokedAt pgtype.Timestamptz `json:"revoked_at"`
+}
+
+func (q *Queries) ListRevokedSessions(ctx context.Context, revokedAt pgtype.Timestamptz) ([]ListRevokedSessionsRow, error) {
+	rows, err := q.db.Query(ctx, listRevokedSessions, revokedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListRevokedSessionsRow{}
+	for rows.Next() {
+		var i ListRevokedSessionsRow
+		if err := rows.Scan(&i.ID, &i.RevokedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRevokedTokens = `-- name: ListRevokedTokens :many
+SELECT jti, expires_at, revoked_at FROM revoked_tokens
+WHERE revoked_at > $1 AND expires_at > NOW()
+`
+
+func (q *Queries) ListRevokedTokens(ctx context.Context, revokedAt pgtype.Timestamptz) ([]RevokedToken, error) {
+	rows, err := q.db.Query(ctx, listRevokedTokens, revokedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RevokedToken{}
+	for rows.Next() {
+		var i RevokedToken
+		if err := rows.Scan(&i.Jti, &i.ExpiresAt, &i.RevokedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTags = `-- name: ListTags :many
+SELECT t.slug, t.name, COUNT(*) AS post_count
+FROM tags t
+JOIN post_tags pt ON pt.tag_id = t.id
+JOIN posts p ON pt.post_id = p.id
+WHERE p.status = 'published'
+GROUP BY t.id
+ORDER BY post_count DESC, t.slug
+LIMIT $1 OFFSET $2
+`
+
+type ListTagsParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+type ListTagsRow struct {
+	Slug      string `json:"slug"`
+	Name      string `json:"name"`
+	PostCount int64  `json:"post_count"`
+}
+
+// Counts published posts only; tags without any are left out.
+func (q *Queries) ListTags(ctx context.Context, arg ListTagsParams) ([]ListTagsRow, error) {
+	rows, err := q.db.Query(ctx, listTags, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTagsRow{}
+	for rows.Next() {
+		var i ListTagsRow
+		if err := rows.Scan(&i.Slug, &i.Name, &i.PostCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTakenPostSlugs = `-- name: ListTakenPostSlugs :many
+SELECT slug FROM posts
+WHERE (slug = $1 OR slug LIKE $1 || '-%') AND id <> $2
+UNION
+SELECT slug FROM post_slugs
+WHERE (slug = $1 OR slug LIKE $1 || '-%') AND post_id <> $2
+`
+
+type ListTakenPostSlugsParams struct {
+	Slug   string `json:"slug"`
+	PostID int32  `json:"post_id"`
+}
+
+// Returns the slugs equal to or starting with slug that other posts have or
+// had.
+func (q *Queries) ListTakenPostSlugs(ctx context.Context, arg ListTakenPostSlugsParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, listTakenPostSlugs, arg.Slug, arg.PostID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return nil, err
+		}
+		items = append(items, slug)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserComments = `-- name: ListUserComments :many
+SELECT id, post_id, parent_id, user_id, content, created_at, updated_at, deleted_at FROM comments
+WHERE user_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListUserComments(ctx context.Context, userID pgtype.Int4) ([]Comment, error) {
+	rows, err := q.db.Query(ctx, listUserComments, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Comment{}
+	for rows.Next() {
+		var i Comment
+		if err := rows.Scan(
+			&i.ID,
+			&i.PostID,
+			&i.ParentID,
+			&i.UserID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserPosts = `-- name: ListUserPosts :many
+SELECT id, user_id, title, content, created_at, updated_at, status, published_at, slug, format, content_html, rendered_with, revision, comments_locked FROM posts
+WHERE user_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListUserPosts(ctx context.Context, userID int32) ([]Post, error) {
+	rows, err := q.db.Query(ctx, listUserPosts, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Post{}
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.PublishedAt,
+			&i.Slug,
+			&i.Format,
+			&i.ContentHtml,
+			&i.RenderedWith,
+			&i.Revision,
+			&i.CommentsLocked,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserReactions = `-- name: ListUserReactions :many
+SELECT post_id, user_id, kind, created_at FROM post_reactions
+WHERE user_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListUserReactions(ctx context.Context, userID int32) ([]PostReaction, error) {
+	rows, err := q.db.Query(ctx, listUserReactions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PostReaction{}
+	for rows.Next() {
+		var i PostReaction
+		if err := rows.Scan(
+			&i.PostID,
+			&i.UserID,
+			&i.Kind,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserSessions = `-- name: ListUserSessions :many
+SELECT id, user_id, refresh_token_hash, is_revoked, expires_at, created_at, user_agent, client_ip, last_seen_at, revoked_at FROM sessions
+WHERE user_id = $1 AND is_revoked = false AND expires_at > NOW()
+ORDER BY last_seen_at DESC
+`
+
+func (q *Queries) ListUserSessions(ctx context.Context, userID int32) ([]Session, error) {
+	rows, err := q.db.Query(ctx, listUserSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.RefreshTokenHash,
+			&i.IsRevoked,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.UserAgent,
+			&i.ClientIp,
+			&i.LastSeenAt,
+			&i.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockLogin = `-- name: LockLogin :exec
+UPDATE login_failures
+SET locked_until = $3
+WHERE scope = $1 AND key = $2
+`
+
+type LockLoginParams struct {
+	Scope       string             `json:"scope"`
+	Key         string             `json:"key"`
+	LockedUntil pgtype.Timestamptz `json:"locked_until"`
+}
+
+func (q *Queries) LockLogin(ctx context.Context, arg LockLoginParams) error {
+	_, err := q.db.Exec(ctx, lockLogin, arg.Scope, arg.Key, arg.LockedUntil)
+	return err
+}
+
+const markUserEmailVerified = `-- name: MarkUserEmailVerified :one
+UPDATE users
+SET email_verified_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND email = $2
+RETURNING id, username, password_hash, created_at, updated_at, role, email, email_verified_at, totp_secret, totp_enabled_at, totp_last_step, deletion_scheduled_at, deleted_at
+`
+
+type MarkUserEmailVerifiedParams struct {
+	ID    int32       `json:"id"`
+	Email pgtype.Text `json:"email"`
+}
+
+func (q *Queries) MarkUserEmailVerified(ctx context.Context, arg MarkUserEmailVerifiedParams) (User, error) {
+	row := q.db.QueryRow(ctx, markUserEmailVerified, arg.ID, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.Email,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.DeletionScheduledAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const publishDuePosts = `-- name: PublishDuePosts :many
+UPDATE posts
+SET status = 'published', updated_at = NOW()
+WHERE status = 'scheduled' AND id IN (
+  SELECT id FROM posts
+  WHERE status = 'scheduled' AND published_at <= NOW()
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, user_id
+`
+
+type PublishDuePostsRow struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+// Each due post is claimed with SKIP LOCKED and its status checked again
+// when it is updated, so instances running this concurrently publish every
+// post exactly once.
+func (q *Queries) PublishDuePosts(ctx context.Context) ([]PublishDuePostsRow, error) {
+	rows, err := q.db.Query(ctx, publishDuePosts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PublishDuePostsRow{}
+	for rows.Next() {
+		var i PublishDuePostsRow
+		if err := rows.Scan(&i.ID, &i.UserID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordLoginFailure = `-- name: RecordLoginFailure :one
+INSERT INTO login_failures (scope, key, failures, last_failure_at)
+VALUES ($1, $2, 1, NOW())
+ON CONFLICT (scope, key) DO UPDATE
+SET failures = CASE
+    WHEN login_failures.last_failure_at < $3 THEN 1
+    ELSE login_failures.failures + 1
+  END,
+  last_failure_at = NOW()
+RETURNING failures
+`
+
+type RecordLoginFailureParams struct {
+	Scope       string             `json:"scope"`
+	Key         string             `json:"key"`
+	ResetBefore pgtype.Timestamptz `json:"reset_before"`
+}
+
+func (q *Queries) RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (int32, error) {
+	row := q.db.QueryRow(ctx, recordLoginFailure, arg.Scope, arg.Key, arg.ResetBefore)
+	var failures int32
+	err := row.Scan(&failures)
+	return failures, err
+}
+
+const rehashUserPassword = `-- name: RehashUserPassword :exec
+UPDATE users
+SET password_hash = $1
+WHERE id = $2 AND password_hash = $3
+`
+
+type RehashUserPasswordParams struct {
+	NewPasswordHash string `json:"new_password_hash"`
+	ID              int32  `json:"id"`
+	PasswordHash    string `json:"password_hash"`
+}
+
+func (q *Queries) RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) error {
+	_, err := q.db.Exec(ctx, rehashUserPassword, arg.NewPasswordHash, arg.ID, arg.PasswordHash)
+	return err
+}
+
+const removePostReaction = `-- name: RemovePostReaction :exec
+DELETE FROM post_reactions
+WHERE post_id = $1 AND user_id = $2 AND kind = $3
 `
 
-type CreatePostParams struct {
-	UserID  int32  `json:"user_id"`
-	Title   string `json:"title"`
-	Content string `json:"content"`
+type RemovePostReactionParams struct {
+	PostID int32  `json:"post_id"`
+	UserID int32  `json:"user_id"`
+	Kind   string `json:"kind"`
 }
 
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
-	row := q.db.QueryRow(ctx, createPost, arg.UserID, arg.Title, arg.Content)
-	var i Post
-	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.Title,
-		&i.Content,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+func (q *Queries) RemovePostReaction(ctx context.Context, arg RemovePostReactionParams) error {
+	_, err := q.db.Exec(ctx, removePostReaction, arg.PostID, arg.UserID, arg.Kind)
+	return err
 }
 
-const createUser = `-- name: CreateUser :one
+const revokeDueUserSessions = `-- name: RevokeDueUserSessions :execrows
+UPDATE sessions
+SET is_revoked = true, revoked_at = NOW()
+WHERE is_revoked = false AND user_id IN (
+  SELECT id FROM users WHERE deletion_scheduled_at <= NOW()
+)
+`
 
-INSERT INTO users (username, password_hash)
-VALUES ($1, $2)
-RETURNING id, username, password_hash, created_at, updated_at
+// Revokes the sessions of the accounts whose deletion grace period has ended,
+// ahead of DeleteDueUsers.
+func (q *Queries) RevokeDueUserSessions(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeDueUserSessions)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeOtherUserSessions = `-- name: RevokeOtherUserSessions :execrows
+UPDATE sessions
+SET is_revoked = true, revoked_at = NOW()
+WHERE user_id = $1 AND id <> $2 AND is_revoked = false
 `
 
-type CreateUserParams struct {
-	Username     string `json:"username"`
-	PasswordHash string `json:"password_hash"`
+type RevokeOtherUserSessionsParams struct {
+	UserID int32     `json:"user_id"`
+	ID     uuid.UUID `json:"id"`
 }
 
-// internal/db/query.sql
-func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRow(ctx, createUser, arg.Username, arg.PasswordHash)
-	var i User
-	err := row.Scan(
-		&i.ID,
-		&i.Username,
-		&i.PasswordHash,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+func (q *Queries) RevokeOtherUserSessions(ctx context.Context, arg RevokeOtherUserSessionsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeOtherUserSessions, arg.UserID, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-const deletePost = `-- name: DeletePost :exec
-DELETE FROM posts
-WHERE id = $1 AND user_id = $2
+const revokePersonalAccessToken = `-- name: RevokePersonalAccessToken :execrows
+UPDATE personal_access_tokens
+SET revoked_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
 `
 
-type DeletePostParams struct {
-	ID     int32 `json:"id"`
+type RevokePersonalAccessTokenParams struct {
+	ID     int64 `json:"id"`
 	UserID int32 `json:"user_id"`
 }
 
-func (q *Queries) DeletePost(ctx context.Context, arg DeletePostParams) error {
-	_, err := q.db.Exec(ctx, deletePost, arg.ID, arg.UserID)
+func (q *Queries) RevokePersonalAccessToken(ctx context.Context, arg RevokePersonalAccessTokenParams) (int64, error) {
+	result, err := q.db.Exec(ctx, revokePersonalAccessToken, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeSession = `-- name: RevokeSession :exec
+UPDATE sessions
+SET is_revoked = true, revoked_at = NOW()
+WHERE id = $1 AND is_revoked = false
+`
+
+func (q *Queries) RevokeSession(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, revokeSession, id)
 	return err
 }
 
-const getPostByID = `-- name: GetPostByID :one
-SELECT p.id, p.user_id, p.title, p.content, p.created_at, p.updated_at, u.username as author_username
-FROM posts p
-JOIN users u ON p.user_id = u.id
-WHERE p.id = $1 LIMIT 1
+const revokeToken = `-- name: RevokeToken :exec
+INSERT INTO revoked_tokens (jti, expires_at)
+VALUES ($1, $2)
+ON CONFLICT (jti) DO NOTHING
 `
 
-type GetPostByIDRow struct {
-	ID             int32              `json:"id"`
-	UserID         int32              `json:"user_id"`
-	Title          string             `json:"title"`
-	Content        string             `json:"content"`
-	CreatedAt      pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
-	AuthorUsername string             `json:"author_username"`
+type RevokeTokenParams struct {
+	Jti       uuid.UUID          `json:"jti"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
 }
 
-func (q *Queries) GetPostByID(ctx context.Context, id int32) (GetPostByIDRow, error) {
-	row := q.db.QueryRow(ctx, getPostByID, id)
-	var i GetPostByIDRow
-	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.Title,
-		&i.Content,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.AuthorUsername,
-	)
-	return i, err
+func (q *Queries) RevokeToken(ctx context.Context, arg RevokeTokenParams) error {
+	_, err := q.db.Exec(ctx, revokeToken, arg.Jti, arg.ExpiresAt)
+	return err
 }
 
-const getUserByID = `-- name: GetUserByID :one
-SELECT id, username, password_hash, created_at, updated_at FROM users
-WHERE id = $1 LIMIT 1
+const revokeUserPersonalAccessTokens = `-- name: RevokeUserPersonalAccessTokens :execrows
+UPDATE personal_access_tokens
+SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL
 `
 
-func (q *Queries) GetUserByID(ctx context.Context, id int32) (User, error) {
-	row := q.db.QueryRow(ctx, getUserByID, id)
-	var i User
+func (q *Queries) RevokeUserPersonalAccessTokens(ctx context.Context, userID int32) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeUserPersonalAccessTokens, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeUserSession = `-- name: RevokeUserSession :execrows
+UPDATE sessions
+SET is_revoked = true, revoked_at = NOW()
+WHERE id = $1 AND user_id = $2 AND is_revoked = false
+`
+
+type RevokeUserSessionParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID int32     `json:"user_id"`
+}
+
+func (q *Queries) RevokeUserSession(ctx context.Context, arg RevokeUserSessionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeUserSession, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeUserSessions = `-- name: RevokeUserSessions :exec
+UPDATE sessions
+SET is_revoked = true, revoked_at = NOW()
+WHERE user_id = $1 AND is_revoked = false
+`
+
+func (q *Queries) RevokeUserSessions(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, revokeUserSessions, userID)
+	return err
+}
+
+const rotateSessionToken = `-- name: RotateSessionToken :one
+UPDATE sessions
+SET refresh_token_hash = $1,
+  user_agent = $2,
+  client_ip = $3,
+  last_seen_at = NOW()
+WHERE id = $4
+  AND refresh_token_hash = $5
+  AND is_revoked = false
+RETURNING id, user_id, refresh_token_hash, is_revoked, expires_at, created_at, user_agent, client_ip, last_seen_at, revoked_at
+`
+
+type RotateSessionTokenParams struct {
+	NewRefreshTokenHash string    `json:"new_refresh_token_hash"`
+	UserAgent           string    `json:"user_agent"`
+	ClientIp            string    `json:"client_ip"`
+	ID                  uuid.UUID `json:"id"`
+	RefreshTokenHash    string    `json:"refresh_token_hash"`
+}
+
+func (q *Queries) RotateSessionToken(ctx context.Context, arg RotateSessionTokenParams) (Session, error) {
+	row := q.db.QueryRow(ctx, rotateSessionToken,
+		arg.NewRefreshTokenHash,
+		arg.UserAgent,
+		arg.ClientIp,
+		arg.ID,
+		arg.RefreshTokenHash,
+	)
+	var i Session
 	err := row.Scan(
 		&i.ID,
-		&i.Username,
-		&i.PasswordHash,
+		&i.UserID,
+		&i.RefreshTokenHash,
+		&i.IsRevoked,
+		&i.ExpiresAt,
 		&i.CreatedAt,
-		&i.UpdatedAt,
+		&i.UserAgent,
+		&i.ClientIp,
+		&i.LastSeenAt,
+		&i.RevokedAt,
 	)
 	return i, err
 }
 
-const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, username, password_hash, created_at, updated_at FROM users
-WHERE username = $1 LIMIT 1
+const scheduleUserDeletion = `-- name: ScheduleUserDeletion :one
+UPDATE users
+SET deletion_scheduled_at = $2, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, username, password_hash, created_at, updated_at, role, email, email_verified_at, totp_secret, totp_enabled_at, totp_last_step, deletion_scheduled_at, deleted_at
 `
 
-func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User, error) {
-	row := q.db.QueryRow(ctx, getUserByUsername, username)
+type ScheduleUserDeletionParams struct {
+	ID                  int32              `json:"id"`
+	DeletionScheduledAt pgtype.Timestamptz `json:"deletion_scheduled_at"`
+}
+
+func (q *Queries) ScheduleUserDeletion(ctx context.Context, arg ScheduleUserDeletionParams) (User, error) {
+	row := q.db.QueryRow(ctx, scheduleUserDeletion, arg.ID, arg.DeletionScheduledAt)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -142,50 +2357,74 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 		&i.PasswordHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.Email,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.DeletionScheduledAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
-const listPosts = `-- name: ListPosts :many
-SELECT p.id, p.user_id, p.title, p.content, p.created_at, p.updated_at, u.username as author_username
-FROM posts p
+const searchPosts = `-- name: SearchPosts :many
+SELECT p.id, p.title, p.slug, p.published_at, u.username AS author_username,
+  ts_rank(s.document, q.query) AS rank,
+  ts_headline(q.config, p.title, q.query, format('HighlightAll=true, StartSel=%s, StopSel=%s', chr(57344), chr(57345)))::text AS title_headline,
+  ts_headline(q.config, p.content, q.query, format('MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=" … ", StartSel=%s, StopSel=%s', chr(57344), chr(57345)))::text AS snippet
+FROM post_search s
+JOIN posts p ON s.post_id = p.id
 JOIN users u ON p.user_id = u.id
-ORDER BY p.created_at DESC
-LIMIT $1 OFFSET $2
+CROSS JOIN (
+  SELECT $1::regconfig AS config, websearch_to_tsquery($1::regconfig, $2) AS query
+) AS q
+WHERE s.document @@ q.query AND p.status = 'published'
+ORDER BY rank DESC, p.published_at DESC
+LIMIT $3 OFFSET $4
 `
 
-type ListPostsParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+type SearchPostsParams struct {
+	Config string `json:"config"`
+	Query  string `json:"query"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
 }
 
-type ListPostsRow struct {
+type SearchPostsRow struct {
 	ID             int32              `json:"id"`
-	UserID         int32              `json:"user_id"`
 	Title          string             `json:"title"`
-	Content        string             `json:"content"`
-	CreatedAt      pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	Slug           string             `json:"slug"`
+	PublishedAt    pgtype.Timestamptz `json:"published_at"`
 	AuthorUsername string             `json:"author_username"`
+	Rank           float32            `json:"rank"`
+	TitleHeadline  string             `json:"title_headline"`
+	Snippet        string             `json:"snippet"`
 }
 
-func (q *Queries) ListPosts(ctx context.Context, arg ListPostsParams) ([]ListPostsRow, error) {
-	rows, err := q.db.Query(ctx, listPosts, arg.Limit, arg.Offset)
+// Finds published posts matching a query in websearch_to_tsquery syntax, best
+// matches first. Matches in the headlines are wrapped in U+E000 and U+E001,
+// which the caller turns into markup after escaping the text. config must be
+// the configuration the documents are built with.
+func (q *Queries) SearchPosts(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error) {
+	rows, err := q.db.Query(ctx, searchPosts, arg.Config, arg.Query, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []ListPostsRow{}
+	items := []SearchPostsRow{}
 	for rows.Next() {
-		var i ListPostsRow
+		var i SearchPostsRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.UserID,
 			&i.Title,
-			&i.Content,
-			&i.CreatedAt,
-			&i.UpdatedAt,
+			&i.Slug,
+			&i.PublishedAt,
 			&i.AuthorUsername,
+			&i.Rank,
+			&i.TitleHeadline,
+			&i.Snippet,
 		); err != nil {
 			return nil, err
 		}
@@ -197,28 +2436,157 @@ func (q *Queries) ListPosts(ctx context.Context, arg ListPostsParams) ([]ListPos
 	return items, nil
 }
 
-const updatePost = `-- name: UpdatePost :one
+const setPostCommentsLocked = `-- name: SetPostCommentsLocked :exec
+UPDATE posts SET comments_locked = $2
+WHERE id = $1
+`
 
-UPDATE posts
-SET title = $2, content = $3, updated_at = NOW()
-WHERE id = $1 AND user_id = $4 -- Ensure user owns the post
-RETURNING id, user_id, title, content, created_at, updated_at
+type SetPostCommentsLockedParams struct {
+	ID             int32 `json:"id"`
+	CommentsLocked bool  `json:"comments_locked"`
+}
+
+func (q *Queries) SetPostCommentsLocked(ctx context.Context, arg SetPostCommentsLockedParams) error {
+	_, err := q.db.Exec(ctx, setPostCommentsLocked, arg.ID, arg.CommentsLocked)
+	return err
+}
+
+const setUserTOTPSecret = `-- name: SetUserTOTPSecret :execrows
+UPDATE users
+SET totp_secret = $2, updated_at = NOW()
+WHERE id = $1 AND totp_enabled_at IS NULL
 `
 
-type UpdatePostParams struct {
+type SetUserTOTPSecretParams struct {
+	ID         int32       `json:"id"`
+	TotpSecret pgtype.Text `json:"totp_secret"`
+}
+
+func (q *Queries) SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setUserTOTPSecret, arg.ID, arg.TotpSecret)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const touchIdentity = `-- name: TouchIdentity :exec
+UPDATE identities
+SET email = $2, last_login_at = NOW()
+WHERE id = $1
+`
+
+type TouchIdentityParams struct {
+	ID    int64       `json:"id"`
+	Email pgtype.Text `json:"email"`
+}
+
+func (q *Queries) TouchIdentity(ctx context.Context, arg TouchIdentityParams) error {
+	_, err := q.db.Exec(ctx, touchIdentity, arg.ID, arg.Email)
+	return err
+}
+
+const updateComment = `-- name: UpdateComment :one
+UPDATE comments
+SET content = $2, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, post_id, parent_id, user_id, content, created_at, updated_at, deleted_at
+`
+
+type UpdateCommentParams struct {
 	ID      int32  `json:"id"`
-	Title   string `json:"title"`
 	Content string `json:"content"`
-	UserID  int32  `json:"user_id"`
 }
 
-// For pagination
+func (q *Queries) UpdateComment(ctx context.Context, arg UpdateCommentParams) (Comment, error) {
+	row := q.db.QueryRow(ctx, updateComment, arg.ID, arg.Content)
+	var i Comment
+	err := row.Scan(
+		&i.ID,
+		&i.PostID,
+		&i.ParentID,
+		&i.UserID,
+		&i.Content,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const updatePost = `-- name: UpdatePost :one
+WITH history AS (
+  INSERT INTO post_slugs (slug, post_id)
+  SELECT slug, id FROM posts
+  WHERE id = $1 AND slug <> $6
+  ON CONFLICT (slug) DO NOTHING
+), updated AS (
+  UPDATE posts
+  SET title = $2, content = $3, status = $4, published_at = $5, slug = $6,
+    format = $7, content_html = $8, rendered_with = $9,
+    revision = revision + 1, updated_at = NOW()
+  WHERE id = $1
+  RETURNING id, user_id, title, content, created_at, updated_at, status, published_at, slug, format, content_html, rendered_with, revision, comments_locked
+), revisions AS (
+  INSERT INTO post_revisions (post_id, revision, user_id, title, content, format)
+  SELECT id, revision, $10::int, title, content, format FROM updated
+), input AS (
+  SELECT unnest($11::text[]) AS slug, unnest($12::text[]) AS name
+  WHERE $13::bool
+), wanted AS (
+  INSERT INTO tags (slug, name)
+  SELECT slug, name FROM input
+  ON CONFLICT (slug) DO UPDATE SET name = tags.name
+  RETURNING id
+), removed AS (
+  DELETE FROM post_tags
+  WHERE $13::bool AND post_id IN (SELECT id FROM updated)
+    AND tag_id NOT IN (SELECT id FROM wanted)
+), tagged AS (
+  INSERT INTO post_tags (post_id, tag_id)
+  SELECT updated.id, wanted.id FROM updated, wanted
+  ON CONFLICT DO NOTHING
+)
+SELECT id, user_id, title, content, created_at, updated_at, status, published_at, slug, format, content_html, rendered_with, revision, comments_locked FROM updated
+`
+
+type UpdatePostParams struct {
+	ID           int32              `json:"id"`
+	Title        string             `json:"title"`
+	Content      string             `json:"content"`
+	Status       string             `json:"status"`
+	PublishedAt  pgtype.Timestamptz `json:"published_at"`
+	Slug         string             `json:"slug"`
+	Format       string             `json:"format"`
+	ContentHtml  pgtype.Text        `json:"content_html"`
+	RenderedWith pgtype.Text        `json:"rendered_with"`
+	EditorID     int32              `json:"editor_id"`
+	TagSlugs     []string           `json:"tag_slugs"`
+	TagNames     []string           `json:"tag_names"`
+	SetTags      bool               `json:"set_tags"`
+}
+
+// A replaced slug is kept in post_slugs, so links to it keep working. The
+// new version is stored as the next revision, saved by editor_id. When
+// set_tags is true, the tags of the post are replaced in the same statement,
+// creating the ones that do not exist yet. The no-op update makes ON
+// CONFLICT return existing tags too, even one created by a concurrent
+// request.
 func (q *Queries) UpdatePost(ctx context.Context, arg UpdatePostParams) (Post, error) {
 	row := q.db.QueryRow(ctx, updatePost,
 		arg.ID,
 		arg.Title,
 		arg.Content,
-		arg.UserID,
+		arg.Status,
+		arg.PublishedAt,
+		arg.Slug,
+		arg.Format,
+		arg.ContentHtml,
+		arg.RenderedWith,
+		arg.EditorID,
+		arg.TagSlugs,
+		arg.TagNames,
+		arg.SetTags,
 	)
 	var i Post
 	err := row.Scan(
@@ -228,6 +2596,215 @@ func (q *Queries) UpdatePost(ctx context.Context, arg UpdatePostParams) (Post, e
 		&i.Content,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Status,
+		&i.PublishedAt,
+		&i.Slug,
+		&i.Format,
+		&i.ContentHtml,
+		&i.RenderedWith,
+		&i.Revision,
+		&i.CommentsLocked,
+	)
+	return i, err
+}
+
+const updatePostContentHTML = `-- name: UpdatePostContentHTML :exec
+UPDATE posts
+SET content_html = $2, rendered_with = $3
+WHERE id = $1 AND updated_at = $4
+`
+
+type UpdatePostContentHTMLParams struct {
+	ID           int32              `json:"id"`
+	ContentHtml  pgtype.Text        `json:"content_html"`
+	RenderedWith pgtype.Text        `json:"rendered_with"`
+	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+}
+
+// Leaves a post alone that was edited after it was listed; the edit rendered
+// it already.
+func (q *Queries) UpdatePostContentHTML(ctx context.Context, arg UpdatePostContentHTMLParams) error {
+	_, err := q.db.Exec(ctx, updatePostContentHTML,
+		arg.ID,
+		arg.ContentHtml,
+		arg.RenderedWith,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+const updateSearchConfig = `-- name: UpdateSearchConfig :execrows
+WITH changed AS (
+  UPDATE search_settings SET config = $1::regconfig
+  WHERE config <> $1::regconfig
+  RETURNING config
+)
+UPDATE post_search s
+SET document = setweight(to_tsvector(changed.config, p.title), 'A') || setweight(to_tsvector(changed.config, p.content), 'B')
+FROM posts p, changed
+WHERE s.post_id = p.id
+`
+
+// Sets the text search configuration of the post_search documents and, if it
+// changed, rebuilds them. Returns the number of documents rebuilt.
+func (q *Queries) UpdateSearchConfig(ctx context.Context, config string) (int64, error) {
+	result, err := q.db.Exec(ctx, updateSearchConfig, config)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateUserEmail = `-- name: UpdateUserEmail :one
+UPDATE users
+SET email = $2, email_verified_at = NULL, updated_at = NOW()
+WHERE id = $1
+RETURNING id, username, password_hash, created_at, updated_at, role, email, email_verified_at, totp_secret, totp_enabled_at, totp_last_step, deletion_scheduled_at, deleted_at
+`
+
+type UpdateUserEmailParams struct {
+	ID    int32       `json:"id"`
+	Email pgtype.Text `json:"email"`
+}
+
+func (q *Queries) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserEmail, arg.ID, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.Email,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.DeletionScheduledAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users
+SET password_hash = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateUserPasswordParams struct {
+	ID           int32  `json:"id"`
+	PasswordHash string `json:"password_hash"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.Exec(ctx, updateUserPassword, arg.ID, arg.PasswordHash)
+	return err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, username, password_hash, created_at, updated_at, role, email, email_verified_at, totp_secret, totp_enabled_at, totp_last_step, deletion_scheduled_at, deleted_at
+`
+
+type UpdateUserRoleParams struct {
+	ID   int32  `json:"id"`
+	Role string `json:"role"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserRole, arg.ID, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.Email,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.DeletionScheduledAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const usePersonalAccessToken = `-- name: UsePersonalAccessToken :one
+UPDATE personal_access_tokens AS t
+SET last_used_at = NOW()
+FROM users AS u
+WHERE t.token_hash = $1
+  AND t.user_id = u.id
+  AND t.revoked_at IS NULL
+  AND (t.expires_at IS NULL OR t.expires_at > NOW())
+RETURNING t.id, t.user_id, t.scopes, t.expires_at, u.username, u.role
+`
+
+type UsePersonalAccessTokenRow struct {
+	ID        int64              `json:"id"`
+	UserID    int32              `json:"user_id"`
+	Scopes    []string           `json:"scopes"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	Username  string             `json:"username"`
+	Role      string             `json:"role"`
+}
+
+func (q *Queries) UsePersonalAccessToken(ctx context.Context, tokenHash string) (UsePersonalAccessTokenRow, error) {
+	row := q.db.QueryRow(ctx, usePersonalAccessToken, tokenHash)
+	var i UsePersonalAccessTokenRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.Username,
+		&i.Role,
 	)
 	return i, err
 }
+
+const useRecoveryCode = `-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+`
+
+type UseRecoveryCodeParams struct {
+	UserID   int32  `json:"user_id"`
+	CodeHash string `json:"code_hash"`
+}
+
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, useRecoveryCode, arg.UserID, arg.CodeHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const useTOTPStep = `-- name: UseTOTPStep :execrows
+UPDATE users
+SET totp_last_step = $2
+WHERE id = $1 AND totp_last_step < $2
+`
+
+type UseTOTPStepParams struct {
+	ID           int32 `json:"id"`
+	TotpLastStep int64 `json:"totp_last_step"`
+}
+
+func (q *Queries) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error) {
+	result, err := q.db.Exec(ctx, useTOTPStep, arg.ID, arg.TotpLastStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
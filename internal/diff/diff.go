@@ -0,0 +1,87 @@
+// Package diff compares two versions of a text.
+package diff
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Chunk operations.
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// Chunk is a piece of text that both versions share, or that only the old
+// one (a deletion) or only the new one (an insertion) has. Joining the equal
+// and deleted chunks in order gives the old text, joining the equal and
+// inserted ones the new text.
+type Chunk struct {
+	Op   string `json:"op" enums:"equal,insert,delete"`
+	Text string `json:"text"`
+}
+
+// Lines compares a and b line by line.
+func Lines(a, b string) []Chunk {
+	return compare(strings.SplitAfter(a, "\n"), strings.SplitAfter(b, "\n"))
+}
+
+// Words compares a and b word by word. Runs of whitespace count as words of
+// their own, so a change in spacing shows up too.
+func Words(a, b string) []Chunk {
+	return compare(splitWords(a), splitWords(b))
+}
+
+func compare(a, b []string) []Chunk {
+	// Without autojunk, frequent tokens such as blank lines or "the" are
+	// matched like any other.
+	matcher := difflib.NewMatcherWithJunk(a, b, false, nil)
+	chunks := []Chunk{}
+	add := func(op string, tokens []string) {
+		text := strings.Join(tokens, "")
+		if text == "" {
+			return
+		}
+		if n := len(chunks); n > 0 && chunks[n-1].Op == op {
+			chunks[n-1].Text += text
+			return
+		}
+		chunks = append(chunks, Chunk{Op: op, Text: text})
+	}
+	for _, op := range matcher.GetOpCodes() {
+		switch op.Tag {
+		case 'e':
+			add(OpEqual, a[op.I1:op.I2])
+		case 'd':
+			add(OpDelete, a[op.I1:op.I2])
+		case 'i':
+			add(OpInsert, b[op.J1:op.J2])
+		case 'r':
+			add(OpDelete, a[op.I1:op.I2])
+			add(OpInsert, b[op.J1:op.J2])
+		}
+	}
+	return chunks
+}
+
+// splitWords splits s into alternating runs of whitespace and of other
+// characters.
+func splitWords(s string) []string {
+	var words []string
+	start := 0
+	space := false
+	for i, r := range s {
+		if i > 0 && unicode.IsSpace(r) != space {
+			words = append(words, s[start:i])
+			start = i
+		}
+		space = unicode.IsSpace(r)
+	}
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+	return words
+}
@@ -0,0 +1,60 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// join rebuilds the old or the new text from chunks.
+func join(chunks []Chunk, skip string) string {
+	var b strings.Builder
+	for _, chunk := range chunks {
+		if chunk.Op != skip {
+			b.WriteString(chunk.Text)
+		}
+	}
+	return b.String()
+}
+
+func TestLines(t *testing.T) {
+	a := "first\nsecond\nthird\n"
+	b := "first\nchanged\nthird\nfourth"
+
+	chunks := Lines(a, b)
+	require.Equal(t, []Chunk{
+		{Op: OpEqual, Text: "first\n"},
+		{Op: OpDelete, Text: "second\n"},
+		{Op: OpInsert, Text: "changed\n"},
+		{Op: OpEqual, Text: "third\n"},
+		{Op: OpInsert, Text: "fourth"},
+	}, chunks)
+	require.Equal(t, a, join(chunks, OpInsert))
+	require.Equal(t, b, join(chunks, OpDelete))
+}
+
+func TestWords(t *testing.T) {
+	a := "The quick brown fox jumps"
+	b := "The  quick red fox jumps high"
+
+	chunks := Words(a, b)
+	require.Equal(t, []Chunk{
+		{Op: OpEqual, Text: "The"},
+		{Op: OpDelete, Text: " "},
+		{Op: OpInsert, Text: "  "},
+		{Op: OpEqual, Text: "quick "},
+		{Op: OpDelete, Text: "brown"},
+		{Op: OpInsert, Text: "red"},
+		{Op: OpEqual, Text: " fox jumps"},
+		{Op: OpInsert, Text: " high"},
+	}, chunks)
+	require.Equal(t, a, join(chunks, OpInsert))
+	require.Equal(t, b, join(chunks, OpDelete))
+}
+
+func TestSame(t *testing.T) {
+	require.Equal(t, []Chunk{{Op: OpEqual, Text: "same\ntext"}}, Lines("same\ntext", "same\ntext"))
+	require.Empty(t, Words("", ""))
+	require.Equal(t, []Chunk{{Op: OpInsert, Text: "new words"}}, Words("", "new words"))
+}
@@ -0,0 +1,49 @@
+package mail
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileSender writes every email as an .eml file into a directory instead of
+// delivering it, for local development and tests.
+type FileSender struct {
+	dir  string
+	from string
+}
+
+func NewFileSender(dir, from string) (*FileSender, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileSender{dir: dir, from: from}, nil
+}
+
+func (sender *FileSender) Send(ctx context.Context, msg Message) error {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	name := time.Now().UTC().Format("20060102T150405.000000Z") + "-" + hex.EncodeToString(suffix) + ".eml"
+	path := filepath.Join(sender.dir, name)
+	if err := os.WriteFile(path, formatMessage(sender.from, msg), 0o600); err != nil {
+		return err
+	}
+	log.Printf("Wrote email to %s (%q) to %s", msg.To, msg.Subject, path)
+	return nil
+}
+
+// LogSender writes every email to the application log instead of delivering
+// it. Bodies can contain secrets such as reset links, so it must not be used
+// in production.
+type LogSender struct{}
+
+func (LogSender) Send(ctx context.Context, msg Message) error {
+	log.Printf("Email to %s\nSubject: %s\n\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
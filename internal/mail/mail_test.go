@@ -0,0 +1,103 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSender(t *testing.T) {
+	dir := t.TempDir()
+	sender, err := NewFileSender(dir, "Plog <no-reply@plog.local>")
+	require.NoError(t, err)
+
+	msg := Message{To: "user@example.com", Subject: "Hello", Body: "line one\nline two"}
+	require.NoError(t, sender.Send(context.Background(), msg))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	content := string(data)
+	require.Contains(t, content, "From: Plog <no-reply@plog.local>\r\n")
+	require.Contains(t, content, "To: user@example.com\r\n")
+	require.Contains(t, content, "Subject: Hello\r\n")
+	require.True(t, strings.HasSuffix(content, "\r\n\r\nline one\r\nline two"))
+}
+
+func TestSMTPSender(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	// A minimal SMTP server that accepts one message and records the
+	// commands it was sent.
+	commands := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var received []string
+		reader := bufio.NewReader(conn)
+		fmt.Fprint(conn, "220 localhost ready\r\n")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				break
+			}
+			line = strings.TrimRight(line, "\r\n")
+			received = append(received, line)
+			switch verb, _, _ := strings.Cut(line, " "); strings.ToUpper(verb) {
+			case "EHLO", "HELO":
+				fmt.Fprint(conn, "250 localhost\r\n")
+			case "DATA":
+				fmt.Fprint(conn, "354 go ahead\r\n")
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					received = append(received, strings.TrimRight(line, "\r\n"))
+				}
+				fmt.Fprint(conn, "250 queued\r\n")
+			case "QUIT":
+				fmt.Fprint(conn, "221 bye\r\n")
+				commands <- received
+				return
+			default:
+				fmt.Fprint(conn, "250 ok\r\n")
+			}
+		}
+		commands <- received
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	sender, err := NewSMTPSender("127.0.0.1", addr.Port, "", "", "Plog <no-reply@plog.local>")
+	require.NoError(t, err)
+
+	msg := Message{To: "user@example.com", Subject: "Hello", Body: "line one"}
+	require.NoError(t, sender.Send(context.Background(), msg))
+
+	received := <-commands
+	require.Contains(t, received, "MAIL FROM:<no-reply@plog.local>")
+	require.Contains(t, received, "RCPT TO:<user@example.com>")
+	require.Contains(t, received, "From: Plog <no-reply@plog.local>")
+	require.Contains(t, received, "line one")
+}
+
+func TestNewSMTPSenderInvalidFrom(t *testing.T) {
+	_, err := NewSMTPSender("localhost", 25, "", "", "Plog no-reply")
+	require.Error(t, err)
+}
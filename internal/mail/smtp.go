@@ -0,0 +1,75 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPSender delivers emails through an SMTP server. The connection is
+// upgraded with STARTTLS when the server offers it; PLAIN authentication is
+// used when a username is set.
+type SMTPSender struct {
+	addr string
+	auth smtp.Auth
+	from string
+	// envelopeFrom is the bare address of from, for the MAIL FROM command.
+	envelopeFrom string
+}
+
+// NewSMTPSender creates an SMTPSender. from may include a display name, as in
+// "Plog <no-reply@example.com>".
+func NewSMTPSender(host string, port int, username, password, from string) (*SMTPSender, error) {
+	address, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", from, err)
+	}
+
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPSender{
+		addr:         net.JoinHostPort(host, fmt.Sprint(port)),
+		auth:         auth,
+		from:         from,
+		envelopeFrom: address.Address,
+	}, nil
+}
+
+func (sender *SMTPSender) Send(ctx context.Context, msg Message) error {
+	// net/smtp has no context support, so only the deadline is honoured.
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(sender.addr, sender.auth, sender.envelopeFrom, []string{msg.To}, formatMessage(sender.from, msg))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// formatMessage renders msg as an RFC 5322 message.
+func formatMessage(from string, msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue(from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// headerValue strips line breaks so values cannot inject extra headers.
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
@@ -0,0 +1,83 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/lshigami/Plog/internal/oidc/oidctest"
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURL = "http://plog.test/api/v1/oidc/test/callback"
+
+// authorize follows the issuer's authorization redirect and returns the code
+// and state it sends back.
+func authorize(t *testing.T, authURL string) (string, string) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	rsp, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer rsp.Body.Close()
+	require.Equal(t, http.StatusFound, rsp.StatusCode)
+
+	location, err := url.Parse(rsp.Header.Get("Location"))
+	require.NoError(t, err)
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func newTestProvider(issuer *oidctest.Issuer, clientSecret string) *Provider {
+	return NewProvider(Config{
+		Name:         "test",
+		IssuerURL:    issuer.URL,
+		ClientID:     oidctest.ClientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+	})
+}
+
+func TestProviderFlow(t *testing.T) {
+	issuer := oidctest.NewIssuer(t, oidctest.User{
+		Subject:           "user-1",
+		Email:             "user@example.com",
+		EmailVerified:     true,
+		PreferredUsername: "jane",
+	})
+	provider := newTestProvider(issuer, oidctest.ClientSecret)
+	ctx := context.Background()
+
+	verifier := GenerateVerifier()
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	require.NoError(t, err)
+
+	code, state := authorize(t, authURL)
+	require.Equal(t, "state-1", state)
+
+	claims, err := provider.Exchange(ctx, code, "nonce-1", verifier)
+	require.NoError(t, err)
+	require.Equal(t, "user-1", claims.Subject)
+	require.Equal(t, "user@example.com", claims.Email)
+	require.True(t, claims.EmailVerified)
+	require.Equal(t, "jane", claims.PreferredUsername)
+}
+
+func TestProviderRejectsWrongVerifierAndNonce(t *testing.T) {
+	issuer := oidctest.NewIssuer(t, oidctest.User{Subject: "user-1"})
+	provider := newTestProvider(issuer, oidctest.ClientSecret)
+	ctx := context.Background()
+
+	verifier := GenerateVerifier()
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	require.NoError(t, err)
+	code, _ := authorize(t, authURL)
+	_, err = provider.Exchange(ctx, code, "nonce-1", GenerateVerifier())
+	require.Error(t, err)
+
+	authURL, err = provider.AuthCodeURL(ctx, "state-2", "nonce-2", verifier)
+	require.NoError(t, err)
+	code, _ = authorize(t, authURL)
+	_, err = provider.Exchange(ctx, code, "another-nonce", verifier)
+	require.ErrorIs(t, err, ErrInvalidIDToken)
+}
@@ -0,0 +1,185 @@
+// Package render turns post content into HTML that is safe to show in a
+// browser.
+package render
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/lshigami/Plog/internal/slug"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// Content formats.
+const (
+	FormatMarkdown = "markdown"
+	FormatPlain    = "plain"
+	FormatHTML     = "html"
+)
+
+// Formats lists every content format.
+var Formats = []string{FormatMarkdown, FormatPlain, FormatHTML}
+
+// version changes whenever the same content and options render to different
+// HTML, so that Fingerprint tells stored HTML to render again.
+const version = "2"
+
+// headingIDPrefix keeps heading anchors apart from the IDs of the page
+// around the post.
+const headingIDPrefix = "h-"
+
+var (
+	headingIDRegexp = regexp.MustCompile(`^h-[\p{L}\p{N}-]+$`)
+	blankLineRegexp = regexp.MustCompile(`\n\s*\n`)
+)
+
+// unsafeElements can run scripts, load other documents or take input, so
+// they cannot be allowed even on request.
+var unsafeElements = []string{
+	"base", "button", "embed", "form", "frame", "frameset", "iframe", "input",
+	"link", "math", "meta", "object", "script", "select", "style", "svg",
+	"template", "textarea",
+}
+
+// Options configure what HTML a Renderer lets through.
+type Options struct {
+	// Basic allows only text formatting, links, lists, quotes and code.
+	// Otherwise everything common in user content is allowed, including
+	// images and tables.
+	Basic bool
+	// ExtraElements are allowed in addition, without attributes.
+	ExtraElements []string
+}
+
+// Renderer renders and sanitizes post content. It is safe for concurrent use.
+type Renderer struct {
+	markdown    goldmark.Markdown
+	policy      *bluemonday.Policy
+	fingerprint string
+}
+
+// New returns a Renderer with the given options.
+func New(opts Options) (*Renderer, error) {
+	for _, element := range opts.ExtraElements {
+		if slices.Contains(unsafeElements, element) {
+			return nil, fmt.Errorf("element %q cannot be allowed", element)
+		}
+	}
+
+	var policy *bluemonday.Policy
+	if opts.Basic {
+		policy = bluemonday.NewPolicy()
+		policy.AllowStandardURLs()
+		policy.AllowAttrs("href").OnElements("a")
+		policy.RequireNoFollowOnLinks(true)
+		policy.AllowElements(
+			"p", "br", "hr", "h1", "h2", "h3", "h4", "h5", "h6",
+			"b", "strong", "i", "em", "u", "s", "del", "sub", "sup", "mark",
+			"ul", "ol", "li", "blockquote", "code", "pre", "span",
+		)
+	} else {
+		policy = ugcPolicy()
+	}
+	policy.AllowAttrs("id").Matching(headingIDRegexp).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	// Highlighted code is colored with inline styles.
+	policy.AllowStyles("color", "background-color", "font-weight", "font-style", "text-decoration").
+		OnElements("span", "pre")
+	policy.AllowElements(opts.ExtraElements...)
+
+	markdown := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			highlighting.NewHighlighting(
+				highlighting.WithStyle("github"),
+				highlighting.WithFormatOptions(chromahtml.WithClasses(false)),
+			),
+		),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		// Raw HTML is kept here and sanitized with everything else.
+		goldmark.WithRendererOptions(goldmarkhtml.WithUnsafe()),
+	)
+
+	extras := slices.Clone(opts.ExtraElements)
+	slices.Sort(extras)
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%t|%s", version, opts.Basic, strings.Join(extras, ","))))
+
+	return &Renderer{
+		markdown:    markdown,
+		policy:      policy,
+		fingerprint: hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+// Fingerprint identifies the renderer's settings. HTML rendered by a renderer
+// with another fingerprint may differ and should be rendered again.
+func (r *Renderer) Fingerprint() string {
+	return r.fingerprint
+}
+
+// Render returns the sanitized HTML of content written in format.
+func (r *Renderer) Render(format, content string) (string, error) {
+	switch format {
+	case FormatMarkdown:
+		var buf bytes.Buffer
+		ctx := parser.NewContext(parser.WithIDs(&headingIDs{used: map[string]bool{}}))
+		if err := r.markdown.Convert([]byte(content), &buf, parser.WithContext(ctx)); err != nil {
+			return "", err
+		}
+		return r.policy.Sanitize(buf.String()), nil
+	case FormatPlain:
+		return renderPlain(content), nil
+	case FormatHTML:
+		return r.policy.Sanitize(content), nil
+	default:
+		return "", fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// renderPlain escapes text and keeps its paragraphs and line breaks.
+func renderPlain(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var b strings.Builder
+	for _, paragraph := range blankLineRegexp.Split(strings.TrimSpace(text), -1) {
+		if paragraph == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>\n"))
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
+
+// headingIDs gives headings anchors made from their text, numbered when a
+// post repeats a heading.
+type headingIDs struct {
+	used map[string]bool
+}
+
+func (ids *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	base := headingIDPrefix + slug.Make(string(value))
+	id := base
+	for n := 2; ids.used[id]; n++ {
+		id = base + "-" + strconv.Itoa(n)
+	}
+	ids.used[id] = true
+	return []byte(id)
+}
+
+func (ids *headingIDs) Put(value []byte) {
+	ids.used[string(value)] = true
+}
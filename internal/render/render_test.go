@@ -0,0 +1,136 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	r, err := New(Options{})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		content  string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "Headings",
+			content:  "# Hello World\n\n## Hello World\n\n## Привет мир",
+			contains: []string{`<h1 id="h-hello-world">`, `<h2 id="h-hello-world-2">`, `<h2 id="h-privet-mir">`},
+		},
+		{
+			name:     "Emphasis",
+			content:  "Some **bold** and _italic_ text",
+			contains: []string{"<strong>bold</strong>", "<em>italic</em>"},
+		},
+		{
+			name:     "Script",
+			content:  "Hi <script>alert(1)</script> there",
+			excludes: []string{"<script", "alert(1)"},
+		},
+		{
+			name:     "EventHandler",
+			content:  `<img src="/cat.png" onerror="alert(1)">`,
+			contains: []string{`<img src="/cat.png">`},
+			excludes: []string{"onerror"},
+		},
+		{
+			name:     "JavaScriptLink",
+			content:  "[click](javascript:alert(1))",
+			excludes: []string{"javascript:"},
+		},
+		{
+			name:     "CodeBlock",
+			content:  "```go\nfunc main() {}\n```",
+			contains: []string{`<pre style="background-color: #fff">`, `<span style="color: #cf222e">func</span>`},
+		},
+		{
+			name:     "Table",
+			content:  "| a | b |\n|---|---|\n| 1 | 2 |",
+			contains: []string{"<table>", "<td>1</td>"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			html, err := r.Render(FormatMarkdown, tc.content)
+			require.NoError(t, err)
+			for _, s := range tc.contains {
+				require.Contains(t, html, s)
+			}
+			for _, s := range tc.excludes {
+				require.NotContains(t, html, s)
+			}
+		})
+	}
+}
+
+func TestRenderPlain(t *testing.T) {
+	r, err := New(Options{})
+	require.NoError(t, err)
+
+	html, err := r.Render(FormatPlain, "a < b\r\nc\n\n\n# d & <b>e</b>\n")
+	require.NoError(t, err)
+	require.Equal(t, "<p>a &lt; b<br>\nc</p>\n<p># d &amp; &lt;b&gt;e&lt;/b&gt;</p>\n", html)
+}
+
+func TestRenderHTML(t *testing.T) {
+	r, err := New(Options{})
+	require.NoError(t, err)
+
+	html, err := r.Render(FormatHTML, `<p onclick="x()">**not markdown**</p><iframe src="https://example.com"></iframe>`)
+	require.NoError(t, err)
+	require.Equal(t, "<p>**not markdown**</p>", html)
+
+	_, err = r.Render("rtf", "text")
+	require.Error(t, err)
+}
+
+func TestElementIDs(t *testing.T) {
+	for _, opts := range []Options{{}, {Basic: true}} {
+		r, err := New(opts)
+		require.NoError(t, err)
+
+		html, err := r.Render(FormatHTML, `<div id="root"><p id="h-intro" title="Intro">Hi</p></div><h2 id="root">A</h2><h2 id="h-b">B</h2>`)
+		require.NoError(t, err)
+		require.NotContains(t, html, `id="root"`)
+		require.NotContains(t, html, `<p id=`)
+		require.Contains(t, html, `<h2 id="h-b">B</h2>`)
+		if !opts.Basic {
+			require.Contains(t, html, `<div><p title="Intro">Hi</p></div><h2>A</h2>`)
+		}
+	}
+}
+
+func TestBasicPolicy(t *testing.T) {
+	r, err := New(Options{Basic: true, ExtraElements: []string{"abbr"}})
+	require.NoError(t, err)
+
+	html, err := r.Render(FormatMarkdown, "# Title\n\n![cat](/cat.png) [link](https://example.com) <abbr>HTML</abbr>")
+	require.NoError(t, err)
+	require.Contains(t, html, `<h1 id="h-title">Title</h1>`)
+	require.Contains(t, html, `<a href="https://example.com" rel="nofollow">link</a>`)
+	require.Contains(t, html, "<abbr>HTML</abbr>")
+	require.NotContains(t, html, "<img")
+}
+
+func TestNewRejectsUnsafeElements(t *testing.T) {
+	_, err := New(Options{ExtraElements: []string{"script"}})
+	require.Error(t, err)
+}
+
+func TestFingerprint(t *testing.T) {
+	ugc, err := New(Options{ExtraElements: []string{"abbr", "kbd"}})
+	require.NoError(t, err)
+	same, err := New(Options{ExtraElements: []string{"kbd", "abbr"}})
+	require.NoError(t, err)
+	basic, err := New(Options{Basic: true, ExtraElements: []string{"abbr", "kbd"}})
+	require.NoError(t, err)
+
+	require.Equal(t, ugc.Fingerprint(), same.Fingerprint())
+	require.NotEqual(t, ugc.Fingerprint(), basic.Fingerprint())
+	require.LessOrEqual(t, len(ugc.Fingerprint()), 64)
+}
@@ -0,0 +1,89 @@
+// Package slug turns titles into URL slugs.
+package slug
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Fallback is the slug of a title without any letters or digits.
+const Fallback = "post"
+
+// maxLength is the longest slug Make returns, in runes.
+const maxLength = 80
+
+// transliterations covers the letters that do not decompose into an ASCII
+// letter and accents: a few Latin ones, Cyrillic and Greek. Letters of other
+// scripts are kept as they are.
+var transliterations = map[rune]string{
+	'đ': "d", 'ð': "d", 'ł': "l", 'ø': "o", 'ı': "i", 'ß': "ss",
+	'æ': "ae", 'œ': "oe", 'þ': "th",
+
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh",
+	'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "",
+	'э': "e", 'ю': "iu", 'я': "ia", 'є': "ie", 'і': "i", 'ґ': "g",
+
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i",
+	'θ': "th", 'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x",
+	'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y",
+	'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+}
+
+// Make returns the slug of title: lowercase, accents removed, Cyrillic and
+// Greek transliterated to Latin letters, and every run of other characters
+// than letters and digits replaced with a single hyphen. Letters of scripts
+// without a transliteration, such as Chinese, are kept. Make never returns
+// an empty string.
+func Make(title string) string {
+	var b strings.Builder
+	length := 0
+	hyphen := false
+	dropMarks := false
+	// Decomposing first splits accented letters into the letter and its
+	// combining marks, which are dropped for the scripts written in Latin
+	// letters in the slug.
+	for _, r := range norm.NFD.String(strings.ToLower(title)) {
+		if unicode.Is(unicode.Mn, r) {
+			if !dropMarks {
+				b.WriteRune(r)
+			}
+			continue
+		}
+		dropMarks = !unicode.IsLetter(r) || unicode.In(r, unicode.Latin, unicode.Cyrillic, unicode.Greek)
+		s, ok := transliterations[r]
+		if !ok {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				hyphen = length > 0
+				continue
+			}
+			s = string(r)
+		}
+		if s == "" {
+			continue
+		}
+		n := utf8.RuneCountInString(s)
+		if hyphen {
+			n++
+		}
+		if length+n > maxLength {
+			break
+		}
+		if hyphen {
+			b.WriteByte('-')
+			hyphen = false
+		}
+		b.WriteString(s)
+		length += n
+	}
+
+	if b.Len() == 0 {
+		return Fallback
+	}
+	// Compose what is left, so the same title always gives the same bytes.
+	return norm.NFC.String(b.String())
+}
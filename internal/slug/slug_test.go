@@ -0,0 +1,38 @@
+package slug
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMake(t *testing.T) {
+	testCases := []struct {
+		title    string
+		expected string
+	}{
+		{title: "Hello, World!", expected: "hello-world"},
+		{title: "  Go 1.24 released  ", expected: "go-1-24-released"},
+		{title: "Bài viết đầu tiên", expected: "bai-viet-dau-tien"},
+		{title: "Crème brûlée für Straße", expected: "creme-brulee-fur-strasse"},
+		{title: "Привет, мир", expected: "privet-mir"},
+		{title: "Καλημέρα κόσμε", expected: "kalimera-kosme"},
+		{title: "日本語のブログ", expected: "日本語のブログ"},
+		{title: "한국어 제목", expected: "한국어-제목"},
+		{title: "🎉 !!! 🎉", expected: Fallback},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			require.Equal(t, tc.expected, Make(tc.title))
+		})
+	}
+}
+
+func TestMakeTruncates(t *testing.T) {
+	slug := Make(strings.Repeat("word ", 100))
+	require.LessOrEqual(t, utf8.RuneCountInString(slug), maxLength)
+	require.False(t, strings.HasSuffix(slug, "-"))
+}